@@ -8,9 +8,11 @@ package main
 import (
 	"errors"
 	"fmt"
+	"io/fs"
 	"log/slog"
 	"net/http"
 	"os"
+	"strconv"
 	"time"
 
 	"github.com/dgraph-io/ristretto/v2"
@@ -20,6 +22,7 @@ import (
 	"github.com/kemadev/go-framework/pkg/client/database"
 	"github.com/kemadev/go-framework/pkg/client/search"
 	"github.com/kemadev/go-framework/pkg/config"
+	"github.com/kemadev/go-framework/pkg/convenience/headkey"
 	"github.com/kemadev/go-framework/pkg/convenience/headval"
 	"github.com/kemadev/go-framework/pkg/convenience/log"
 	"github.com/kemadev/go-framework/pkg/convenience/otel"
@@ -27,14 +30,24 @@ import (
 	"github.com/kemadev/go-framework/pkg/convenience/resp"
 	"github.com/kemadev/go-framework/pkg/convenience/sechead"
 	"github.com/kemadev/go-framework/pkg/convenience/trace"
+	"github.com/kemadev/go-framework/pkg/cors"
+	"github.com/kemadev/go-framework/pkg/csrf"
 	"github.com/kemadev/go-framework/pkg/encoding"
+	"github.com/kemadev/go-framework/pkg/iputil"
+	"github.com/kemadev/go-framework/pkg/loadshed"
 	flog "github.com/kemadev/go-framework/pkg/log"
 	"github.com/kemadev/go-framework/pkg/maxbytes"
 	"github.com/kemadev/go-framework/pkg/monitoring"
 	"github.com/kemadev/go-framework/pkg/otelfailsafe"
+	"github.com/kemadev/go-framework/pkg/ratelimit"
+	"github.com/kemadev/go-framework/pkg/realip"
 	"github.com/kemadev/go-framework/pkg/router"
 	"github.com/kemadev/go-framework/pkg/server"
+	"github.com/kemadev/go-framework/pkg/session"
+	"github.com/kemadev/go-framework/pkg/sse"
+	"github.com/kemadev/go-framework/pkg/static"
 	"github.com/kemadev/go-framework/pkg/timeout"
+	"github.com/kemadev/go-framework/pkg/websocket"
 	"github.com/kemadev/go-framework/web"
 	"github.com/opensearch-project/opensearch-go/v4/opensearchapi"
 	"github.com/valkey-io/valkey-go"
@@ -76,37 +89,86 @@ func main() {
 
 	r := router.New()
 
+	// Resolve clients of requests forwarded by trusted proxies first, so that other middlewares see their address
+	realIP, err := realip.NewMiddleware(realip.Config{
+		Header:         conf.Server.ProxyHeader,
+		TrustedProxies: conf.Server.TrustedProxies,
+	})
+	if err != nil {
+		flog.FallbackError(err)
+		os.Exit(1)
+	}
+	r.Use(realIP)
+
+	// Restrict clients using access lists (use Reload to update them at runtime)
+	clientAccess := iputil.NewAccessList(iputil.AccessConfig{
+		Allow: conf.Server.AllowedClients,
+		Deny:  conf.Server.DeniedClients,
+	})
+	r.Use(clientAccess.Middleware)
+
+	// Shed requests when server is overloaded, lowest priority first (see loadshed.Class to set routes priority)
+	inboundPolicies, err := otelfailsafe.NewPolicyEngine[any]("inbound")
+	if err != nil {
+		flog.FallbackError(err)
+		os.Exit(1)
+	}
+
+	shedder, err := loadshed.NewShedder(loadshed.Config{
+		Builder: inboundPolicies.NewAdaptiveLimiterBuilder(),
+		// Long-lived streams and monitoring endpoints are exempted using loadshed.Exempt
+		Router: r,
+	})
+	if err != nil {
+		flog.FallbackError(err)
+		os.Exit(1)
+	}
+	defer shedder.Close()
+	r.Use(shedder.Middleware)
+
 	// Always protect your routes (you can further customize at handler / group level)
-	r.Use(timeout.NewMiddleware(5 * time.Second))
+	r.Use(timeout.NewMiddlewareWithConfig(timeout.Config{
+		Timeout: 5 * time.Second,
+		// Let routes override timeout, see timeout.For and timeout.Disabled
+		Router: r,
+		// Don't keep working for callers that already gave up
+		Header: headkey.GRPCTimeout,
+	}))
 	r.Use(maxbytes.NewMiddleware(100000))
 
 	// Add other middlewares
 	r.Use(encoding.DecompressMiddleware)
 	r.Use(encoding.CompressMiddleware)
 
-	// Add monitoring endpoints
-	r.Handle(
-		monitoring.LivenessHandler(
-			func() monitoring.CheckResults {
-				// Add your check function logic
-				return monitoring.CheckResults{}
-			},
-			conf,
-		),
-	)
-	r.Handle(
-		monitoring.ReadinessHandler(
-			func() monitoring.CheckResults {
-				return monitoring.CheckResults{
-					// Adjust status on ping fail
-					"database": database.Check(databaseClient, monitoring.StatusDown),
-					"cache":    cache.Check(cacheClient, monitoring.StatusDown),
-					"search":   search.Check(searchClient, monitoring.StatusDown),
-					// Add your check functions
-				}
-			},
-		),
-	)
+	// Add monitoring endpoints, which must remain reachable when server is overloaded
+	r.Group(func(r *router.Router) {
+		r.Annotate(loadshed.Exempt())
+
+		r.Handle(
+			monitoring.LivenessHandler(
+				func() monitoring.CheckResults {
+					// Add your check function logic
+					return monitoring.CheckResults{}
+				},
+				conf,
+			),
+		)
+		r.Handle(
+			monitoring.ReadinessHandler(
+				func() monitoring.CheckResults {
+					return monitoring.CheckResults{
+						// Adjust status on ping fail
+						"database": database.Check(databaseClient, monitoring.StatusDown),
+						"cache":    cache.Check(cacheClient, monitoring.StatusDown),
+						"search":   search.Check(searchClient, monitoring.StatusDown),
+						// Degraded while shedding requests
+						"loadShedding": shedder.Check(),
+						// Add your check functions
+					}
+				},
+			),
+		)
+	})
 
 	// Use otelfailsafe to create a policy engine
 	pe, err := otelfailsafe.NewPolicyEngine[any]("example")
@@ -130,10 +192,57 @@ func main() {
 	// This policy is arbitrary and should be tailored to your needs
 	exec := pe.NewExecutor(pe.NewRetryBuilder().WithJitterFactor(.25).Build(), pe.NewCacheBuilder(cacheBackend).Build())
 
+	// Allow browsers to call API routes from other origins (adjust origins to your needs)
+	apiCORS, err := cors.NewMiddleware(cors.Config{
+		AllowedOrigins: []string{"https://*.example.com"},
+		AllowedMethods: []string{http.MethodGet, http.MethodPost, http.MethodDelete},
+		AllowedHeaders: []string{headkey.ContentType, headkey.Authorization},
+	})
+	if err != nil {
+		flog.FallbackError(err)
+		os.Exit(1)
+	}
+
+	// Limit API request rate of each client, counting requests in valkey so that limit applies across instances
+	apiRateLimit, err := ratelimit.NewMiddleware(ratelimit.Config{
+		Policy: ratelimit.Policy{
+			Name:      "api",
+			Algorithm: ratelimit.TokenBucket,
+			Limit:     100,
+			Window:    time.Minute,
+		},
+		Store: ratelimit.NewValkeyStore(cacheClient, ""),
+	})
+	if err != nil {
+		flog.FallbackError(err)
+		os.Exit(1)
+	}
+
+	// Limit CSP violation reports rate of each client, as browsers send them without credentials
+	reportRateLimit, err := ratelimit.NewMiddleware(ratelimit.Config{
+		Policy: ratelimit.Policy{
+			Name:      "csp-report",
+			Algorithm: ratelimit.TokenBucket,
+			Limit:     20,
+			Window:    time.Minute,
+		},
+		Store: ratelimit.NewValkeyStore(cacheClient, ""),
+	})
+	if err != nil {
+		flog.FallbackError(err)
+		os.Exit(1)
+	}
+
 	// Add handlers
-	r.Handle(
-		otel.WrapHandler("GET /foo/{bar}", NewExampleHandler(exec)),
-	)
+	r.Group(func(r *router.Router) {
+		// Preflight requests are answered by CORS middleware, OPTIONS routes being registered automatically
+		r.Use(apiCORS)
+		r.Use(apiRateLimit)
+
+		r.Handle(
+			otel.WrapHandler("GET /foo/{bar}", NewExampleHandler(exec)),
+		)
+	})
 
 	r.Handle(
 		otel.WrapHandler(
@@ -156,29 +265,135 @@ func main() {
 		),
 	)
 
+	// Let event streams and websockets live longer than timeout
+	eventsPattern, eventsHandler := otel.WrapHandler("GET /events", NewExampleSSEHandler())
+	r.Handle(eventsPattern, eventsHandler, timeout.Disabled(), loadshed.Exempt())
+
+	wsPattern, wsHandler := otel.WrapHandler("GET /ws", NewExampleWebSocketHandler())
+	r.Handle(wsPattern, wsHandler, timeout.Disabled(), loadshed.Exempt())
+
+	// Handle static (public) assets, precompressed once at startup
+	staticFS, err := fs.Sub(web.GetStaticFS(), web.StaticBaseDirName)
+	if err != nil {
+		flog.FallbackError(err)
+		os.Exit(1)
+	}
+
+	staticHandler, err := static.NewWithConfig(staticFS, static.Config{
+		Prefix: "/" + web.StaticBaseDirName + "/",
+	})
+	if err != nil {
+		flog.FallbackError(err)
+		os.Exit(1)
+	}
+
+	// Fingerprinted assets are integrity-checked, so they can be allowed from same origin, and inline scripts
+	// and styles need the per-request nonce
+	frontendSecHeaders := sechead.SecHeadersStrictHTMLApp
+	if conf.Runtime.IsLocalEnvironment() {
+		frontendSecHeaders = sechead.SecHeadersRelaxedDev
+	}
+	// Report violations to built-in endpoint, using both Reporting API and legacy report-uri
+	frontendSecHeaders.Reporting.Endpoints = map[string]string{
+		"csp": "/csp-report",
+	}
+	frontendSecHeaders.ContentSecurityPolicy.ReportingDirectives = sechead.ContentSecurityPolicyReportingDirectives{
+		ReportTo:  "csp",
+		ReportURI: []string{"/csp-report"},
+	}
+	// Trial Trusted Types enforcement without breaking pages, violations being reported only
+	frontendSecHeaders.ContentSecurityPolicyReportOnly = &sechead.ContentSecurityPolicy{
+		OtherDirectives: sechead.ContentSecurityPolicyOtherDirectives{
+			RequireTrustedTypesFor: []string{sechead.RequireTrustedTypesForScript},
+		},
+		ReportingDirectives: frontendSecHeaders.ContentSecurityPolicy.ReportingDirectives,
+	}
+
+	err = frontendSecHeaders.Validate()
+	if err != nil {
+		flog.FallbackError(err)
+		os.Exit(1)
+	}
+
+	// Store frontend sessions in valkey, so that they are shared between instances. Prefixed cookies require
+	// HTTPS, which local environment lacks
+	frontendSessions, err := session.NewMiddleware(session.Config{
+		Store:    session.NewValkeyStore(cacheClient, ""),
+		Insecure: conf.Runtime.IsLocalEnvironment(),
+	})
+	if err != nil {
+		flog.FallbackError(err)
+		os.Exit(1)
+	}
+
 	// Create groups (sub-groups are also possible)
 	r.Group(func(r *router.Router) {
 		// Secure frontend with security headers
-		r.Use(sechead.NewMiddleware(sechead.SecHeadersDefaultStrict))
+		r.Use(sechead.NewMiddleware(frontendSecHeaders))
 		// Secure frontend with CORF checks (you can customize the middleware as needed)
 		r.Use(http.NewCrossOriginProtection().Handler)
+		// Load sessions, see session.FromContext
+		r.Use(frontendSessions)
+		// Validate session-bound CSRF tokens for browsers lacking Sec-Fetch-Site support (forms embed them using
+		// csrfField template function)
+		r.Use(csrf.NewMiddleware(csrf.Config{}))
 
 		// Handle template assets
-		tmplFS := web.GetTmplFS()
-		renderer, _ := render.New(tmplFS, web.TemplateBaseDirName)
+		var tmplFS fs.FS = web.GetTmplFS()
+		// Reload templates from disk in local environment, so that changes apply without recompiling. Path is
+		// relative to working directory, see tool/dev/docker-compose.yaml
+		if conf.Runtime.IsLocalEnvironment() {
+			tmplFS = os.DirFS("web")
+		}
+
+		renderer, err := render.NewWithConfig(tmplFS, web.TemplateBaseDirName, render.Config{
+			// Provide asset and integrity functions to templates
+			Funcs: staticHandler.FuncMap(),
+			// Provide CSRF token functions to templates
+			RequestFuncs: csrf.RequestFuncs(),
+			// Translations are picked using Accept-Language header
+			Translations: map[string]map[string]string{
+				"en": {
+					"title":    "Hello",
+					"greeting": "Hello, %s!",
+					"footer":   "Made with go-framework",
+				},
+				"fr": {
+					"title":    "Bonjour",
+					"greeting": "Bonjour, %s !",
+					"footer":   "Fait avec go-framework",
+				},
+			},
+			Reload: conf.Runtime.IsLocalEnvironment(),
+		})
+		if err != nil {
+			flog.FallbackError(err)
+			os.Exit(1)
+		}
 		r.Handle(
 			otel.WrapHandler(
 				"GET /",
-				NewExampleTemplateRender(renderer, exec),
+				NewExampleTemplateRender(renderer),
+			),
+		)
+	})
+
+	// Receive CSP violation reports, outside of frontend group as browsers send them without CORF headers
+	r.Group(func(r *router.Router) {
+		r.Use(reportRateLimit)
+
+		r.Handle(
+			otel.WrapHandler(
+				"POST /csp-report",
+				sechead.ReportHandler(),
 			),
 		)
 	})
 
-	// Handle static (public) assets
 	r.Handle(
 		otel.WrapHandler(
 			"GET /"+web.StaticBaseDirName+"/",
-			http.FileServerFS(web.GetStaticFS()).ServeHTTP,
+			staticHandler.ServeHTTP,
 		),
 	)
 
@@ -231,25 +446,31 @@ func NewExampleHandler(exec failsafe.Executor[any]) http.HandlerFunc {
 	}
 }
 
-func NewExampleTemplateRender(tr *render.TemplateRenderer, exec failsafe.Executor[any]) http.HandlerFunc {
+func NewExampleTemplateRender(tr *render.TemplateRenderer) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		err := exec.Run(func() error {
-			return tr.Execute(
-				w,
-				// Mind about file extension
-				r.URL.Path+".gotmpl.html",
-				map[string]any{
-					"WorldName": "WoRlD",
-				},
-				headval.MIMETextHTMLCharsetUTF8,
-			)
-		})
+		// Rendering writes the response, and thus must not be retried
+		err := tr.ExecuteRequest(
+			w,
+			r,
+			// Mind about file extension
+			r.URL.Path+".gotmpl.html",
+			map[string]any{
+				"WorldName": "WoRlD",
+			},
+			headval.MIMETextHTMLCharsetUTF8,
+		)
 		if err != nil {
 			if errors.Is(err, render.ErrTemplateNotFound) {
 				http.NotFound(w, r)
 				return
 			}
 
+			// Client already got a response, that can't be replaced with an error
+			if errors.Is(err, render.ErrErrorPageSent) || errors.Is(err, render.ErrPartialResponse) {
+				log.ErrLog(packageName, "error rendering template", err)
+				return
+			}
+
 			log.Logger(packageName).
 				Error("error rendering template",
 					slog.String(
@@ -266,6 +487,76 @@ func NewExampleTemplateRender(tr *render.TemplateRenderer, exec failsafe.Executo
 	}
 }
 
+func NewExampleSSEHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		stream, err := sse.New(w, r)
+		if err != nil {
+			log.ErrLog(packageName, "error opening event stream", err)
+			http.Error(
+				w,
+				http.StatusText(http.StatusInternalServerError),
+				http.StatusInternalServerError,
+			)
+
+			return
+		}
+		defer stream.Close()
+
+		// Resume from last event received by client, if any
+		id, _ := strconv.Atoi(stream.LastEventID())
+
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stream.Context().Done():
+				return
+			case t := <-ticker.C:
+				id++
+
+				err := stream.Send(sse.Event{
+					ID:   strconv.Itoa(id),
+					Type: "tick",
+					Data: t.String(),
+				})
+				if err != nil {
+					return
+				}
+			}
+		}
+	}
+}
+
+func NewExampleWebSocketHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Cross-origin upgrades are rejected, add trusted origins as needed
+		conn, err := websocket.UpgradeWithConfig(w, r, websocket.Config{
+			EnableCompression: true,
+		})
+		if err != nil {
+			// Upgrade already responded to the client, or closed the connection once hijacked
+			log.ErrLog(packageName, "error upgrading to websocket", err)
+
+			return
+		}
+		defer conn.Close(websocket.StatusNormalClosure, "")
+
+		// Echo messages back
+		for {
+			typ, msg, err := conn.Read(r.Context())
+			if err != nil {
+				return
+			}
+
+			err = conn.Write(r.Context(), typ, msg)
+			if err != nil {
+				return
+			}
+		}
+	}
+}
+
 func NewExampleCacheHandler(client valkey.Client, exec failsafe.Executor[any]) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		err := exec.Run(func() error {
@@ -0,0 +1,80 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+)
+
+// MethodAPIKey is the authentication method of principals authenticated by [APIKeyAuthenticator].
+const MethodAPIKey = "apikey"
+
+// DefaultAPIKeyHeader is the default header carrying API keys.
+const DefaultAPIKeyHeader = "X-API-Key"
+
+// APIKeyConfig defines the configuration for API key authenticator.
+type APIKeyConfig struct {
+	// Header carrying API key. Defaults to [DefaultAPIKeyHeader].
+	Header string
+	// Principals by API key hash, see [HashAPIKey], so that keys themselves are not kept in configuration.
+	// Principal method is set to [MethodAPIKey].
+	Keys map[string]Principal
+}
+
+// APIKeyAuthenticator authenticates requests carrying an API key in a header.
+type APIKeyAuthenticator struct {
+	header string
+	keys   map[string]Principal
+}
+
+// HashAPIKey returns hash of [key], as used in [APIKeyConfig].
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+
+	return hex.EncodeToString(sum[:])
+}
+
+// NewAPIKeyAuthenticator returns an [APIKeyAuthenticator]. As keys are looked up by hash, lookup time does not
+// depend on how many characters of a key match.
+func NewAPIKeyAuthenticator(conf APIKeyConfig) *APIKeyAuthenticator {
+	if conf.Header == "" {
+		conf.Header = DefaultAPIKeyHeader
+	}
+
+	keys := make(map[string]Principal, len(conf.Keys))
+
+	for hash, principal := range conf.Keys {
+		principal.Method = MethodAPIKey
+		keys[strings.ToLower(hash)] = principal
+	}
+
+	return &APIKeyAuthenticator{
+		header: conf.Header,
+		keys:   keys,
+	}
+}
+
+// Challenge implements [Authenticator].
+func (a *APIKeyAuthenticator) Challenge() string {
+	// No registered scheme exists for API keys, see RFC 9110 section 11.6.1
+	return `APIKey header="` + a.header + `"`
+}
+
+// Authenticate implements [Authenticator].
+func (a *APIKeyAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+	key := r.Header.Get(a.header)
+	if key == "" {
+		return nil, ErrNoCredentials
+	}
+
+	principal, ok := a.keys[HashAPIKey(key)]
+	if !ok {
+		return nil, ErrInvalidCredentials
+	}
+
+	return &principal, nil
+}
@@ -0,0 +1,125 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/kemadev/go-framework/pkg/convenience/headkey"
+	"github.com/kemadev/go-framework/pkg/convenience/log"
+	"github.com/kemadev/go-framework/pkg/convenience/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+)
+
+const packageName = "github.com/kemadev/go-framework/pkg/auth"
+
+var (
+	// ErrNoCredentials is returned by authenticators when request carries no credentials they handle, so that
+	// next authenticator is tried.
+	ErrNoCredentials = errors.New("no credentials")
+	// ErrInvalidCredentials is returned by authenticators when request credentials are rejected.
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	// ErrUnavailable is returned by authenticators when credentials can't be verified, e.g. as keys can't be
+	// fetched.
+	ErrUnavailable = errors.New("authentication unavailable")
+)
+
+// Principal is an authenticated entity.
+type Principal struct {
+	// Unique identifier, e.g. JWT subject or API key owner
+	ID string
+	// Authentication method, e.g. [MethodJWT]
+	Method string
+	// Scopes granted to principal
+	Scopes []string
+	// Roles of principal
+	Roles []string
+	// Claims of principal, e.g. JWT claims
+	Claims map[string]any
+}
+
+// Authenticator authenticates requests.
+type Authenticator interface {
+	// Authenticate returns principal authenticated by request credentials. It returns an error wrapping
+	// [ErrNoCredentials] when request carries no credentials handled by authenticator, [ErrInvalidCredentials]
+	// when they are rejected, and [ErrUnavailable] when they can't be verified.
+	Authenticate(r *http.Request) (*Principal, error)
+	// Challenge returns WWW-Authenticate header value sent along with 401 responses, see RFC 9110 section 11.6.1.
+	Challenge() string
+}
+
+type principalKey struct{}
+
+// FromContext returns principal authenticated by [NewMiddleware], if any.
+func FromContext(c context.Context) (*Principal, bool) {
+	p, ok := c.Value(principalKey{}).(*Principal)
+
+	return p, ok && p != nil
+}
+
+// NewContext returns a context carrying [p].
+func NewContext(c context.Context, p *Principal) context.Context {
+	return context.WithValue(c, principalKey{}, p)
+}
+
+// Config defines the configuration for authentication middleware.
+type Config struct {
+	// Authenticators tried in order, the first one finding credentials in request deciding
+	Authenticators []Authenticator
+	// Let requests without credentials through, without principal in context. Requests with invalid
+	// credentials are still rejected.
+	Optional bool
+}
+
+// NewMiddleware returns a middleware authenticating requests using configured authenticators. Authenticated
+// principal is stored in request context, see [FromContext], and its ID is recorded on current span as
+// enduser.id. Requests without valid credentials are rejected with 401 status, along with challenges of all
+// authenticators, and requests whose credentials can't be verified are rejected with 503 status.
+func NewMiddleware(conf Config) func(http.Handler) http.Handler {
+	challenges := make([]string, 0, len(conf.Authenticators))
+
+	for _, authenticator := range conf.Authenticators {
+		challenges = append(challenges, authenticator.Challenge())
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var (
+				principal *Principal
+				err       = ErrNoCredentials
+			)
+
+			for _, authenticator := range conf.Authenticators {
+				principal, err = authenticator.Authenticate(r)
+				if !errors.Is(err, ErrNoCredentials) {
+					break
+				}
+			}
+
+			switch {
+			case err == nil:
+				trace.Span(r.Context()).SetAttributes(semconv.EnduserID(principal.ID))
+
+				next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), principal)))
+			case errors.Is(err, ErrNoCredentials) && conf.Optional:
+				next.ServeHTTP(w, r)
+			case errors.Is(err, ErrUnavailable):
+				log.ErrLog(packageName, "error authenticating request", err)
+				http.Error(
+					w,
+					http.StatusText(http.StatusServiceUnavailable),
+					http.StatusServiceUnavailable,
+				)
+			default:
+				for _, challenge := range challenges {
+					w.Header().Add(headkey.WWWAuthenticate, challenge)
+				}
+
+				http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			}
+		})
+	}
+}
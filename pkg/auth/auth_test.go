@@ -0,0 +1,592 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package auth_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kemadev/go-framework/pkg/auth"
+	"github.com/kemadev/go-framework/pkg/convenience/headkey"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	testIssuer   = "https://issuer.example.com"
+	testAudience = "api"
+)
+
+// signer signs tokens with a key published by a local JWKS stand-in.
+type signer struct {
+	kid string
+	alg string
+	rsa *rsa.PrivateKey
+	ec  *ecdsa.PrivateKey
+}
+
+func newRSASigner(t *testing.T, kid string) *signer {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey: %s", err)
+	}
+
+	return &signer{kid: kid, alg: "RS256", rsa: key}
+}
+
+func newECSigner(t *testing.T, kid string) *signer {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey: %s", err)
+	}
+
+	return &signer{kid: kid, alg: "ES256", ec: key}
+}
+
+func (s *signer) jwk() map[string]string {
+	enc := base64.RawURLEncoding.EncodeToString
+
+	if s.rsa != nil {
+		return map[string]string{
+			"kty": "RSA",
+			"kid": s.kid,
+			"use": "sig",
+			"n":   enc(s.rsa.N.Bytes()),
+			"e":   enc(big.NewInt(int64(s.rsa.E)).Bytes()),
+		}
+	}
+
+	pub, err := s.ec.PublicKey.Bytes()
+	if err != nil {
+		panic(err)
+	}
+
+	return map[string]string{
+		"kty": "EC",
+		"kid": s.kid,
+		"crv": "P-256",
+		"x":   enc(pub[1:33]),
+		"y":   enc(pub[33:]),
+	}
+}
+
+func (s *signer) sign(t *testing.T, claims map[string]any) string {
+	t.Helper()
+
+	header, _ := json.Marshal(map[string]string{"alg": s.alg, "kid": s.kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." +
+		base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signed))
+
+	var sig []byte
+
+	if s.rsa != nil {
+		var err error
+
+		sig, err = rsa.SignPKCS1v15(rand.Reader, s.rsa, crypto.SHA256, digest[:])
+		if err != nil {
+			t.Fatalf("SignPKCS1v15: %s", err)
+		}
+	} else {
+		r, ss, err := ecdsa.Sign(rand.Reader, s.ec, digest[:])
+		if err != nil {
+			t.Fatalf("Sign: %s", err)
+		}
+
+		sig = append(r.FillBytes(make([]byte, 32)), ss.FillBytes(make([]byte, 32))...)
+	}
+
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+// jwksServer is a local JWKS and OpenID Provider configuration stand-in.
+type jwksServer struct {
+	*httptest.Server
+	mu      sync.Mutex
+	signers []*signer
+	fetches int
+	down    bool
+}
+
+func newJWKSServer(t *testing.T, signers ...*signer) *jwksServer {
+	t.Helper()
+
+	s := &jwksServer{signers: signers}
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /jwks", func(w http.ResponseWriter, _ *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		s.fetches++
+
+		if s.down {
+			w.WriteHeader(http.StatusBadGateway)
+
+			return
+		}
+
+		keys := make([]map[string]string, 0, len(s.signers))
+		for _, signer := range s.signers {
+			keys = append(keys, signer.jwk())
+		}
+
+		_ = json.NewEncoder(w).Encode(map[string]any{"keys": keys})
+	})
+
+	mux.HandleFunc("GET /.well-known/openid-configuration", func(w http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":   s.URL,
+			"jwks_uri": s.URL + "/jwks",
+		})
+	})
+
+	s.Server = httptest.NewServer(mux)
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+func (s *jwksServer) setSigners(signers ...*signer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.signers = signers
+}
+
+func claims(overrides map[string]any) map[string]any {
+	now := time.Now()
+	c := map[string]any{
+		"iss":   testIssuer,
+		"aud":   []string{"other", testAudience},
+		"sub":   "user-1",
+		"exp":   now.Add(time.Hour).Unix(),
+		"iat":   now.Unix(),
+		"scope": "read write",
+		"roles": []string{"admin"},
+	}
+
+	for k, v := range overrides {
+		if v == nil {
+			delete(c, k)
+		} else {
+			c[k] = v
+		}
+	}
+
+	return c
+}
+
+func newJWTAuthenticator(t *testing.T, server *jwksServer) *auth.JWTAuthenticator {
+	t.Helper()
+
+	a, err := auth.NewJWTAuthenticator(auth.JWTConfig{
+		Issuer:    testIssuer,
+		Audiences: []string{testAudience},
+		JWKS: auth.JWKSConfig{
+			URL:                server.URL + "/jwks",
+			MinRefreshInterval: time.Nanosecond,
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewJWTAuthenticator: %s", err)
+	}
+
+	return a
+}
+
+func bearerRequest(t *testing.T, token string) *http.Request {
+	t.Helper()
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, "/", nil)
+	if err != nil {
+		t.Fatalf("NewRequestWithContext: %s", err)
+	}
+
+	req.Header.Set(headkey.Authorization, "Bearer "+token)
+
+	return req
+}
+
+func TestJWTAuthenticator(t *testing.T) {
+	t.Parallel()
+
+	rsaSigner := newRSASigner(t, "rsa-1")
+	ecSigner := newECSigner(t, "ec-1")
+	unknownSigner := newRSASigner(t, "rsa-1")
+	server := newJWKSServer(t, rsaSigner, ecSigner)
+	a := newJWTAuthenticator(t, server)
+
+	tests := []struct {
+		Name     string
+		Token    string
+		Expected error
+	}{
+		{
+			Name:  "valid RS256",
+			Token: rsaSigner.sign(t, claims(nil)),
+		},
+		{
+			Name:  "valid ES256",
+			Token: ecSigner.sign(t, claims(nil)),
+		},
+		{
+			Name:     "expired",
+			Token:    rsaSigner.sign(t, claims(map[string]any{"exp": time.Now().Add(-time.Hour).Unix()})),
+			Expected: auth.ErrTokenExpired,
+		},
+		{
+			Name:     "missing expiry",
+			Token:    rsaSigner.sign(t, claims(map[string]any{"exp": nil})),
+			Expected: auth.ErrTokenExpired,
+		},
+		{
+			Name:     "not yet valid",
+			Token:    rsaSigner.sign(t, claims(map[string]any{"nbf": time.Now().Add(time.Hour).Unix()})),
+			Expected: auth.ErrTokenNotYetValid,
+		},
+		{
+			Name:     "wrong issuer",
+			Token:    rsaSigner.sign(t, claims(map[string]any{"iss": "https://evil.example.com"})),
+			Expected: auth.ErrInvalidIssuer,
+		},
+		{
+			Name:     "wrong audience",
+			Token:    rsaSigner.sign(t, claims(map[string]any{"aud": "other"})),
+			Expected: auth.ErrInvalidAudience,
+		},
+		{
+			Name:     "missing subject",
+			Token:    rsaSigner.sign(t, claims(map[string]any{"sub": nil})),
+			Expected: auth.ErrMissingSubject,
+		},
+		{
+			Name:     "signed by unknown key",
+			Token:    unknownSigner.sign(t, claims(nil)),
+			Expected: auth.ErrInvalidSignature,
+		},
+		{
+			Name: "unsigned",
+			Token: base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none"}`)) + "." +
+				base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"user-1"}`)) + ".",
+			Expected: auth.ErrUnsupportedAlgorithm,
+		},
+		{
+			Name:     "malformed",
+			Token:    "not-a-token",
+			Expected: auth.ErrMalformedToken,
+		},
+	}
+
+	for _, test := range tests {
+		principal, err := a.Authenticate(bearerRequest(t, test.Token))
+
+		if test.Expected == nil {
+			if err != nil {
+				t.Errorf("%s: unexpected error: %s", test.Name, err)
+
+				continue
+			}
+
+			if principal.ID != "user-1" || principal.Method != auth.MethodJWT {
+				t.Errorf("%s: unexpected principal %+v", test.Name, principal)
+			}
+
+			if len(principal.Scopes) != 2 || len(principal.Roles) != 1 {
+				t.Errorf("%s: unexpected scopes %v or roles %v", test.Name, principal.Scopes, principal.Roles)
+			}
+
+			continue
+		}
+
+		if !errors.Is(err, auth.ErrInvalidCredentials) || !errors.Is(err, test.Expected) {
+			t.Errorf("%s: expected error %q but was %v", test.Name, test.Expected, err)
+		}
+	}
+}
+
+func TestJWTKeyRotation(t *testing.T) {
+	t.Parallel()
+
+	oldSigner := newRSASigner(t, "old")
+	newSigner := newECSigner(t, "new")
+	server := newJWKSServer(t, oldSigner)
+	a := newJWTAuthenticator(t, server)
+
+	_, err := a.Authenticate(bearerRequest(t, oldSigner.sign(t, claims(nil))))
+	if err != nil {
+		t.Fatalf("old key: unexpected error: %s", err)
+	}
+
+	server.setSigners(newSigner)
+
+	_, err = a.Authenticate(bearerRequest(t, newSigner.sign(t, claims(nil))))
+	if err != nil {
+		t.Errorf("new key: unexpected error: %s", err)
+	}
+
+	server.mu.Lock()
+	server.down = true
+	server.mu.Unlock()
+
+	// Known keys keep being used while JWKS is unavailable
+	_, err = a.Authenticate(bearerRequest(t, newSigner.sign(t, claims(nil))))
+	if err != nil {
+		t.Errorf("JWKS down: unexpected error: %s", err)
+	}
+
+	_, err = a.Authenticate(bearerRequest(t, newRSASigner(t, "unknown").sign(t, claims(nil))))
+	if !errors.Is(err, auth.ErrUnavailable) {
+		t.Errorf("JWKS down, unknown key: expected error %q but was %v", auth.ErrUnavailable, err)
+	}
+}
+
+// keyCache is a [auth.KeyCache] stand-in, which drops key sets when lossy, as caches may do.
+type keyCache struct {
+	mu    sync.Mutex
+	lossy bool
+	sets  map[string]*auth.KeySet
+}
+
+func (c *keyCache) Get(key string) (*auth.KeySet, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	set, found := c.sets[key]
+
+	return set, found
+}
+
+func (c *keyCache) Set(key string, value *auth.KeySet) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.lossy {
+		c.sets[key] = value
+	}
+}
+
+func TestJWTKeyCache(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Name            string
+		Lossy           bool
+		ExpectedFetches int
+	}{
+		{
+			Name:            "shared",
+			ExpectedFetches: 1,
+		},
+		{
+			Name:            "lossy",
+			Lossy:           true,
+			ExpectedFetches: 2,
+		},
+	}
+
+	for _, test := range tests {
+		signer := newRSASigner(t, "rsa-1")
+		server := newJWKSServer(t, signer)
+		cache := &keyCache{lossy: test.Lossy, sets: make(map[string]*auth.KeySet)}
+
+		authenticators := make([]*auth.JWTAuthenticator, 2)
+		for i := range authenticators {
+			a, err := auth.NewJWTAuthenticator(auth.JWTConfig{
+				Issuer:    testIssuer,
+				Audiences: []string{testAudience},
+				JWKS: auth.JWKSConfig{
+					URL:   server.URL + "/jwks",
+					Cache: cache,
+				},
+			})
+			if err != nil {
+				t.Fatalf("%s: NewJWTAuthenticator: %s", test.Name, err)
+			}
+
+			authenticators[i] = a
+		}
+
+		// Key sets are kept by each authenticator, regardless of cache
+		for range 3 {
+			for _, a := range authenticators {
+				_, err := a.Authenticate(bearerRequest(t, signer.sign(t, claims(nil))))
+				if err != nil {
+					t.Errorf("%s: unexpected error: %s", test.Name, err)
+				}
+			}
+		}
+
+		server.mu.Lock()
+		fetches := server.fetches
+		server.mu.Unlock()
+
+		if fetches != test.ExpectedFetches {
+			t.Errorf("%s: expected %d fetches but was %d", test.Name, test.ExpectedFetches, fetches)
+		}
+	}
+}
+
+func TestOIDCAuthenticator(t *testing.T) {
+	t.Parallel()
+
+	signer := newRSASigner(t, "rsa-1")
+	server := newJWKSServer(t, signer)
+
+	a, err := auth.NewOIDCAuthenticator(context.Background(), auth.JWTConfig{
+		Issuer:    server.URL,
+		Audiences: []string{testAudience},
+	})
+	if err != nil {
+		t.Fatalf("NewOIDCAuthenticator: %s", err)
+	}
+
+	_, err = a.Authenticate(bearerRequest(t, signer.sign(t, claims(map[string]any{"iss": server.URL}))))
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+
+	_, err = auth.NewOIDCAuthenticator(context.Background(), auth.JWTConfig{
+		Issuer:    server.URL + "/other",
+		Audiences: []string{testAudience},
+	})
+	if !errors.Is(err, auth.ErrDiscovery) {
+		t.Errorf("expected error %q but was %v", auth.ErrDiscovery, err)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	t.Parallel()
+
+	hash, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("GenerateFromPassword: %s", err)
+	}
+
+	basic, err := auth.NewBasicAuthenticator(auth.BasicConfig{
+		Realm: "test",
+		Users: map[string]string{"alice": string(hash)},
+	})
+	if err != nil {
+		t.Fatalf("NewBasicAuthenticator: %s", err)
+	}
+
+	apiKey := auth.NewAPIKeyAuthenticator(auth.APIKeyConfig{
+		Keys: map[string]auth.Principal{
+			auth.HashAPIKey("key-1"): {ID: "service-1"},
+		},
+	})
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		principal, ok := auth.FromContext(r.Context())
+		if !ok {
+			w.WriteHeader(http.StatusNoContent)
+
+			return
+		}
+
+		_, _ = w.Write([]byte(principal.Method + ":" + principal.ID))
+	}
+
+	required := auth.NewMiddleware(auth.Config{
+		Authenticators: []auth.Authenticator{apiKey, basic},
+	})(http.HandlerFunc(handler))
+	optional := auth.NewMiddleware(auth.Config{
+		Authenticators: []auth.Authenticator{apiKey, basic},
+		Optional:       true,
+	})(http.HandlerFunc(handler))
+
+	tests := []struct {
+		Name           string
+		Handler        http.Handler
+		Header         http.Header
+		ExpectedStatus int
+		ExpectedBody   string
+	}{
+		{
+			Name:           "api key",
+			Handler:        required,
+			Header:         http.Header{auth.DefaultAPIKeyHeader: {"key-1"}},
+			ExpectedStatus: http.StatusOK,
+			ExpectedBody:   "apikey:service-1",
+		},
+		{
+			Name:           "invalid api key",
+			Handler:        optional,
+			Header:         http.Header{auth.DefaultAPIKeyHeader: {"key-2"}},
+			ExpectedStatus: http.StatusUnauthorized,
+		},
+		{
+			Name:    "basic",
+			Handler: required,
+			Header: http.Header{headkey.Authorization: {
+				"Basic " + base64.StdEncoding.EncodeToString([]byte("alice:secret")),
+			}},
+			ExpectedStatus: http.StatusOK,
+			ExpectedBody:   "basic:alice",
+		},
+		{
+			Name:    "invalid basic",
+			Handler: required,
+			Header: http.Header{headkey.Authorization: {
+				"Basic " + base64.StdEncoding.EncodeToString([]byte("bob:secret")),
+			}},
+			ExpectedStatus: http.StatusUnauthorized,
+		},
+		{
+			Name:           "no credentials",
+			Handler:        required,
+			ExpectedStatus: http.StatusUnauthorized,
+		},
+		{
+			Name:           "optional without credentials",
+			Handler:        optional,
+			ExpectedStatus: http.StatusNoContent,
+		},
+	}
+
+	for _, test := range tests {
+		req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, "/", nil)
+		if err != nil {
+			t.Fatalf("NewRequestWithContext: %s", err)
+		}
+
+		for key, values := range test.Header {
+			req.Header.Set(key, values[0])
+		}
+
+		rr := httptest.NewRecorder()
+		test.Handler.ServeHTTP(rr, req)
+
+		if rr.Code != test.ExpectedStatus {
+			t.Errorf("%s: expected status %d but was %d", test.Name, test.ExpectedStatus, rr.Code)
+		}
+
+		if test.ExpectedBody != "" && rr.Body.String() != test.ExpectedBody {
+			t.Errorf("%s: expected body %q but was %q", test.Name, test.ExpectedBody, rr.Body.String())
+		}
+
+		if rr.Code == http.StatusUnauthorized && len(rr.Header().Values(headkey.WWWAuthenticate)) != 2 {
+			t.Errorf("%s: expected 2 challenges, got %v", test.Name, rr.Header().Values(headkey.WWWAuthenticate))
+		}
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package auth
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// MethodBasic is the authentication method of principals authenticated by [BasicAuthenticator].
+const MethodBasic = "basic"
+
+// BasicConfig defines the configuration for HTTP Basic authenticator.
+type BasicConfig struct {
+	// Realm sent in challenge, see RFC 7617 section 2
+	Realm string
+	// Bcrypt password hashes by username, see [golang.org/x/crypto/bcrypt.GenerateFromPassword]
+	Users map[string]string
+}
+
+// BasicAuthenticator authenticates requests carrying HTTP Basic credentials, see RFC 7617. It must only be used
+// over TLS, as credentials are sent in clear text.
+type BasicAuthenticator struct {
+	realm string
+	users map[string][]byte
+	// dummy is compared against for unknown users, so that response time does not tell whether a user exists
+	dummy []byte
+}
+
+// NewBasicAuthenticator returns a [BasicAuthenticator]. Principal ID is the username.
+func NewBasicAuthenticator(conf BasicConfig) (*BasicAuthenticator, error) {
+	users := make(map[string][]byte, len(conf.Users))
+
+	for user, hash := range conf.Users {
+		users[user] = []byte(hash)
+	}
+
+	dummy, err := bcrypt.GenerateFromPassword([]byte("dummy"), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, fmt.Errorf("error hashing dummy password: %w", err)
+	}
+
+	return &BasicAuthenticator{
+		realm: conf.Realm,
+		users: users,
+		dummy: dummy,
+	}, nil
+}
+
+// Challenge implements [Authenticator].
+func (a *BasicAuthenticator) Challenge() string {
+	return "Basic realm=" + strconv.Quote(a.realm) + `, charset="UTF-8"`
+}
+
+// Authenticate implements [Authenticator].
+func (a *BasicAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+	user, password, ok := r.BasicAuth()
+	if !ok {
+		return nil, ErrNoCredentials
+	}
+
+	hash, exists := a.users[user]
+	if !exists {
+		hash = a.dummy
+	}
+
+	err := bcrypt.CompareHashAndPassword(hash, []byte(password))
+	if err != nil || !exists {
+		return nil, ErrInvalidCredentials
+	}
+
+	return &Principal{
+		ID:     user,
+		Method: MethodBasic,
+	}, nil
+}
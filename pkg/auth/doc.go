@@ -0,0 +1,7 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+// Package auth authenticates requests using pluggable [Authenticator] implementations, such as bearer JWTs
+// verified against a JWKS (optionally discovered from an OpenID Connect issuer), API keys and HTTP Basic
+// credentials. Authenticated principals are stored in request context, see [FromContext].
+package auth
@@ -0,0 +1,386 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/kemadev/go-framework/pkg/convenience/headkey"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+const (
+	// DefaultJWKSRefreshInterval is the default duration key sets are used for before being fetched again,
+	// unless JWKS response Cache-Control header states otherwise.
+	DefaultJWKSRefreshInterval = time.Hour
+	// DefaultJWKSMinRefreshInterval is the default minimum duration between key set fetches triggered by
+	// unknown key IDs, bounding fetches caused by forged tokens.
+	DefaultJWKSMinRefreshInterval = time.Minute
+	// minRSAKeyBits is the minimum size of RSA keys, see RFC 7518 section 3.3.
+	minRSAKeyBits = 2048
+	// maxJWKSSize bounds JWKS response size.
+	maxJWKSSize = 1 << 20
+)
+
+var (
+	ErrKeyNotFound    = errors.New("key not found")
+	ErrInvalidKey     = errors.New("invalid key")
+	ErrJWKSFetch      = errors.New("error fetching JWKS")
+	ErrJWKSUnexpected = errors.New("unexpected JWKS response")
+)
+
+// KeyCache caches key sets by JWKS URL, e.g. to share them between authenticators, see
+// [github.com/kemadev/go-framework/pkg/client/cache.NewFailsafeLocal].
+type KeyCache interface {
+	Get(key string) (*KeySet, bool)
+	Set(key string, value *KeySet)
+}
+
+// KeySet is a set of verification keys fetched from a JWKS endpoint.
+type KeySet struct {
+	keys      []jwk
+	fetchedAt time.Time
+	expiresAt time.Time
+}
+
+// jwk is a parsed JSON Web Key, see RFC 7517.
+type jwk struct {
+	id        string
+	algorithm string
+	key       crypto.PublicKey
+}
+
+// rawJWK is a JSON Web Key, as sent by JWKS endpoints.
+type rawJWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	// RSA
+	N string `json:"n"`
+	E string `json:"e"`
+	// EC and OKP
+	Curve string `json:"crv"`
+	X     string `json:"x"`
+	Y     string `json:"y"`
+}
+
+// JWKSConfig defines the configuration for JWKS fetching.
+type JWKSConfig struct {
+	// URL key set is fetched from
+	URL string
+	// Client used to fetch key set. Defaults to a client instrumented with otelhttp.
+	Client *http.Client
+	// Cache of fetched key sets, shared with other authenticators, optional. Key sets are kept in memory
+	// regardless, as caches may evict or drop them.
+	Cache KeyCache
+	// Duration key set is used for before being fetched again, unless response Cache-Control header sets a
+	// max-age. Defaults to [DefaultJWKSRefreshInterval].
+	RefreshInterval time.Duration
+	// Minimum duration between fetches triggered by unknown key IDs. Defaults to
+	// [DefaultJWKSMinRefreshInterval].
+	MinRefreshInterval time.Duration
+}
+
+// jwks provides verification keys of a JWKS endpoint, fetching it again upon expiry or unknown key ID so that
+// keys can be rotated. Previous keys are kept in use when fetching fails.
+type jwks struct {
+	conf JWKSConfig
+	// current is the latest known key set, nil until first fetched
+	current atomic.Pointer[KeySet]
+	// mu serializes fetches
+	mu sync.Mutex
+}
+
+func newJWKS(conf JWKSConfig) *jwks {
+	if conf.Client == nil {
+		conf.Client = &http.Client{
+			Transport: otelhttp.NewTransport(http.DefaultTransport),
+			Timeout:   10 * time.Second,
+		}
+	}
+
+	if conf.RefreshInterval == 0 {
+		conf.RefreshInterval = DefaultJWKSRefreshInterval
+	}
+
+	if conf.MinRefreshInterval == 0 {
+		conf.MinRefreshInterval = DefaultJWKSMinRefreshInterval
+	}
+
+	return &jwks{conf: conf}
+}
+
+// key returns key [kid] usable with [algorithm], an empty [kid] matching the only key usable with it.
+func (j *jwks) key(c context.Context, kid string, algorithm string) (crypto.PublicKey, error) {
+	set := j.load()
+
+	now := time.Now()
+
+	if set == nil || now.After(set.expiresAt) {
+		fetched, err := j.refresh(c, set, func(current *KeySet) bool {
+			return current == nil || now.After(current.expiresAt)
+		})
+		if err != nil && set == nil {
+			return nil, err
+		}
+
+		if err == nil {
+			set = fetched
+		}
+	}
+
+	key, err := set.lookup(kid, algorithm)
+	if err == nil || kid == "" {
+		return key, err
+	}
+
+	// Unknown key ID, keys may have been rotated
+	fetched, err := j.refresh(c, set, func(current *KeySet) bool {
+		return time.Since(current.fetchedAt) >= j.conf.MinRefreshInterval
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return fetched.lookup(kid, algorithm)
+}
+
+// refresh fetches key set if [stale] reports current one as such, current one being [known] or a key set
+// fetched concurrently.
+func (j *jwks) refresh(c context.Context, known *KeySet, stale func(current *KeySet) bool) (*KeySet, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	current := j.load()
+	if current == nil {
+		current = known
+	}
+
+	if current != nil && !stale(current) {
+		return current, nil
+	}
+
+	set, err := j.fetch(c)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrUnavailable, err)
+	}
+
+	j.current.Store(set)
+
+	if j.conf.Cache != nil {
+		j.conf.Cache.Set(j.conf.URL, set)
+	}
+
+	return set, nil
+}
+
+// load returns latest known key set, be it fetched by [j] or by another authenticator sharing its cache, nil
+// if none.
+func (j *jwks) load() *KeySet {
+	current := j.current.Load()
+
+	if j.conf.Cache == nil {
+		return current
+	}
+
+	cached, found := j.conf.Cache.Get(j.conf.URL)
+	if !found || cached == nil || (current != nil && !cached.fetchedAt.After(current.fetchedAt)) {
+		return current
+	}
+
+	// Concurrent loads may store an older key set, which is harmless as it is replaced upon next load
+	j.current.Store(cached)
+
+	return cached
+}
+
+func (j *jwks) fetch(c context.Context) (*KeySet, error) {
+	req, err := http.NewRequestWithContext(c, http.MethodGet, j.conf.URL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrJWKSFetch, err)
+	}
+
+	resp, err := j.conf.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrJWKSFetch, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: status %d", ErrJWKSUnexpected, resp.StatusCode)
+	}
+
+	var body struct {
+		Keys []rawJWK `json:"keys"`
+	}
+
+	err = json.NewDecoder(io.LimitReader(resp.Body, maxJWKSSize)).Decode(&body)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrJWKSUnexpected, err)
+	}
+
+	now := time.Now()
+	set := &KeySet{
+		fetchedAt: now,
+		expiresAt: now.Add(j.conf.RefreshInterval),
+	}
+
+	if maxAge := cacheMaxAge(resp.Header); maxAge > 0 {
+		set.expiresAt = now.Add(max(maxAge, j.conf.MinRefreshInterval))
+	}
+
+	for _, raw := range body.Keys {
+		// Skip keys that are not meant for signature verification, or of unsupported types
+		if raw.Use != "" && raw.Use != "sig" {
+			continue
+		}
+
+		key, err := raw.parse()
+		if err != nil {
+			continue
+		}
+
+		set.keys = append(set.keys, jwk{
+			id:        raw.KeyID,
+			algorithm: raw.Algorithm,
+			key:       key,
+		})
+	}
+
+	return set, nil
+}
+
+// lookup returns key [kid] usable with [algorithm], an empty [kid] matching the only key usable with it.
+func (set *KeySet) lookup(kid string, algorithm string) (crypto.PublicKey, error) {
+	var candidates []jwk
+
+	for _, k := range set.keys {
+		if (kid == "" || k.id == kid) && (k.algorithm == "" || k.algorithm == algorithm) &&
+			keyMatchesAlgorithm(k.key, algorithm) {
+			candidates = append(candidates, k)
+		}
+	}
+
+	if len(candidates) != 1 {
+		return nil, fmt.Errorf("%w: %q for %s", ErrKeyNotFound, kid, algorithm)
+	}
+
+	return candidates[0].key, nil
+}
+
+func (raw *rawJWK) parse() (crypto.PublicKey, error) {
+	switch raw.KeyType {
+	case "RSA":
+		n, err := decodeBigInt(raw.N)
+		if err != nil {
+			return nil, err
+		}
+
+		e, err := decodeBigInt(raw.E)
+		if err != nil {
+			return nil, err
+		}
+
+		if n.BitLen() < minRSAKeyBits || !e.IsInt64() || e.Int64() < 3 || e.Int64() > 1<<31-1 {
+			return nil, fmt.Errorf("%w: unsupported RSA parameters", ErrInvalidKey)
+		}
+
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+
+		switch raw.Curve {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("%w: unsupported curve %s", ErrInvalidKey, raw.Curve)
+		}
+
+		size := (curve.Params().BitSize + 7) / 8
+
+		x, err := base64.RawURLEncoding.DecodeString(raw.X)
+		if err != nil || len(x) != size {
+			return nil, fmt.Errorf("%w: invalid x coordinate", ErrInvalidKey)
+		}
+
+		y, err := base64.RawURLEncoding.DecodeString(raw.Y)
+		if err != nil || len(y) != size {
+			return nil, fmt.Errorf("%w: invalid y coordinate", ErrInvalidKey)
+		}
+
+		// Validates point is on curve
+		key, err := ecdsa.ParseUncompressedPublicKey(curve, append(append([]byte{4}, x...), y...))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidKey, err)
+		}
+
+		return key, nil
+	case "OKP":
+		if raw.Curve != "Ed25519" {
+			return nil, fmt.Errorf("%w: unsupported curve %s", ErrInvalidKey, raw.Curve)
+		}
+
+		x, err := base64.RawURLEncoding.DecodeString(raw.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("%w: invalid public key", ErrInvalidKey)
+		}
+
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, fmt.Errorf("%w: unsupported key type %s", ErrInvalidKey, raw.KeyType)
+	}
+}
+
+// cacheMaxAge returns max-age directive of Cache-Control header in [h], zero if absent or if caching is
+// disabled.
+func cacheMaxAge(h http.Header) time.Duration {
+	var maxAge time.Duration
+
+	for directive := range strings.SplitSeq(h.Get(headkey.CacheControl), ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(directive), "=")
+
+		switch strings.ToLower(name) {
+		case "no-cache", "no-store":
+			return 0
+		case "max-age":
+			seconds, err := strconv.ParseInt(strings.Trim(value, `"`), 10, 64)
+			if err == nil && seconds > 0 {
+				maxAge = time.Duration(seconds) * time.Second
+			}
+		}
+	}
+
+	return maxAge
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(b) == 0 {
+		return nil, fmt.Errorf("%w: invalid integer", ErrInvalidKey)
+	}
+
+	return new(big.Int).SetBytes(b), nil
+}
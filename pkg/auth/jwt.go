@@ -0,0 +1,397 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	// Register hash functions used by signature algorithms
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+
+	"github.com/kemadev/go-framework/pkg/convenience/headkey"
+)
+
+// MethodJWT is the authentication method of principals authenticated by [JWTAuthenticator].
+const MethodJWT = "jwt"
+
+const (
+	// DefaultLeeway is the default clock skew tolerated when checking token times.
+	DefaultLeeway = time.Minute
+	// DefaultRolesClaim is the default claim holding principal roles.
+	DefaultRolesClaim = "roles"
+	// maxTokenSize bounds accepted tokens size.
+	maxTokenSize = 16 << 10
+)
+
+var (
+	ErrMalformedToken       = errors.New("malformed token")
+	ErrUnsupportedAlgorithm = errors.New("unsupported signing algorithm")
+	ErrInvalidSignature     = errors.New("invalid token signature")
+	ErrTokenExpired         = errors.New("token expired")
+	ErrTokenNotYetValid     = errors.New("token not yet valid")
+	ErrInvalidIssuer        = errors.New("invalid token issuer")
+	ErrInvalidAudience      = errors.New("invalid token audience")
+	ErrMissingSubject       = errors.New("missing token subject")
+	ErrMissingIssuer        = errors.New("issuer is required")
+	ErrMissingAudience      = errors.New("at least one audience is required")
+)
+
+// algorithm verifies signatures of a JWS algorithm, see RFC 7518 section 3.
+type algorithm struct {
+	hash crypto.Hash
+	// verify reports whether [sig] is a valid signature of [digest] for [key]
+	verify func(key crypto.PublicKey, hash crypto.Hash, digest []byte, sig []byte) bool
+}
+
+var algorithms = map[string]algorithm{
+	"RS256": {crypto.SHA256, verifyPKCS1v15},
+	"RS384": {crypto.SHA384, verifyPKCS1v15},
+	"RS512": {crypto.SHA512, verifyPKCS1v15},
+	"PS256": {crypto.SHA256, verifyPSS},
+	"PS384": {crypto.SHA384, verifyPSS},
+	"PS512": {crypto.SHA512, verifyPSS},
+	"ES256": {crypto.SHA256, verifyECDSA},
+	"ES384": {crypto.SHA384, verifyECDSA},
+	"ES512": {crypto.SHA512, verifyECDSA},
+	// Ed25519 signs message itself
+	"EdDSA": {0, verifyEd25519},
+}
+
+// DefaultAlgorithms are the default accepted signing algorithms, that is all supported asymmetric ones.
+var DefaultAlgorithms = []string{
+	"RS256", "RS384", "RS512",
+	"PS256", "PS384", "PS512",
+	"ES256", "ES384", "ES512",
+	"EdDSA",
+}
+
+// JWTConfig defines the configuration for JWT authenticator.
+type JWTConfig struct {
+	// Expected iss claim, required
+	Issuer string
+	// Accepted aud claim values, token audience having to contain at least one of them, required
+	Audiences []string
+	// Key set verifying signatures
+	JWKS JWKSConfig
+	// Accepted signing algorithms. Defaults to [DefaultAlgorithms].
+	Algorithms []string
+	// Clock skew tolerated when checking exp, nbf and iat claims. Defaults to [DefaultLeeway].
+	Leeway time.Duration
+	// Claim holding principal roles, as an array of strings. Defaults to [DefaultRolesClaim].
+	RolesClaim string
+}
+
+// JWTAuthenticator authenticates requests carrying a JWT as bearer token, see RFC 6750.
+type JWTAuthenticator struct {
+	conf JWTConfig
+	keys *jwks
+}
+
+// NewJWTAuthenticator returns a [JWTAuthenticator]. Tokens must be signed by a key of configured JWKS using an
+// accepted algorithm, be currently valid, and carry expected issuer and audience as well as a subject, which
+// becomes principal ID. Principal scopes are read from scope (space-separated) or scp (array) claim.
+func NewJWTAuthenticator(conf JWTConfig) (*JWTAuthenticator, error) {
+	if conf.Issuer == "" {
+		return nil, ErrMissingIssuer
+	}
+
+	if len(conf.Audiences) == 0 {
+		return nil, ErrMissingAudience
+	}
+
+	if len(conf.Algorithms) == 0 {
+		conf.Algorithms = DefaultAlgorithms
+	}
+
+	for _, alg := range conf.Algorithms {
+		if _, ok := algorithms[alg]; !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnsupportedAlgorithm, alg)
+		}
+	}
+
+	if conf.Leeway == 0 {
+		conf.Leeway = DefaultLeeway
+	}
+
+	if conf.RolesClaim == "" {
+		conf.RolesClaim = DefaultRolesClaim
+	}
+
+	return &JWTAuthenticator{
+		conf: conf,
+		keys: newJWKS(conf.JWKS),
+	}, nil
+}
+
+// Challenge implements [Authenticator].
+func (a *JWTAuthenticator) Challenge() string {
+	return "Bearer"
+}
+
+// Authenticate implements [Authenticator].
+func (a *JWTAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+	scheme, token, found := strings.Cut(r.Header.Get(headkey.Authorization), " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return nil, ErrNoCredentials
+	}
+
+	token = strings.TrimSpace(token)
+
+	claims, err := a.verify(r, token)
+	if err != nil {
+		if errors.Is(err, ErrUnavailable) {
+			return nil, err
+		}
+
+		return nil, fmt.Errorf("%w: %w", ErrInvalidCredentials, err)
+	}
+
+	subject, _ := claims["sub"].(string)
+	if subject == "" {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidCredentials, ErrMissingSubject)
+	}
+
+	return &Principal{
+		ID:     subject,
+		Method: MethodJWT,
+		Scopes: scopes(claims),
+		Roles:  stringSlice(claims[a.conf.RolesClaim]),
+		Claims: claims,
+	}, nil
+}
+
+// verify returns claims of [token] once its signature and claims are verified.
+func (a *JWTAuthenticator) verify(r *http.Request, token string) (map[string]any, error) {
+	if len(token) > maxTokenSize {
+		return nil, fmt.Errorf("%w: token too large", ErrMalformedToken)
+	}
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: expected 3 parts, got %d", ErrMalformedToken, len(parts))
+	}
+
+	var header struct {
+		Algorithm string   `json:"alg"`
+		KeyID     string   `json:"kid"`
+		Critical  []string `json:"crit"`
+	}
+
+	err := decodeSegment(parts[0], &header)
+	if err != nil {
+		return nil, err
+	}
+
+	// No extension is supported, see RFC 7515 section 4.1.11
+	if len(header.Critical) > 0 {
+		return nil, fmt.Errorf("%w: unsupported critical header parameters", ErrMalformedToken)
+	}
+
+	alg, ok := algorithms[header.Algorithm]
+	if !ok || !slices.Contains(a.conf.Algorithms, header.Algorithm) {
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedAlgorithm, header.Algorithm)
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrMalformedToken, err)
+	}
+
+	key, err := a.keys.key(r.Context(), header.KeyID, header.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	signed := []byte(parts[0] + "." + parts[1])
+
+	digest := signed
+	if alg.hash != 0 {
+		h := alg.hash.New()
+		h.Write(signed)
+		digest = h.Sum(nil)
+	}
+
+	if !alg.verify(key, alg.hash, digest, sig) {
+		return nil, ErrInvalidSignature
+	}
+
+	var claims map[string]any
+
+	err = decodeSegment(parts[1], &claims)
+	if err != nil {
+		return nil, err
+	}
+
+	err = a.checkClaims(claims)
+	if err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
+
+func (a *JWTAuthenticator) checkClaims(claims map[string]any) error {
+	now := time.Now()
+
+	exp, ok := numericDate(claims["exp"])
+	if !ok || now.After(exp.Add(a.conf.Leeway)) {
+		return ErrTokenExpired
+	}
+
+	if nbf, ok := numericDate(claims["nbf"]); ok && now.Add(a.conf.Leeway).Before(nbf) {
+		return ErrTokenNotYetValid
+	}
+
+	if iat, ok := numericDate(claims["iat"]); ok && now.Add(a.conf.Leeway).Before(iat) {
+		return ErrTokenNotYetValid
+	}
+
+	if iss, _ := claims["iss"].(string); iss != a.conf.Issuer {
+		return fmt.Errorf("%w: %q", ErrInvalidIssuer, iss)
+	}
+
+	// Audience is either a string or an array of strings, see RFC 7519 section 4.1.3
+	audiences := stringSlice(claims["aud"])
+	if aud, ok := claims["aud"].(string); ok {
+		audiences = []string{aud}
+	}
+
+	if !slices.ContainsFunc(audiences, func(aud string) bool {
+		return slices.Contains(a.conf.Audiences, aud)
+	}) {
+		return fmt.Errorf("%w: %q", ErrInvalidAudience, audiences)
+	}
+
+	return nil
+}
+
+func decodeSegment(segment string, v any) error {
+	b, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrMalformedToken, err)
+	}
+
+	err = json.Unmarshal(b, v)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrMalformedToken, err)
+	}
+
+	return nil
+}
+
+// numericDate returns time of a NumericDate claim, see RFC 7519 section 2.
+func numericDate(v any) (time.Time, bool) {
+	seconds, ok := v.(float64)
+	if !ok {
+		return time.Time{}, false
+	}
+
+	return time.Unix(0, 0).Add(time.Duration(seconds * float64(time.Second))), true
+}
+
+// scopes returns scopes of scope (space-separated string, see RFC 8693 section 4.2) or scp claim.
+func scopes(claims map[string]any) []string {
+	if scope, ok := claims["scope"].(string); ok {
+		return strings.Fields(scope)
+	}
+
+	if scp, ok := claims["scp"].(string); ok {
+		return strings.Fields(scp)
+	}
+
+	return stringSlice(claims["scp"])
+}
+
+// stringSlice returns strings of JSON array [v], ignoring other values.
+func stringSlice(v any) []string {
+	values, ok := v.([]any)
+	if !ok {
+		return nil
+	}
+
+	res := make([]string, 0, len(values))
+
+	for _, value := range values {
+		if s, ok := value.(string); ok {
+			res = append(res, s)
+		}
+	}
+
+	return res
+}
+
+// keyMatchesAlgorithm reports whether [key] type can verify signatures of [alg].
+func keyMatchesAlgorithm(key crypto.PublicKey, alg string) bool {
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		return strings.HasPrefix(alg, "RS") || strings.HasPrefix(alg, "PS")
+	case *ecdsa.PublicKey:
+		// Curve is tied to algorithm, see RFC 7518 section 3.4
+		switch alg {
+		case "ES256":
+			return k.Curve.Params().Name == "P-256"
+		case "ES384":
+			return k.Curve.Params().Name == "P-384"
+		case "ES512":
+			return k.Curve.Params().Name == "P-521"
+		}
+
+		return false
+	case ed25519.PublicKey:
+		return alg == "EdDSA"
+	default:
+		return false
+	}
+}
+
+func verifyPKCS1v15(key crypto.PublicKey, hash crypto.Hash, digest []byte, sig []byte) bool {
+	k, ok := key.(*rsa.PublicKey)
+
+	return ok && rsa.VerifyPKCS1v15(k, hash, digest, sig) == nil
+}
+
+func verifyPSS(key crypto.PublicKey, hash crypto.Hash, digest []byte, sig []byte) bool {
+	k, ok := key.(*rsa.PublicKey)
+
+	return ok && rsa.VerifyPSS(k, hash, digest, sig, &rsa.PSSOptions{
+		SaltLength: rsa.PSSSaltLengthEqualsHash,
+	}) == nil
+}
+
+// verifyECDSA verifies a signature made of fixed-size R and S concatenation, see RFC 7518 section 3.4.
+func verifyECDSA(key crypto.PublicKey, _ crypto.Hash, digest []byte, sig []byte) bool {
+	k, ok := key.(*ecdsa.PublicKey)
+	if !ok {
+		return false
+	}
+
+	size := (k.Curve.Params().BitSize + 7) / 8
+	if len(sig) != 2*size {
+		return false
+	}
+
+	r := new(big.Int).SetBytes(sig[:size])
+	s := new(big.Int).SetBytes(sig[size:])
+
+	return ecdsa.Verify(k, digest, r, s)
+}
+
+func verifyEd25519(key crypto.PublicKey, _ crypto.Hash, message []byte, sig []byte) bool {
+	k, ok := key.(ed25519.PublicKey)
+
+	return ok && ed25519.Verify(k, message, sig)
+}
@@ -0,0 +1,85 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+// oidcDiscoveryPath is the path of OpenID Provider configuration, relative to issuer.
+const oidcDiscoveryPath = "/.well-known/openid-configuration"
+
+var ErrDiscovery = errors.New("error discovering OpenID Provider configuration")
+
+// NewOIDCAuthenticator returns a [JWTAuthenticator] verifying tokens issued by OpenID Provider [conf.Issuer],
+// whose JWKS URL is discovered from its configuration, see [OpenID Connect Discovery]. Discovery happens once,
+// keys being fetched again as configured.
+//
+// [OpenID Connect Discovery]: https://openid.net/specs/openid-connect-discovery-1_0.html
+func NewOIDCAuthenticator(c context.Context, conf JWTConfig) (*JWTAuthenticator, error) {
+	if conf.Issuer == "" {
+		return nil, ErrMissingIssuer
+	}
+
+	client := conf.JWKS.Client
+	if client == nil {
+		client = &http.Client{
+			Transport: otelhttp.NewTransport(http.DefaultTransport),
+			Timeout:   10 * time.Second,
+		}
+	}
+
+	req, err := http.NewRequestWithContext(
+		c,
+		http.MethodGet,
+		strings.TrimSuffix(conf.Issuer, "/")+oidcDiscoveryPath,
+		nil,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrDiscovery, err)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrDiscovery, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: status %d", ErrDiscovery, resp.StatusCode)
+	}
+
+	var provider struct {
+		Issuer  string `json:"issuer"`
+		JWKSURI string `json:"jwks_uri"`
+	}
+
+	err = json.NewDecoder(io.LimitReader(resp.Body, maxJWKSSize)).Decode(&provider)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrDiscovery, err)
+	}
+
+	// Prevents a provider from impersonating another one, see OpenID Connect Discovery section 4.3
+	if provider.Issuer != conf.Issuer {
+		return nil, fmt.Errorf("%w: issuer %q does not match %q", ErrDiscovery, provider.Issuer, conf.Issuer)
+	}
+
+	if provider.JWKSURI == "" {
+		return nil, fmt.Errorf("%w: missing jwks_uri", ErrDiscovery)
+	}
+
+	conf.JWKS.URL = provider.JWKSURI
+	conf.JWKS.Client = client
+
+	return NewJWTAuthenticator(conf)
+}
@@ -0,0 +1,126 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package authz
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/kemadev/go-framework/pkg/auth"
+	"github.com/kemadev/go-framework/pkg/convenience/headkey"
+	"github.com/kemadev/go-framework/pkg/convenience/log"
+	"github.com/kemadev/go-framework/pkg/convenience/resp"
+	"github.com/kemadev/go-framework/pkg/router"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+)
+
+const packageName = "github.com/kemadev/go-framework/pkg/authz"
+
+const (
+	// DecisionAllow is the audit log decision of allowed requests.
+	DecisionAllow = "allow"
+	// DecisionDeny is the audit log decision of denied requests.
+	DecisionDeny = "deny"
+)
+
+// Config defines the configuration for authorization middleware.
+type Config struct {
+	// Router whose routes requests are matched against
+	Router *router.Router
+	// WWW-Authenticate header values sent along with 401 responses, see [auth.Authenticator]
+	Challenges []string
+}
+
+// NewMiddleware returns a middleware authorizing requests against requirements of routes of configured router
+// they match, see [Requirements]. It must be used after [auth.NewMiddleware], typically with [auth.Config] Optional set so
+// that public routes are reachable without credentials.
+//
+// Requests are denied by default: routes declaring no requirement are rejected with 403 status. Requests
+// without principal are rejected with 401 status, and requests whose principal does not meet a requirement
+// with 403 status, both using problem details, see [resp.ProblemJSON]. Unmet requirements are only disclosed in
+// logs. Requests matching no route are let through, so that mux answers them, unless mux matched a pattern
+// unknown to router, e.g. registered using its embedded mux, which is denied. Each decision is logged,
+// allowed requests at info level and denied ones at warning level.
+func NewMiddleware(conf Config) func(http.Handler) http.Handler {
+	logger := log.Logger(packageName)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route, found := conf.Router.Lookup(r)
+			if !found && route.Pattern == "" {
+				next.ServeHTTP(w, r)
+
+				return
+			}
+
+			principal, _ := auth.FromContext(r.Context())
+
+			status, reason := http.StatusForbidden, "route not registered with router"
+			if found {
+				// Let predicates read path values when used in routers global chain
+				status, reason = authorize(Requirements(route), principal, route.Bind(r))
+			}
+
+			attrs := []any{
+				slog.String("authz.decision", DecisionAllow),
+				slog.String(string(semconv.HTTPRouteKey), route.Pattern),
+				slog.String("authz.reason", reason),
+			}
+			if principal != nil {
+				attrs = append(
+					attrs,
+					slog.String(string(semconv.EnduserIDKey), principal.ID),
+					slog.String("authz.method", principal.Method),
+				)
+			}
+
+			if status == http.StatusOK {
+				logger.InfoContext(r.Context(), "request authorized", attrs...)
+				next.ServeHTTP(w, r)
+
+				return
+			}
+
+			attrs[0] = slog.String("authz.decision", DecisionDeny)
+			logger.WarnContext(r.Context(), "request denied", attrs...)
+
+			if status == http.StatusUnauthorized {
+				for _, challenge := range conf.Challenges {
+					w.Header().Add(headkey.WWWAuthenticate, challenge)
+				}
+			}
+
+			err := resp.ProblemJSON(w, resp.NewProblem(status, ""))
+			if err != nil {
+				log.ErrLog(packageName, "error sending problem", err)
+			}
+		})
+	}
+}
+
+// authorize returns status to answer request with, [net/http.StatusOK] meaning allowed, along with reason of
+// decision.
+func authorize(reqs []Requirement, principal *auth.Principal, r *http.Request) (int, string) {
+	if len(reqs) == 0 {
+		return http.StatusForbidden, "no requirement declared for route"
+	}
+
+	for _, req := range reqs {
+		if req.public {
+			return http.StatusOK, "requirement " + req.String() + " met"
+		}
+	}
+
+	if principal == nil {
+		return http.StatusUnauthorized, "authentication required"
+	}
+
+	for _, req := range reqs {
+		if !req.check(principal, r) {
+			return http.StatusForbidden, "requirement " + req.String() + " not met"
+		}
+	}
+
+	return http.StatusOK, "all requirements met"
+}
@@ -0,0 +1,210 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package authz_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kemadev/go-framework/pkg/auth"
+	"github.com/kemadev/go-framework/pkg/authz"
+	"github.com/kemadev/go-framework/pkg/router"
+)
+
+func TestMiddleware(t *testing.T) {
+	t.Parallel()
+
+	handler := func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	app := router.New()
+	app.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal := &auth.Principal{
+				ID:     r.Header.Get("X-Principal"),
+				Scopes: []string{"items:read"},
+				Roles:  []string{r.Header.Get("X-Role")},
+			}
+			if principal.ID != "" {
+				r = r.WithContext(auth.NewContext(r.Context(), principal))
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	})
+	app.Use(authz.NewMiddleware(authz.Config{
+		Router:     app,
+		Challenges: []string{`Bearer realm="test"`},
+	}))
+
+	app.HandleFunc("GET /health", handler, authz.Public())
+	app.HandleFunc("GET /undeclared", handler)
+	app.Group(func(r *router.Router) {
+		r.Annotate(authz.Scope("items:read"))
+		r.HandleFunc("GET /items", handler)
+		r.HandleFunc("DELETE /items", handler, authz.Role("admin"))
+		r.HandleFunc("GET /users/{id}", handler, authz.Predicate(
+			"owner",
+			func(p *auth.Principal, r *http.Request) bool {
+				return p.ID == r.PathValue("id")
+			},
+		))
+	})
+	app.HandleFunc("GET /things/{id}", handler, authz.Public())
+	// Served by mux using pattern of route above, requirements and wildcard names still being its own
+	app.HandleFunc("OPTIONS /things/{name}", handler, authz.Predicate(
+		"owner",
+		func(p *auth.Principal, r *http.Request) bool {
+			return p.ID == r.PathValue("name")
+		},
+	))
+	app.ServeMux.HandleFunc("GET /direct", handler)
+
+	tests := []struct {
+		Method         string
+		RequestPath    string
+		Principal      string
+		Role           string
+		ExpectedStatus int
+	}{
+		{
+			Method:         http.MethodGet,
+			RequestPath:    "/health",
+			ExpectedStatus: http.StatusOK,
+		},
+		{
+			Method:         http.MethodGet,
+			RequestPath:    "/undeclared",
+			Principal:      "alice",
+			ExpectedStatus: http.StatusForbidden,
+		},
+		{
+			Method:         http.MethodGet,
+			RequestPath:    "/items",
+			ExpectedStatus: http.StatusUnauthorized,
+		},
+		{
+			Method:         http.MethodGet,
+			RequestPath:    "/items",
+			Principal:      "alice",
+			ExpectedStatus: http.StatusOK,
+		},
+		{
+			Method:         http.MethodDelete,
+			RequestPath:    "/items",
+			Principal:      "alice",
+			ExpectedStatus: http.StatusForbidden,
+		},
+		{
+			Method:         http.MethodDelete,
+			RequestPath:    "/items",
+			Principal:      "alice",
+			Role:           "admin",
+			ExpectedStatus: http.StatusOK,
+		},
+		{
+			Method:         http.MethodGet,
+			RequestPath:    "/users/alice",
+			Principal:      "alice",
+			ExpectedStatus: http.StatusOK,
+		},
+		{
+			Method:         http.MethodGet,
+			RequestPath:    "/users/bob",
+			Principal:      "alice",
+			ExpectedStatus: http.StatusForbidden,
+		},
+		{
+			Method:         http.MethodGet,
+			RequestPath:    "/unknown",
+			ExpectedStatus: http.StatusNotFound,
+		},
+		{
+			Method:         http.MethodOptions,
+			RequestPath:    "/items",
+			ExpectedStatus: http.StatusNoContent,
+		},
+		{
+			Method:         http.MethodOptions,
+			RequestPath:    "/things/alice",
+			ExpectedStatus: http.StatusUnauthorized,
+		},
+		{
+			Method:         http.MethodOptions,
+			RequestPath:    "/things/bob",
+			Principal:      "alice",
+			ExpectedStatus: http.StatusForbidden,
+		},
+		{
+			Method:         http.MethodOptions,
+			RequestPath:    "/things/alice",
+			Principal:      "alice",
+			ExpectedStatus: http.StatusOK,
+		},
+		{
+			Method:         http.MethodGet,
+			RequestPath:    "/direct",
+			Principal:      "alice",
+			ExpectedStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, test := range tests {
+		req, err := http.NewRequestWithContext(
+			context.Background(),
+			test.Method,
+			test.RequestPath,
+			nil,
+		)
+		if err != nil {
+			t.Errorf("NewRequestWithContext: %s", err)
+		}
+
+		req.Header.Set("X-Principal", test.Principal)
+		req.Header.Set("X-Role", test.Role)
+
+		rr := httptest.NewRecorder()
+		app.ServeHTTP(rr, req)
+
+		if rr.Code != test.ExpectedStatus {
+			t.Errorf(
+				"%s %s as %q: expected status %d but was %d",
+				test.Method,
+				test.RequestPath,
+				test.Principal,
+				test.ExpectedStatus,
+				rr.Code,
+			)
+		}
+
+		if rr.Code == http.StatusUnauthorized && rr.Header().Get("WWW-Authenticate") == "" {
+			t.Errorf("%s %s: expected challenge", test.Method, test.RequestPath)
+		}
+
+		if rr.Code == http.StatusForbidden && rr.Header().Get("Content-Type") != "application/problem+json" {
+			t.Errorf("%s %s: expected problem response", test.Method, test.RequestPath)
+		}
+	}
+}
+
+func TestRequirements(t *testing.T) {
+	t.Parallel()
+
+	app := router.New()
+	app.Annotate(authz.Authenticated(), "other")
+	app.HandleFunc("GET /items", func(_ http.ResponseWriter, _ *http.Request) {}, authz.Role("a", "b"))
+
+	routes := app.Routes()
+	if len(routes) != 1 {
+		t.Fatalf("expected 1 route; got %v", routes)
+	}
+
+	reqs := authz.Requirements(routes[0])
+	if len(reqs) != 2 || reqs[0].String() != "authenticated" || reqs[1].String() != "role(a,b)" {
+		t.Errorf("unexpected requirements %v", reqs)
+	}
+}
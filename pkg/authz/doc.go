@@ -0,0 +1,8 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+// Package authz authorizes requests against declarative [Requirement] values attached to routes and groups of a
+// [github.com/kemadev/go-framework/pkg/router.Router], using principals authenticated by
+// [github.com/kemadev/go-framework/pkg/auth]. Routes declaring no requirement are denied, and each decision is
+// recorded as an audit log record.
+package authz
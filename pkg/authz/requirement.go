@@ -0,0 +1,97 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package authz
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/kemadev/go-framework/pkg/auth"
+	"github.com/kemadev/go-framework/pkg/router"
+)
+
+// Requirement is an authorization requirement, to be attached to routes and groups using
+// [router.Router.Annotate] or route annotations, see [router.Router.Handle]. All requirements of a route must
+// be met for a request to be allowed.
+type Requirement struct {
+	name   string
+	public bool
+	check  func(p *auth.Principal, r *http.Request) bool
+}
+
+// String returns a description of requirement, as used in audit logs.
+func (req Requirement) String() string {
+	return req.name
+}
+
+// Public returns a requirement letting all requests through, including unauthenticated ones. It takes
+// precedence over other requirements of a route.
+func Public() Requirement {
+	return Requirement{
+		name:   "public",
+		public: true,
+	}
+}
+
+// Authenticated returns a requirement met by any authenticated principal.
+func Authenticated() Requirement {
+	return Requirement{
+		name: "authenticated",
+		check: func(_ *auth.Principal, _ *http.Request) bool {
+			return true
+		},
+	}
+}
+
+// Role returns a requirement met by principals having any of [roles].
+func Role(roles ...string) Requirement {
+	return Requirement{
+		name: "role(" + strings.Join(roles, ",") + ")",
+		check: func(p *auth.Principal, _ *http.Request) bool {
+			return slices.ContainsFunc(roles, func(role string) bool {
+				return slices.Contains(p.Roles, role)
+			})
+		},
+	}
+}
+
+// Scope returns a requirement met by principals granted all of [scopes].
+func Scope(scopes ...string) Requirement {
+	return Requirement{
+		name: "scope(" + strings.Join(scopes, ",") + ")",
+		check: func(p *auth.Principal, _ *http.Request) bool {
+			for _, scope := range scopes {
+				if !slices.Contains(p.Scopes, scope) {
+					return false
+				}
+			}
+
+			return true
+		},
+	}
+}
+
+// Predicate returns a requirement met when [fn] returns true for authenticated principal and request, e.g. to
+// check resource ownership using path values. [name] describes requirement in audit logs.
+func Predicate(name string, fn func(p *auth.Principal, r *http.Request) bool) Requirement {
+	return Requirement{
+		name:  name,
+		check: fn,
+	}
+}
+
+// Requirements returns requirements attached to [route], in declaration order.
+func Requirements(route router.Route) []Requirement {
+	var reqs []Requirement
+
+	for _, annotation := range route.Annotations {
+		req, ok := annotation.(Requirement)
+		if ok {
+			reqs = append(reqs, req)
+		}
+	}
+
+	return reqs
+}
@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"log/slog"
 	"net"
+	"net/netip"
 	"net/url"
 	"os"
 	"reflect"
@@ -56,8 +57,15 @@ type Server struct {
 	WriteTimeout time.Duration `default:"15s"       required:"true"`
 	// IdleTimeout is the HTTP idle timeout for the HTTP server
 	IdleTimeout time.Duration `default:"60s"       required:"true"`
-	// ProxyHeader is the proxy header for forwarded entity
+	// ProxyHeader is the proxy header for forwarded entity, one of Forwarded, X-Forwarded-For and
+	// X-Envoy-External-Address
 	ProxyHeader string `default:"Forwarded" required:"true"`
+	// TrustedProxies are the comma-separated CIDR prefixes of proxies whose ProxyHeader is trusted
+	TrustedProxies []netip.Prefix `required:"false"`
+	// AllowedClients are the comma-separated CIDR prefixes of allowed clients, all clients being allowed if empty
+	AllowedClients []netip.Prefix `required:"false"`
+	// DeniedClients are the comma-separated CIDR prefixes of denied clients, taking precedence over allowed ones
+	DeniedClients []netip.Prefix `required:"false"`
 	// ShutdownGracePeriod is the grace period to give the server before canceling contexts upon shutdown
 	ShutdownGracePeriod time.Duration `default:"5s"        required:"true"`
 }
@@ -246,6 +254,10 @@ func setFieldValue(field reflect.Value, value, envVarName string) error {
 			return setURLSlice(field, value, envVarName)
 		}
 
+		if field.Type().Elem() == reflect.TypeOf(netip.Prefix{}) {
+			return setPrefixSlice(field, value, envVarName)
+		}
+
 		return fmt.Errorf(
 			"%s - unsupported slice type %s: %w",
 			envVarName,
@@ -388,6 +400,42 @@ func setURLSlice(field reflect.Value, value, envVarName string) error {
 	return nil
 }
 
+// setPrefixSlice parses a comma-separated string of CIDR prefixes into a []netip.Prefix slice. Bare addresses
+// are parsed as single-address prefixes.
+func setPrefixSlice(field reflect.Value, value, envVarName string) error {
+	parts := strings.Split(value, ",")
+	result := make([]netip.Prefix, 0, len(parts))
+
+	for i, str := range parts {
+		str = strings.TrimSpace(str)
+		if str == "" {
+			continue
+		}
+
+		prefix, err := netip.ParsePrefix(str)
+		if err != nil {
+			addr, addrErr := netip.ParseAddr(str)
+			if addrErr != nil {
+				return fmt.Errorf(
+					"%s - invalid prefix %s at index %d: %w",
+					envVarName,
+					str,
+					i,
+					ErrVariableMalformed,
+				)
+			}
+
+			prefix = netip.PrefixFrom(addr, addr.BitLen())
+		}
+
+		result = append(result, prefix.Masked())
+	}
+
+	field.Set(reflect.ValueOf(result))
+
+	return nil
+}
+
 // buildEnvVarName builds the environment variable name from prefix and field path.
 func buildEnvVarName(prefix, parentPath, fieldName string) string {
 	parts := []string{CamelToScreamingSnake(prefix)}
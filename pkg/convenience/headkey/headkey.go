@@ -7,7 +7,7 @@ const (
 	// https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Accept
 	Accept = "Accept"
 	// https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Accept-Charset
-	AcceptCharset = "Accept"
+	AcceptCharset = "Accept-Charset"
 	// https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Accept-Encoding
 	AcceptEncoding = "Accept-Encoding"
 	// https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Accept-Language
@@ -38,6 +38,8 @@ const (
 	Authorization = "Authorization"
 	// https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Cache-Control
 	CacheControl = "Cache-Control"
+	// https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Clear-Site-Data
+	ClearSiteData = "Clear-Site-Data"
 	// https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Content-Digest
 	ContentDigest = "Content-Digest"
 	// https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Content-Encoding
@@ -52,6 +54,8 @@ const (
 	ContentRange = "Content-Range"
 	// https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Content-Security-Policy
 	ContentSecurityPolicy = "Content-Security-Policy"
+	// https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Content-Security-Policy-Report-Only
+	ContentSecurityPolicyReportOnly = "Content-Security-Policy-Report-Only"
 	// https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Content-Type
 	ContentType = "Content-Type"
 	// https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Cross-Origin-Embedder-Policy
@@ -86,24 +90,42 @@ const (
 	IntegrityPolicy = "Integrity-Policy"
 	// https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Keep-Alive
 	KeepAlive = "Keep-Alive"
+	// https://html.spec.whatwg.org/multipage/server-sent-events.html#last-event-id
+	LastEventID = "Last-Event-ID"
 	// https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Last-Modified
 	LastModified = "Last-Modified"
 	// https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Location
 	Location = "Location"
 	// https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Max-Forwards
 	MaxForwards = "Max-Forwards"
+	// https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Origin
+	Origin = "Origin"
+	// https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Origin-Agent-Cluster
+	OriginAgentCluster = "Origin-Agent-Cluster"
+	// https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Permissions-Policy
+	PermissionsPolicy = "Permissions-Policy"
 	// https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Prefer
 	Prefer = "Prefer"
 	// https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Priority
 	Priority = "Priority"
 	// https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Range
 	Range = "Range"
+	// https://datatracker.ietf.org/doc/html/draft-ietf-httpapi-ratelimit-headers-07#section-5.1
+	RateLimitLimit = "RateLimit-Limit"
+	// https://datatracker.ietf.org/doc/html/draft-ietf-httpapi-ratelimit-headers-07#section-5.4
+	RateLimitPolicy = "RateLimit-Policy"
+	// https://datatracker.ietf.org/doc/html/draft-ietf-httpapi-ratelimit-headers-07#section-5.2
+	RateLimitRemaining = "RateLimit-Remaining"
+	// https://datatracker.ietf.org/doc/html/draft-ietf-httpapi-ratelimit-headers-07#section-5.3
+	RateLimitReset = "RateLimit-Reset"
 	// https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Referer
 	Referer = "Referer"
 	// https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Retry-After
 	RetryAfter = "Retry-After"
 	// https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Referrer-Policy
 	ReferrerPolicy = "Referrer-Policy"
+	// https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Reporting-Endpoints
+	ReportingEndpoints = "Reporting-Endpoints"
 	// https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Sec-Fetch-Dest
 	SecFetchDest = "Sec-Fetch-Dest"
 	// https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Sec-Fetch-Mode
@@ -148,6 +170,8 @@ const (
 	WWWAuthenticate = "WWW-Authenticate"
 	// https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/X-Content-Type-Options
 	XContentTypeOptions = "X-Content-Type-Options"
+	// https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/X-Permitted-Cross-Domain-Policies
+	XPermittedCrossDomainPolicies = "X-Permitted-Cross-Domain-Policies"
 	// Custom token used as CSRF token.
 	XCSRFToken = "X-CSRF-Token"
 	// https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/X-Frame-Options
@@ -156,4 +180,6 @@ const (
 	XForwardedFor = "X-Forwarded-For"
 	// https://www.envoyproxy.io/docs/envoy/latest/configuration/http/http_conn_man/headers#x-envoy-external-address
 	XEnvoyExternalAddress = "X-Envoy-External-Address"
+	// https://github.com/grpc/grpc/blob/master/doc/PROTOCOL-HTTP2.md#requests
+	GRPCTimeout = "Grpc-Timeout"
 )
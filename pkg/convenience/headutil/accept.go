@@ -9,52 +9,226 @@ import (
 	"strings"
 
 	"github.com/kemadev/go-framework/pkg/convenience/headkey"
+	"github.com/kemadev/go-framework/pkg/convenience/headval"
 )
 
 // AcceptedValue represents a parsed Accept header value with its quality factor.
 type AcceptedValue struct {
 	Value   string
 	Quality float64
+	// Params holds media type parameters, that is, parameters preceding quality
+	Params map[string]string
 }
 
+// Match specificity, used to rank ranges matching a given offer.
+const (
+	specificityNone = iota
+	specificityWildcard
+	specificityFallback
+	specificityPrefix
+	specificityExact
+	specificityParams
+)
+
 // Accepts returns whether client signals accepting given media type (based on Accept header).
-func Accepts(h http.Header, encoding string) bool {
-	return accepts(h.Get(headkey.Accept), encoding)
+func Accepts(h http.Header, mediaType string) bool {
+	_, ok := Negotiate(h, []string{mediaType})
+
+	return ok
 }
 
 // AcceptsEncoding returns whether client signals accepting given encoding.
 func AcceptsEncoding(h http.Header, encoding string) bool {
-	return accepts(h.Get(headkey.AcceptEncoding), encoding)
+	_, ok := NegotiateEncoding(h, []string{encoding})
+
+	return ok
 }
 
 // AcceptsLanguage returns whether client signals accepting given language.
 func AcceptsLanguage(h http.Header, language string) bool {
-	return accepts(h.Get(headkey.AcceptLanguage), language)
+	_, ok := NegotiateLanguage(h, []string{language})
+
+	return ok
 }
 
-func accepts(head, val string) bool {
-	if val == "" {
-		return false
+// Negotiate returns the media type from offers that best matches the Accept header, following [RFC 9110 proactive
+// negotiation]. Offers are given by order of server preference, which breaks ties between equally accepted offers.
+// Media ranges such as "text/*" and media type parameters are supported, the most specific range matching an offer
+// determining its quality. It returns false if no offer is acceptable. When client does not send an Accept header,
+// the first offer is returned.
+//
+// [RFC 9110 proactive negotiation]: https://www.rfc-editor.org/rfc/rfc9110#section-12.5.1
+func Negotiate(h http.Header, offers []string) (string, bool) {
+	return negotiate(h, headkey.Accept, offers, matchMediaType)
+}
+
+// NegotiateEncoding returns the content coding from offers that best matches the Accept-Encoding header. Identity is
+// acceptable unless explicitly refused, and is the only acceptable coding when client does not send the header.
+func NegotiateEncoding(h http.Header, offers []string) (string, bool) {
+	head := strings.Join(h.Values(headkey.AcceptEncoding), ",")
+	accepted := parseAcceptHeader(head)
+
+	best, bestQuality := "", 0.0
+
+	for _, offer := range offers {
+		quality, specificity := rank(accepted, offer, matchToken)
+
+		if specificity == specificityNone && strings.EqualFold(offer, headval.EncodingIdentity) {
+			// Identity is always acceptable, unless refused by an explicit or wildcard entry
+			quality = 1
+			if head != "" {
+				quality = 0.001
+			}
+		}
+
+		if quality > bestQuality {
+			best, bestQuality = offer, quality
+		}
 	}
 
+	return best, bestQuality > 0
+}
+
+// NegotiateLanguage returns the language tag from offers that best matches the Accept-Language header. Language
+// ranges match more specific tags (e.g. "en" matches "en-US"), and fall back to less specific ones (e.g. "en-US"
+// matches "en") with a lower precedence, see [RFC 4647].
+//
+// [RFC 4647]: https://www.rfc-editor.org/rfc/rfc4647
+func NegotiateLanguage(h http.Header, offers []string) (string, bool) {
+	return negotiate(h, headkey.AcceptLanguage, offers, matchLanguage)
+}
+
+// NegotiateCharset returns the charset from offers that best matches the Accept-Charset header.
+func NegotiateCharset(h http.Header, offers []string) (string, bool) {
+	return negotiate(h, headkey.AcceptCharset, offers, matchToken)
+}
+
+func negotiate(
+	h http.Header,
+	key string,
+	offers []string,
+	match func(accepted AcceptedValue, offer string) int,
+) (string, bool) {
+	if len(offers) == 0 {
+		return "", false
+	}
+
+	head := strings.Join(h.Values(key), ",")
 	if head == "" {
-		return true
+		return offers[0], true
 	}
 
 	accepted := parseAcceptHeader(head)
 
+	best, bestQuality, bestSpecificity := "", 0.0, specificityNone
+
+	for _, offer := range offers {
+		quality, specificity := rank(accepted, offer, match)
+
+		if quality > bestQuality || (quality == bestQuality && quality > 0 && specificity > bestSpecificity) {
+			best, bestQuality, bestSpecificity = offer, quality, specificity
+		}
+	}
+
+	return best, bestQuality > 0
+}
+
+// rank returns the quality of offer, given by the most specific matching accepted value, and the specificity of the match.
+func rank(
+	accepted []AcceptedValue,
+	offer string,
+	match func(accepted AcceptedValue, offer string) int,
+) (float64, int) {
+	quality, specificity := 0.0, specificityNone
+
 	for _, value := range accepted {
-		if value.Quality == 0 {
-			continue
+		s := match(value, offer)
+		if s > specificity || (s == specificity && s != specificityNone && value.Quality > quality) {
+			quality, specificity = value.Quality, s
 		}
+	}
 
-		valueLower := strings.ToLower(value.Value)
-		if valueLower == val || valueLower == "*" {
-			return true
+	return quality, specificity
+}
+
+func matchToken(accepted AcceptedValue, offer string) int {
+	switch {
+	case strings.EqualFold(accepted.Value, offer):
+		return specificityExact
+	case accepted.Value == "*":
+		return specificityWildcard
+	default:
+		return specificityNone
+	}
+}
+
+func matchMediaType(accepted AcceptedValue, offer string) int {
+	offerType, offerParams := splitMediaType(offer)
+
+	acceptedType, acceptedSubtype, ok := strings.Cut(strings.ToLower(accepted.Value), "/")
+	if !ok {
+		return specificityNone
+	}
+
+	typ, subtype, ok := strings.Cut(offerType, "/")
+	if !ok {
+		return specificityNone
+	}
+
+	switch {
+	case acceptedType == "*" && acceptedSubtype == "*":
+		return specificityWildcard
+	case acceptedType == typ && acceptedSubtype == "*":
+		return specificityPrefix
+	case acceptedType != typ || acceptedSubtype != subtype:
+		return specificityNone
+	}
+
+	if len(accepted.Params) == 0 {
+		return specificityExact
+	}
+
+	for key, val := range accepted.Params {
+		if !strings.EqualFold(offerParams[key], val) {
+			return specificityNone
+		}
+	}
+
+	return specificityParams
+}
+
+func matchLanguage(accepted AcceptedValue, offer string) int {
+	rng, tag := strings.ToLower(accepted.Value), strings.ToLower(offer)
+
+	switch {
+	case rng == "*":
+		return specificityWildcard
+	case rng == tag:
+		return specificityExact
+	case strings.HasPrefix(tag, rng+"-"):
+		return specificityPrefix
+	case strings.HasPrefix(rng, tag+"-"):
+		return specificityFallback
+	default:
+		return specificityNone
+	}
+}
+
+// splitMediaType returns the lowercased media type and its parameters.
+func splitMediaType(mediaType string) (string, map[string]string) {
+	parts := strings.Split(mediaType, ";")
+	params := make(map[string]string, len(parts)-1)
+
+	for _, part := range parts[1:] {
+		key, val, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			continue
 		}
+
+		params[strings.ToLower(strings.TrimSpace(key))] = strings.Trim(strings.TrimSpace(val), `"`)
 	}
 
-	return false
+	return strings.ToLower(strings.TrimSpace(parts[0])), params
 }
 
 func parseAcceptHeader(header string) []AcceptedValue {
@@ -78,37 +252,44 @@ func parseAcceptHeader(header string) []AcceptedValue {
 		parts := strings.Split(value, ";")
 		acceptedValue.Value = strings.TrimSpace(parts[0])
 
-		if len(parts) < 2 {
-			accepted = append(accepted, acceptedValue)
+		valid := true
 
-			continue
-		}
+		for _, part := range parts[1:] {
+			key, val, ok := strings.Cut(strings.TrimSpace(part), "=")
+			if !ok {
+				continue
+			}
 
-		if len(parts) != 2 {
-			// Malformed quality
-			continue
-		}
+			key = strings.ToLower(strings.TrimSpace(key))
+			val = strings.TrimSpace(val)
 
-		for _, part := range parts[1:] {
-			param := strings.TrimSpace(part)
+			if key != "q" {
+				if acceptedValue.Params == nil {
+					acceptedValue.Params = make(map[string]string)
+				}
 
-			qualPrefix := "q="
-			lenQualPrefix := len(qualPrefix)
+				acceptedValue.Params[key] = strings.Trim(val, `"`)
 
-			if !strings.HasPrefix(param, qualPrefix) || len(param) < (lenQualPrefix+1) {
 				continue
 			}
 
-			qual, err := strconv.ParseFloat(param[lenQualPrefix:], 32)
-			if err != nil {
-				// Fallback
-				continue
+			qual, err := strconv.ParseFloat(val, 64)
+			if err != nil || qual < 0 || qual > 1 {
+				// Malformed quality
+				valid = false
+
+				break
 			}
 
 			acceptedValue.Quality = qual
+
+			// Parameters following quality are accept extensions, which are ignored
+			break
 		}
 
-		accepted = append(accepted, acceptedValue)
+		if valid {
+			accepted = append(accepted, acceptedValue)
+		}
 	}
 
 	return accepted
@@ -0,0 +1,155 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package headutil_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/kemadev/go-framework/pkg/convenience/headkey"
+	"github.com/kemadev/go-framework/pkg/convenience/headutil"
+)
+
+func TestNegotiate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Name          string
+		Negotiate     func(h http.Header, offers []string) (string, bool)
+		HeaderKey     string
+		HeaderValue   string
+		Offers        []string
+		ExpectedValue string
+		ExpectedOK    bool
+	}{
+		{
+			Name:          "media type absent header",
+			Negotiate:     headutil.Negotiate,
+			HeaderKey:     headkey.Accept,
+			HeaderValue:   "",
+			Offers:        []string{"application/json", "text/html"},
+			ExpectedValue: "application/json",
+			ExpectedOK:    true,
+		},
+		{
+			Name:          "media type quality ordering",
+			Negotiate:     headutil.Negotiate,
+			HeaderKey:     headkey.Accept,
+			HeaderValue:   "application/json;q=0.5, text/html",
+			Offers:        []string{"application/json", "text/html"},
+			ExpectedValue: "text/html",
+			ExpectedOK:    true,
+		},
+		{
+			Name:          "media type partial wildcard",
+			Negotiate:     headutil.Negotiate,
+			HeaderKey:     headkey.Accept,
+			HeaderValue:   "text/*;q=0.8, */*;q=0.1",
+			Offers:        []string{"application/json", "text/plain"},
+			ExpectedValue: "text/plain",
+			ExpectedOK:    true,
+		},
+		{
+			Name:          "media type most specific range wins",
+			Negotiate:     headutil.Negotiate,
+			HeaderKey:     headkey.Accept,
+			HeaderValue:   "text/*, text/plain;q=0",
+			Offers:        []string{"text/plain", "text/css"},
+			ExpectedValue: "text/css",
+			ExpectedOK:    true,
+		},
+		{
+			Name:          "media type parameters",
+			Negotiate:     headutil.Negotiate,
+			HeaderKey:     headkey.Accept,
+			HeaderValue:   "text/html;level=1;q=0.2, text/html;q=0.7",
+			Offers:        []string{"text/html;level=1", "text/html"},
+			ExpectedValue: "text/html",
+			ExpectedOK:    true,
+		},
+		{
+			Name:          "media type not acceptable",
+			Negotiate:     headutil.Negotiate,
+			HeaderKey:     headkey.Accept,
+			HeaderValue:   "image/png",
+			Offers:        []string{"application/json"},
+			ExpectedValue: "",
+			ExpectedOK:    false,
+		},
+		{
+			Name:          "encoding absent header",
+			Negotiate:     headutil.NegotiateEncoding,
+			HeaderKey:     headkey.AcceptEncoding,
+			HeaderValue:   "",
+			Offers:        []string{"gzip", "identity"},
+			ExpectedValue: "identity",
+			ExpectedOK:    true,
+		},
+		{
+			Name:          "encoding server preference on ties",
+			Negotiate:     headutil.NegotiateEncoding,
+			HeaderKey:     headkey.AcceptEncoding,
+			HeaderValue:   "gzip, br",
+			Offers:        []string{"br", "gzip", "identity"},
+			ExpectedValue: "br",
+			ExpectedOK:    true,
+		},
+		{
+			Name:          "encoding identity refused",
+			Negotiate:     headutil.NegotiateEncoding,
+			HeaderKey:     headkey.AcceptEncoding,
+			HeaderValue:   "*;q=0",
+			Offers:        []string{"identity"},
+			ExpectedValue: "",
+			ExpectedOK:    false,
+		},
+		{
+			Name:          "language prefix match",
+			Negotiate:     headutil.NegotiateLanguage,
+			HeaderKey:     headkey.AcceptLanguage,
+			HeaderValue:   "fr;q=0.9, en;q=0.8",
+			Offers:        []string{"en-US", "fr-CA"},
+			ExpectedValue: "fr-CA",
+			ExpectedOK:    true,
+		},
+		{
+			Name:          "language fallback",
+			Negotiate:     headutil.NegotiateLanguage,
+			HeaderKey:     headkey.AcceptLanguage,
+			HeaderValue:   "de-CH",
+			Offers:        []string{"en", "de"},
+			ExpectedValue: "de",
+			ExpectedOK:    true,
+		},
+		{
+			Name:          "charset wildcard",
+			Negotiate:     headutil.NegotiateCharset,
+			HeaderKey:     headkey.AcceptCharset,
+			HeaderValue:   "iso-8859-1;q=0.5, *;q=0.1",
+			Offers:        []string{"utf-8", "iso-8859-1"},
+			ExpectedValue: "iso-8859-1",
+			ExpectedOK:    true,
+		},
+	}
+
+	for _, test := range tests {
+		h := http.Header{}
+		if test.HeaderValue != "" {
+			h.Set(test.HeaderKey, test.HeaderValue)
+		}
+
+		value, ok := test.Negotiate(h, test.Offers)
+
+		if value != test.ExpectedValue || ok != test.ExpectedOK {
+			t.Errorf(
+				"%s: expected (%q, %t) but was (%q, %t)",
+				test.Name,
+				test.ExpectedValue,
+				test.ExpectedOK,
+				value,
+				ok,
+			)
+		}
+	}
+}
@@ -0,0 +1,372 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package headutil
+
+import (
+	"errors"
+	"fmt"
+	"iter"
+	"net/http"
+	"net/netip"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/kemadev/go-framework/pkg/convenience/headkey"
+)
+
+var (
+	// ErrForwardedInvalid is returned when Forwarded header does not conform to RFC 7239.
+	ErrForwardedInvalid = errors.New("invalid Forwarded header")
+	// ErrForwardedNodeInvalid is returned when a Forwarded node does not conform to RFC 7239 section 6.
+	ErrForwardedNodeInvalid = errors.New("invalid Forwarded node")
+)
+
+// ForwardedElement is an element of Forwarded header, added by a proxy, see RFC 7239 section 4. Values are
+// unquoted.
+type ForwardedElement struct {
+	// Client-facing interface of proxy, see [ParseForwardedNode]
+	By string
+	// Client of proxy, see [ParseForwardedNode]
+	For string
+	// Host header received by proxy
+	Host string
+	// Protocol used by client to reach proxy, e.g. https
+	Proto string
+	// Extension parameters, by lowercase name
+	Extensions map[string]string
+}
+
+// ForwardedNode is a node identifier, see RFC 7239 section 6.
+type ForwardedNode struct {
+	// IP address, invalid if node is unknown or obfuscated
+	Addr netip.Addr
+	// Port, zero if not provided or obfuscated
+	Port uint16
+	// Obfuscated identifier, including leading underscore, see RFC 7239 section 6.3
+	Obfuscated string
+	// Obfuscated port, including leading underscore, see RFC 7239 section 6.3
+	ObfuscatedPort string
+	// Node is "unknown", see RFC 7239 section 6.2
+	Unknown bool
+}
+
+// AddrPort returns node address and port, if node is identified by its address.
+func (n ForwardedNode) AddrPort() (netip.AddrPort, bool) {
+	return netip.AddrPortFrom(n.Addr, n.Port), n.Addr.IsValid()
+}
+
+// ParseForwarded returns elements of Forwarded headers of [h], from the one added by first proxy to the one
+// added by last proxy, see RFC 7239 section 4.
+func ParseForwarded(h http.Header) ([]ForwardedElement, error) {
+	var elements []ForwardedElement
+
+	for _, value := range h.Values(headkey.Forwarded) {
+		parsed, err := parseForwardedValue(value)
+		if err != nil {
+			return nil, err
+		}
+
+		elements = append(elements, parsed...)
+	}
+
+	return elements, nil
+}
+
+// ForwardedBackward returns an iterator over elements of Forwarded headers of [h], from the one added by last
+// proxy to the one added by first proxy, see RFC 7239 section 4. Elements are parsed as they are iterated over,
+// so that callers stopping at first untrusted proxy never parse elements forged by clients. Iteration ends with
+// an error upon malformed elements.
+func ForwardedBackward(h http.Header) iter.Seq2[ForwardedElement, error] {
+	return func(yield func(ForwardedElement, error) bool) {
+		for _, value := range slices.Backward(h.Values(headkey.Forwarded)) {
+			for rest, found := value, true; found; {
+				var raw string
+
+				rest, raw, found = cutLastForwardedElement(rest)
+
+				// Raw element holds no comma outside of quoted strings, hence at most one element
+				elements, err := parseForwardedValue(raw)
+				if err != nil {
+					yield(ForwardedElement{}, err)
+
+					return
+				}
+
+				for _, element := range elements {
+					if !yield(element, nil) {
+						return
+					}
+				}
+			}
+		}
+	}
+}
+
+// cutLastForwardedElement slices Forwarded header field [value] around its last comma outside of quoted
+// strings, returning text before and after it, and whether such a comma was found. Quoted strings are tracked
+// from the end of [value], so that text before last element needs not be well-formed.
+func cutLastForwardedElement(value string) (string, string, bool) {
+	quoted := false
+
+	for i := len(value) - 1; i >= 0; i-- {
+		switch value[i] {
+		case '"':
+			// Within quoted strings, quotes preceded by an odd number of backslashes are escaped
+			backslashes := 0
+			for j := i - 1; j >= 0 && value[j] == '\\'; j-- {
+				backslashes++
+			}
+
+			if !quoted || backslashes%2 == 0 {
+				quoted = !quoted
+			}
+		case ',':
+			if !quoted {
+				return value[:i], value[i+1:], true
+			}
+		}
+	}
+
+	return "", value, false
+}
+
+// parseForwardedValue parses a Forwarded header field value.
+func parseForwardedValue(value string) ([]ForwardedElement, error) {
+	var (
+		elements []ForwardedElement
+		element  ForwardedElement
+		// Element holds at least one pair, empty elements being tolerated as in lists, see RFC 9110 section 5.6.1
+		hasPair bool
+	)
+
+	for i := 0; ; {
+		i = skipWhitespace(value, i)
+
+		if i < len(value) && value[i] != ',' && value[i] != ';' {
+			name, paramValue, next, err := parseForwardedPair(value, i)
+			if err != nil {
+				return nil, err
+			}
+
+			err = element.set(name, paramValue)
+			if err != nil {
+				return nil, err
+			}
+
+			hasPair = true
+			i = skipWhitespace(value, next)
+		}
+
+		if i >= len(value) || value[i] == ',' {
+			if hasPair {
+				elements = append(elements, element)
+			}
+
+			if i >= len(value) {
+				return elements, nil
+			}
+
+			element = ForwardedElement{}
+			hasPair = false
+		} else if value[i] != ';' {
+			return nil, fmt.Errorf("%w: unexpected %q at %d", ErrForwardedInvalid, value[i], i)
+		}
+
+		i++
+	}
+}
+
+// parseForwardedPair parses a name=value pair starting at [i] in [value], returning lowercase name, unquoted
+// value and index following pair.
+func parseForwardedPair(value string, i int) (string, string, int, error) {
+	start := i
+	for i < len(value) && isTokenChar(value[i]) {
+		i++
+	}
+
+	if i == start || i >= len(value) || value[i] != '=' {
+		return "", "", 0, fmt.Errorf("%w: expected parameter at %d", ErrForwardedInvalid, start)
+	}
+
+	name := strings.ToLower(value[start:i])
+	i++
+
+	if i < len(value) && value[i] == '"' {
+		var b strings.Builder
+
+		for i++; i < len(value); i++ {
+			switch value[i] {
+			case '"':
+				return name, b.String(), i + 1, nil
+			case '\\':
+				i++
+				if i >= len(value) {
+					return "", "", 0, fmt.Errorf("%w: unterminated quoted string", ErrForwardedInvalid)
+				}
+			}
+
+			b.WriteByte(value[i])
+		}
+
+		return "", "", 0, fmt.Errorf("%w: unterminated quoted string", ErrForwardedInvalid)
+	}
+
+	start = i
+	for i < len(value) && isTokenChar(value[i]) {
+		i++
+	}
+
+	if i == start {
+		return "", "", 0, fmt.Errorf("%w: expected value of %s parameter", ErrForwardedInvalid, name)
+	}
+
+	return name, value[start:i], i, nil
+}
+
+// set sets parameter [name] of element to [value].
+func (e *ForwardedElement) set(name string, value string) error {
+	var target *string
+
+	switch name {
+	case "by":
+		target = &e.By
+	case "for":
+		target = &e.For
+	case "host":
+		target = &e.Host
+	case "proto":
+		target = &e.Proto
+	default:
+		if e.Extensions == nil {
+			e.Extensions = make(map[string]string)
+		}
+
+		if _, exists := e.Extensions[name]; exists {
+			return fmt.Errorf("%w: duplicate %s parameter", ErrForwardedInvalid, name)
+		}
+
+		e.Extensions[name] = value
+
+		return nil
+	}
+
+	// Each parameter must not occur more than once per element, see RFC 7239 section 4
+	if *target != "" {
+		return fmt.Errorf("%w: duplicate %s parameter", ErrForwardedInvalid, name)
+	}
+
+	*target = value
+
+	return nil
+}
+
+// ParseForwardedNode parses a node identifier, as found in for and by parameters, see RFC 7239 section 6. IPv6
+// addresses are enclosed in brackets, and nodes may be "unknown" or obfuscated, as in "_hidden:_port".
+func ParseForwardedNode(node string) (ForwardedNode, error) {
+	var (
+		parsed ForwardedNode
+		name   = node
+		port   string
+	)
+
+	if strings.HasPrefix(node, "[") {
+		end := strings.IndexByte(node, ']')
+		if end < 0 {
+			return ForwardedNode{}, fmt.Errorf("%w: %q", ErrForwardedNodeInvalid, node)
+		}
+
+		name = node[:end+1]
+
+		rest := node[end+1:]
+		if rest != "" {
+			var found bool
+
+			port, found = strings.CutPrefix(rest, ":")
+			if !found {
+				return ForwardedNode{}, fmt.Errorf("%w: %q", ErrForwardedNodeInvalid, node)
+			}
+		}
+	} else if before, after, found := strings.Cut(node, ":"); found {
+		name, port = before, after
+	}
+
+	switch {
+	case name == "unknown":
+		parsed.Unknown = true
+	case strings.HasPrefix(name, "_"):
+		if !isObfuscated(name) {
+			return ForwardedNode{}, fmt.Errorf("%w: %q", ErrForwardedNodeInvalid, node)
+		}
+
+		parsed.Obfuscated = name
+	case strings.HasPrefix(name, "["):
+		addr, err := netip.ParseAddr(strings.Trim(name, "[]"))
+		if err != nil || !addr.Is6() || addr.Zone() != "" {
+			return ForwardedNode{}, fmt.Errorf("%w: %q", ErrForwardedNodeInvalid, node)
+		}
+
+		parsed.Addr = addr
+	default:
+		addr, err := netip.ParseAddr(name)
+		if err != nil || !addr.Is4() {
+			return ForwardedNode{}, fmt.Errorf("%w: %q", ErrForwardedNodeInvalid, node)
+		}
+
+		parsed.Addr = addr
+	}
+
+	switch {
+	case port == "" && !strings.HasSuffix(node, ":"):
+	case strings.HasPrefix(port, "_"):
+		if !isObfuscated(port) {
+			return ForwardedNode{}, fmt.Errorf("%w: %q", ErrForwardedNodeInvalid, node)
+		}
+
+		parsed.ObfuscatedPort = port
+	default:
+		// Port is 1 to 5 digits, see RFC 7239 section 6.1
+		number, err := strconv.ParseUint(port, 10, 16)
+		if err != nil || len(port) > 5 || strings.ContainsAny(port, "+-") {
+			return ForwardedNode{}, fmt.Errorf("%w: %q", ErrForwardedNodeInvalid, node)
+		}
+
+		parsed.Port = uint16(number)
+	}
+
+	return parsed, nil
+}
+
+// isObfuscated returns whether [s] is an obfuscated identifier, see RFC 7239 section 6.3.
+func isObfuscated(s string) bool {
+	if len(s) < 2 || s[0] != '_' {
+		return false
+	}
+
+	for _, c := range []byte(s[1:]) {
+		if !isAlphaNum(c) && c != '.' && c != '_' && c != '-' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// isTokenChar returns whether [c] is a token character, see RFC 9110 section 5.6.2.
+func isTokenChar(c byte) bool {
+	return isAlphaNum(c) || strings.IndexByte("!#$%&'*+-.^_`|~", c) >= 0
+}
+
+func isAlphaNum(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// skipWhitespace returns index of first non-whitespace character of [s] from [i].
+func skipWhitespace(s string, i int) int {
+	for i < len(s) && (s[i] == ' ' || s[i] == '\t') {
+		i++
+	}
+
+	return i
+}
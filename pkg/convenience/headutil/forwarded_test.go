@@ -0,0 +1,211 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package headutil_test
+
+import (
+	"errors"
+	"net/http"
+	"net/netip"
+	"reflect"
+	"testing"
+
+	"github.com/kemadev/go-framework/pkg/convenience/headkey"
+	"github.com/kemadev/go-framework/pkg/convenience/headutil"
+)
+
+func TestParseForwarded(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Name             string
+		HeaderValues     []string
+		ExpectedElements []headutil.ForwardedElement
+		ExpectedError    error
+	}{
+		{
+			Name:         "single element",
+			HeaderValues: []string{`for=192.0.2.60;proto=http;by=203.0.113.43`},
+			ExpectedElements: []headutil.ForwardedElement{
+				{For: "192.0.2.60", Proto: "http", By: "203.0.113.43"},
+			},
+		},
+		{
+			Name:         "case-insensitive names and quoted values",
+			HeaderValues: []string{`For="[2001:db8:cafe::17]:4711";HOST="example.com"`},
+			ExpectedElements: []headutil.ForwardedElement{
+				{For: "[2001:db8:cafe::17]:4711", Host: "example.com"},
+			},
+		},
+		{
+			Name:         "multiple elements and headers",
+			HeaderValues: []string{`for=192.0.2.43, for="_gazonk"`, `for=unknown;ext="a\"b,c"`},
+			ExpectedElements: []headutil.ForwardedElement{
+				{For: "192.0.2.43"},
+				{For: "_gazonk"},
+				{For: "unknown", Extensions: map[string]string{"ext": `a"b,c`}},
+			},
+		},
+		{
+			Name:          "duplicate parameter",
+			HeaderValues:  []string{`for=192.0.2.43;for=192.0.2.44`},
+			ExpectedError: headutil.ErrForwardedInvalid,
+		},
+		{
+			Name:          "unterminated quoted string",
+			HeaderValues:  []string{`for="[2001:db8::1]`},
+			ExpectedError: headutil.ErrForwardedInvalid,
+		},
+		{
+			Name:          "missing value",
+			HeaderValues:  []string{`for=`},
+			ExpectedError: headutil.ErrForwardedInvalid,
+		},
+	}
+
+	for _, test := range tests {
+		h := http.Header{}
+		for _, value := range test.HeaderValues {
+			h.Add(headkey.Forwarded, value)
+		}
+
+		elements, err := headutil.ParseForwarded(h)
+		if !errors.Is(err, test.ExpectedError) {
+			t.Errorf("%s: expected error %v; got %v", test.Name, test.ExpectedError, err)
+		}
+
+		if !reflect.DeepEqual(elements, test.ExpectedElements) {
+			t.Errorf("%s: expected %+v; got %+v", test.Name, test.ExpectedElements, elements)
+		}
+	}
+}
+
+func TestForwardedBackward(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Name             string
+		HeaderValues     []string
+		Limit            int
+		ExpectedElements []headutil.ForwardedElement
+		ExpectedError    error
+	}{
+		{
+			Name:         "multiple elements and headers",
+			HeaderValues: []string{`for=192.0.2.43, , for="_gazonk"`, `for=unknown;ext="a\"b,c\\"`},
+			ExpectedElements: []headutil.ForwardedElement{
+				{For: "unknown", Extensions: map[string]string{"ext": `a"b,c\`}},
+				{For: "_gazonk"},
+				{For: "192.0.2.43"},
+			},
+		},
+		{
+			Name:          "malformed last element",
+			HeaderValues:  []string{`for=192.0.2.43, for="[2001:db8::1]`},
+			ExpectedError: headutil.ErrForwardedInvalid,
+		},
+		{
+			Name:         "malformed first element",
+			HeaderValues: []string{`for="bad, for=192.0.2.43`},
+			ExpectedElements: []headutil.ForwardedElement{
+				{For: "192.0.2.43"},
+			},
+			ExpectedError: headutil.ErrForwardedInvalid,
+		},
+		{
+			Name:         "malformed first header not parsed once stopped",
+			HeaderValues: []string{`for="bad`, `for=192.0.2.43`},
+			Limit:        1,
+			ExpectedElements: []headutil.ForwardedElement{
+				{For: "192.0.2.43"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		h := http.Header{}
+		for _, value := range test.HeaderValues {
+			h.Add(headkey.Forwarded, value)
+		}
+
+		var (
+			elements []headutil.ForwardedElement
+			err      error
+		)
+
+		for element, e := range headutil.ForwardedBackward(h) {
+			if e != nil {
+				err = e
+
+				break
+			}
+
+			elements = append(elements, element)
+			if len(elements) == test.Limit {
+				break
+			}
+		}
+
+		if !errors.Is(err, test.ExpectedError) {
+			t.Errorf("%s: expected error %v; got %v", test.Name, test.ExpectedError, err)
+		}
+
+		if !reflect.DeepEqual(elements, test.ExpectedElements) {
+			t.Errorf("%s: expected %+v; got %+v", test.Name, test.ExpectedElements, elements)
+		}
+	}
+}
+
+func TestParseForwardedNode(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Node          string
+		ExpectedNode  headutil.ForwardedNode
+		ExpectedError bool
+	}{
+		{
+			Node:         "192.0.2.43",
+			ExpectedNode: headutil.ForwardedNode{Addr: netip.MustParseAddr("192.0.2.43")},
+		},
+		{
+			Node:         "[2001:db8:cafe::17]:4711",
+			ExpectedNode: headutil.ForwardedNode{Addr: netip.MustParseAddr("2001:db8:cafe::17"), Port: 4711},
+		},
+		{
+			Node:         "unknown",
+			ExpectedNode: headutil.ForwardedNode{Unknown: true},
+		},
+		{
+			Node:         "_hidden:_port",
+			ExpectedNode: headutil.ForwardedNode{Obfuscated: "_hidden", ObfuscatedPort: "_port"},
+		},
+		{
+			Node:          "2001:db8::1",
+			ExpectedError: true,
+		},
+		{
+			Node:          "192.0.2.43:",
+			ExpectedError: true,
+		},
+		{
+			Node:          "192.0.2.43:123456",
+			ExpectedError: true,
+		},
+		{
+			Node:          "_bad/char",
+			ExpectedError: true,
+		},
+	}
+
+	for _, test := range tests {
+		node, err := headutil.ParseForwardedNode(test.Node)
+		if (err != nil) != test.ExpectedError {
+			t.Errorf("%s: unexpected error %v", test.Node, err)
+		}
+
+		if node != test.ExpectedNode {
+			t.Errorf("%s: expected %+v; got %+v", test.Node, test.ExpectedNode, node)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package headutil
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+// ErrGRPCTimeoutInvalid is returned when a timeout does not conform to gRPC over HTTP/2 Timeout format.
+var ErrGRPCTimeoutInvalid = errors.New("invalid gRPC timeout")
+
+// maxGRPCTimeoutDigits is the maximum number of digits of gRPC timeout values.
+const maxGRPCTimeoutDigits = 8
+
+// grpcTimeoutUnits are gRPC timeout units, from finest to coarsest.
+var grpcTimeoutUnits = []struct {
+	unit     byte
+	duration time.Duration
+}{
+	{'n', time.Nanosecond},
+	{'u', time.Microsecond},
+	{'m', time.Millisecond},
+	{'S', time.Second},
+	{'M', time.Minute},
+	{'H', time.Hour},
+}
+
+// ParseGRPCTimeout returns the duration of [value], formatted as Grpc-Timeout header, e.g. "100m" for 100
+// milliseconds, see https://github.com/grpc/grpc/blob/master/doc/PROTOCOL-HTTP2.md#requests. Values
+// exceeding [time.Duration] range, e.g. "99999999H", are rejected.
+func ParseGRPCTimeout(value string) (time.Duration, error) {
+	if len(value) < 2 || len(value) > maxGRPCTimeoutDigits+1 {
+		return 0, fmt.Errorf("%w: %q", ErrGRPCTimeoutInvalid, value)
+	}
+
+	digits, unit := value[:len(value)-1], value[len(value)-1]
+
+	for _, c := range []byte(digits) {
+		if c < '0' || c > '9' {
+			return 0, fmt.Errorf("%w: %q", ErrGRPCTimeoutInvalid, value)
+		}
+	}
+
+	n, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q", ErrGRPCTimeoutInvalid, value)
+	}
+
+	for _, u := range grpcTimeoutUnits {
+		if u.unit != unit {
+			continue
+		}
+
+		if n > math.MaxInt64/int64(u.duration) {
+			return 0, fmt.Errorf("%w: %q overflows duration", ErrGRPCTimeoutInvalid, value)
+		}
+
+		return time.Duration(n) * u.duration, nil
+	}
+
+	return 0, fmt.Errorf("%w: %q", ErrGRPCTimeoutInvalid, value)
+}
+
+// FormatGRPCTimeout returns [d] formatted as Grpc-Timeout header, using the finest unit fitting in 8 digits,
+// e.g. to propagate remaining time of a deadline to outbound calls. Non-positive durations are formatted as
+// zero.
+func FormatGRPCTimeout(d time.Duration) string {
+	d = max(d, 0)
+
+	for _, u := range grpcTimeoutUnits {
+		// Truncate so that callees never get more time than callers
+		n := d / u.duration
+
+		// Any duration fits in 8 digits of hours, the coarsest unit
+		if n < 1e8 || u.unit == 'H' {
+			return strconv.FormatInt(int64(n), 10) + string(u.unit)
+		}
+	}
+
+	return "0n"
+}
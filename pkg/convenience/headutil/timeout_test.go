@@ -0,0 +1,81 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package headutil_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kemadev/go-framework/pkg/convenience/headutil"
+)
+
+func TestParseGRPCTimeout(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Value            string
+		ExpectedDuration time.Duration
+		ExpectedErr      error
+	}{
+		{Value: "100m", ExpectedDuration: 100 * time.Millisecond},
+		{Value: "5S", ExpectedDuration: 5 * time.Second},
+		{Value: "1H", ExpectedDuration: time.Hour},
+		{Value: "99999999n", ExpectedDuration: 99999999 * time.Nanosecond},
+		{Value: "0u"},
+		{Value: "2562047H", ExpectedDuration: 2562047 * time.Hour},
+		{Value: "2562048H", ExpectedErr: headutil.ErrGRPCTimeoutInvalid},
+		{Value: "99999999H", ExpectedErr: headutil.ErrGRPCTimeoutInvalid},
+		{Value: "99999999M", ExpectedDuration: 99999999 * time.Minute},
+		{Value: "100000000n", ExpectedErr: headutil.ErrGRPCTimeoutInvalid},
+		{Value: "-1S", ExpectedErr: headutil.ErrGRPCTimeoutInvalid},
+		{Value: "10s", ExpectedErr: headutil.ErrGRPCTimeoutInvalid},
+		{Value: "S", ExpectedErr: headutil.ErrGRPCTimeoutInvalid},
+		{Value: "", ExpectedErr: headutil.ErrGRPCTimeoutInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Value, func(t *testing.T) {
+			t.Parallel()
+
+			d, err := headutil.ParseGRPCTimeout(tt.Value)
+			if !errors.Is(err, tt.ExpectedErr) {
+				t.Fatalf("expected error %v but was %v", tt.ExpectedErr, err)
+			}
+
+			if d != tt.ExpectedDuration {
+				t.Errorf("expected duration %s but was %s", tt.ExpectedDuration, d)
+			}
+		})
+	}
+}
+
+func TestFormatGRPCTimeout(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Duration      time.Duration
+		ExpectedValue string
+	}{
+		{Duration: 0, ExpectedValue: "0n"},
+		{Duration: -time.Second, ExpectedValue: "0n"},
+		{Duration: 100 * time.Millisecond, ExpectedValue: "100000u"},
+		{Duration: time.Minute, ExpectedValue: "60000000u"},
+		{Duration: time.Hour, ExpectedValue: "3600000m"},
+		{Duration: time.Hour + time.Nanosecond, ExpectedValue: "3600000m"},
+		{Duration: 100*time.Second + 1500*time.Microsecond, ExpectedValue: "100001m"},
+		{Duration: time.Duration(1<<63 - 1), ExpectedValue: "2562047H"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ExpectedValue, func(t *testing.T) {
+			t.Parallel()
+
+			value := headutil.FormatGRPCTimeout(tt.Duration)
+			if value != tt.ExpectedValue {
+				t.Errorf("expected value %q but was %q", tt.ExpectedValue, value)
+			}
+		})
+	}
+}
@@ -0,0 +1,26 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package headutil
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/kemadev/go-framework/pkg/convenience/headkey"
+)
+
+// AddVary adds [key] to Vary header unless already present, so that stacked handlers negotiating on the same
+// request header do not repeat it.
+func AddVary(h http.Header, key string) {
+	for _, value := range h.Values(headkey.Vary) {
+		for _, field := range strings.Split(value, ",") {
+			field = strings.TrimSpace(field)
+			if field == "*" || strings.EqualFold(field, key) {
+				return
+			}
+		}
+	}
+
+	h.Add(headkey.Vary, key)
+}
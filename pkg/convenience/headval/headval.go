@@ -17,13 +17,19 @@ const (
 )
 
 const (
+	MIMEApplicationCSPReport       = "application/csp-report"
 	MIMEApplicationForm            = "application/x-www-form-urlencoded"
 	MIMEApplicationJSON            = "application/json"
 	MIMEApplicationJSONCharsetUTF8 = "application/json; charset=utf-8"
+	MIMEApplicationJSONLines       = "application/jsonl"
+	MIMEApplicationNDJSON          = "application/x-ndjson"
+	MIMEApplicationProblemJSON     = "application/problem+json"
+	MIMEApplicationReportsJSON     = "application/reports+json"
 	MIMEMultipartForm              = "multipart/form-data"
 	MIMEOctetStream                = "application/octet-stream"
 	MIMETextCSS                    = "text/css"
 	MIMETextCSSCharsetUTF8         = "text/css; charset=utf-8"
+	MIMETextEventStream            = "text/event-stream"
 	MIMETextHTML                   = "text/html"
 	MIMETextHTMLCharsetUTF8        = "text/html; charset=utf-8"
 	MIMETextJavaScript             = "text/javascript"
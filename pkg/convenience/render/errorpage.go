@@ -0,0 +1,44 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package render
+
+import (
+	"html/template"
+	"net/http"
+
+	"github.com/kemadev/go-framework/pkg/convenience/headkey"
+	"github.com/kemadev/go-framework/pkg/convenience/headutil"
+	"github.com/kemadev/go-framework/pkg/convenience/headval"
+	"github.com/kemadev/go-framework/pkg/convenience/log"
+)
+
+// errorPage displays template errors in browser, it must only be used in local environment as it leaks
+// implementation details.
+var errorPage = template.Must(template.New("error").Parse(`<!DOCTYPE html>
+<html lang="en">
+
+<head>
+	<meta charset="utf-8">
+	<title>Template error</title>
+</head>
+
+<body>
+	<h1>Template error</h1>
+	<pre>{{ . }}</pre>
+</body>
+
+</html>
+`))
+
+// writeErrorPage sends an error page describing [err].
+func writeErrorPage(w http.ResponseWriter, err error) {
+	w.Header().Set(headkey.ContentType, headval.MIMETextHTMLCharsetUTF8)
+	headutil.SetCachePolicy(w, headutil.CacheHeader{NoStore: true})
+	w.WriteHeader(http.StatusInternalServerError)
+
+	execErr := errorPage.Execute(w, err.Error())
+	if execErr != nil {
+		log.ErrLog(packageName, "error writing template error page", execErr)
+	}
+}
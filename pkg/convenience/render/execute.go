@@ -0,0 +1,169 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"hash/fnv"
+	"html/template"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/kemadev/go-framework/pkg/convenience/headkey"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// execute renders [t] to [w], buffering output up to configured size. Request [r] may be nil.
+func (tr *TemplateRenderer) execute(
+	w http.ResponseWriter,
+	r *http.Request,
+	t *template.Template,
+	templateName string,
+	data any,
+	contentType string,
+) error {
+	if r != nil {
+		_, span := otel.Tracer(packageName).Start(
+			r.Context(),
+			"render.template",
+			trace.WithAttributes(attribute.String("template.name", templateName)),
+		)
+		defer span.End()
+
+		err := tr.executeBuffered(w, r, t, templateName, data, contentType)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "error rendering template")
+		}
+
+		return err
+	}
+
+	return tr.executeBuffered(w, r, t, templateName, data, contentType)
+}
+
+func (tr *TemplateRenderer) executeBuffered(
+	w http.ResponseWriter,
+	r *http.Request,
+	t *template.Template,
+	templateName string,
+	data any,
+	contentType string,
+) error {
+	buf, ok := tr.buffers.Get().(*bytes.Buffer)
+	if !ok {
+		buf = &bytes.Buffer{}
+	}
+
+	buf.Reset()
+	defer tr.releaseBuffer(buf)
+
+	bw := &bufferedWriter{
+		w:   w,
+		buf: buf,
+		max: tr.conf.MaxBufferSize,
+	}
+
+	w.Header().Set(headkey.ContentType, contentType)
+
+	err := t.Execute(bw, data)
+	if err != nil {
+		err = fmt.Errorf("error executing template %s: %w", templateName, err)
+
+		if bw.streaming {
+			return fmt.Errorf("%w: %w", ErrPartialResponse, err)
+		}
+
+		if tr.conf.Reload {
+			writeErrorPage(w, err)
+
+			return fmt.Errorf("%w: %w", ErrErrorPageSent, err)
+		}
+
+		return err
+	}
+
+	if bw.streaming {
+		return nil
+	}
+
+	hash := fnv.New64a()
+	_, _ = hash.Write(buf.Bytes())
+	etag := `"` + strconv.FormatUint(hash.Sum64(), 16) + `"`
+
+	w.Header().Set(headkey.ETag, etag)
+
+	if r != nil && etagMatch(r.Header.Get(headkey.IfNoneMatch), etag) {
+		w.WriteHeader(http.StatusNotModified)
+
+		return nil
+	}
+
+	w.Header().Set(headkey.ContentLength, strconv.Itoa(buf.Len()))
+
+	_, err = buf.WriteTo(w)
+	if err != nil {
+		return fmt.Errorf("error writing template %s output: %w", templateName, err)
+	}
+
+	return nil
+}
+
+// releaseBuffer returns [buf] to the pool, unless it grew too large to be worth keeping.
+func (tr *TemplateRenderer) releaseBuffer(buf *bytes.Buffer) {
+	if buf.Cap() > 2*tr.conf.MaxBufferSize {
+		return
+	}
+
+	tr.buffers.Put(buf)
+}
+
+// etagMatch reports whether [etag] matches If-None-Match header value, using weak comparison as per RFC 9110.
+func etagMatch(ifNoneMatch string, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// bufferedWriter buffers writes up to max bytes, then switches to streaming to underlying writer.
+type bufferedWriter struct {
+	w         http.ResponseWriter
+	buf       *bytes.Buffer
+	max       int
+	streaming bool
+}
+
+// Write implements [io.Writer].
+func (b *bufferedWriter) Write(p []byte) (int, error) {
+	if b.streaming {
+		return b.w.Write(p)
+	}
+
+	if b.buf.Len()+len(p) <= b.max {
+		return b.buf.Write(p)
+	}
+
+	b.streaming = true
+
+	_, err := b.buf.WriteTo(b.w)
+	if err != nil {
+		return 0, err
+	}
+
+	return b.w.Write(p)
+}
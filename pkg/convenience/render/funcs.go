@@ -0,0 +1,16 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package render
+
+import "time"
+
+// formatDate formats [t] using [layout], see [time.Time.Format]. Arguments order allows pipelines.
+func formatDate(layout string, t time.Time) string {
+	return t.Format(layout)
+}
+
+// unboundRequestFunc stands for request functions when templates are not executed for a request.
+func unboundRequestFunc(...any) (string, error) {
+	return "", ErrRequestFuncUnbound
+}
@@ -0,0 +1,61 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package render
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/kemadev/go-framework/pkg/convenience/headutil"
+)
+
+// languages returns translations languages, by order of server preference.
+func languages(conf Config) []string {
+	langs := []string{conf.DefaultLanguage}
+
+	others := make([]string, 0, len(conf.Translations))
+
+	for lang := range conf.Translations {
+		if lang != conf.DefaultLanguage {
+			others = append(others, lang)
+		}
+	}
+
+	slices.Sort(others)
+
+	return append(langs, others...)
+}
+
+// negotiateLanguage returns the language of translations that best matches [r] Accept-Language header.
+func (tr *TemplateRenderer) negotiateLanguage(r *http.Request) string {
+	lang, ok := headutil.NegotiateLanguage(r.Header, tr.languages)
+	if !ok {
+		return tr.conf.DefaultLanguage
+	}
+
+	return lang
+}
+
+// translator returns a function translating message keys to [lang], falling back to default language, then to
+// message key itself.
+func (tr *TemplateRenderer) translator(lang string) func(key string, args ...any) string {
+	return func(key string, args ...any) string {
+		msg, ok := tr.conf.Translations[lang][key]
+		if !ok {
+			msg, ok = tr.conf.Translations[tr.conf.DefaultLanguage][key]
+		}
+
+		// Key is not a format string
+		if !ok {
+			return key
+		}
+
+		if len(args) > 0 {
+			return fmt.Sprintf(msg, args...)
+		}
+
+		return msg
+	}
+}
@@ -4,43 +4,147 @@
 package render
 
 import (
-	"embed"
+	"bytes"
 	"errors"
 	"fmt"
 	"html/template"
 	"io/fs"
 	"net/http"
-	"path/filepath"
+	"path"
 	"strings"
+	"sync"
 
-	"github.com/kemadev/go-framework/pkg/convenience/headkey"
+	"github.com/kemadev/go-framework/pkg/convenience/sechead"
 )
 
 const packageName = "github.com/kemadev/go-framework/pkg/convenience/render"
 
-var ErrTemplateNotFound = errors.New("template not found")
+const (
+	// DefaultLayoutsDir is the default directory of layout templates, relative to templates base directory.
+	DefaultLayoutsDir = "layouts"
+	// DefaultPartialsDir is the default directory of partial templates, relative to templates base directory.
+	DefaultPartialsDir = "partials"
+	// DefaultLanguage is the default language of translations.
+	DefaultLanguage = "en"
+	// DefaultMaxBufferSize is the default output size up to which templates are rendered in memory.
+	DefaultMaxBufferSize = 1 << 20
+)
+
+var (
+	ErrTemplateNotFound   = errors.New("template not found")
+	ErrRequestFuncUnbound = errors.New("request function called without request")
+	ErrErrorPageSent      = errors.New("error page sent")
+	ErrPartialResponse    = errors.New("partial response sent")
+)
 
 // TemplateRenderer handles template parsing and rendering.
 type TemplateRenderer struct {
-	templates map[string]*template.Template
+	tmpl        fs.FS
+	baseDirName string
+	pages       map[string]*page
+	conf        Config
+	languages   []string
+	buffers     sync.Pool
 }
 
-// New creates a new template renderer with all templates parsed.
-func New(tmpl embed.FS, baseDirName string) (*TemplateRenderer, error) {
+// page holds a page template along with shared layouts and partials.
+type page struct {
+	// master is never executed, so that it can be cloned to bind request functions
+	master *template.Template
+	// plain is executed when no request is available
+	plain *template.Template
+}
+
+// Config defines the configuration for template renderer.
+type Config struct {
+	// Functions available to all templates, e.g. [github.com/kemadev/go-framework/pkg/static.Handler.FuncMap]
+	Funcs template.FuncMap
+	// Functions bound to the request being rendered, available when using [TemplateRenderer.ExecuteRequest].
+	// Each entry returns the template function for given request.
+	RequestFuncs map[string]func(r *http.Request) any
+	// Directory of layout templates, relative to templates base directory. Defaults to [DefaultLayoutsDir].
+	LayoutsDir string
+	// Directory of partial templates, relative to templates base directory. Defaults to [DefaultPartialsDir].
+	PartialsDir string
+	// Message catalogs by language tag, used by "t" function
+	Translations map[string]map[string]string
+	// Language used when client accepts none of translations languages. Defaults to [DefaultLanguage].
+	DefaultLanguage string
+	// Re-parse templates upon each execution, so that changes apply without recompiling, e.g. using
+	// [os.DirFS] in local environment. Parse and execution errors are then sent to the client as an error page.
+	Reload bool
+	// Output size up to which templates are rendered in memory before being sent, larger outputs being
+	// streamed. Defaults to [DefaultMaxBufferSize].
+	MaxBufferSize int
+}
+
+// New creates a new template renderer with all templates in [baseDirName] directory of [tmpl] parsed.
+func New(tmpl fs.FS, baseDirName string) (*TemplateRenderer, error) {
+	return NewWithConfig(tmpl, baseDirName, Config{})
+}
+
+// NewWithConfig creates a new template renderer with all templates parsed, using custom configuration.
+//
+// Templates in layouts and partials directories are shared by all pages, and can be referenced by their path
+// relative to templates base directory, as well as by the names they define. Other templates are pages, which
+// can execute a layout and override the blocks it defines:
+//
+//	{{ template "layouts/base.gotmpl.html" . }}
+//	{{ define "content" }}<h1>Hello!</h1>{{ end }}
+//
+// Besides configured functions, templates can use built-in functions:
+//   - date: formats a [time.Time] with a layout, as in {{ .CreatedAt | date "2006-01-02" }}
+//   - t: translates a message key to the language negotiated from Accept-Language header, formatting
+//     optional arguments as [fmt.Sprintf], as in {{ t "greeting" .Name }}
+//   - lang: returns the language negotiated from Accept-Language header
+//   - nonce: returns the Content-Security-Policy nonce, see [sechead.Nonce], as in
+//     <script nonce="{{ nonce }}">
+//
+// Functions t, lang and nonce, as well as configured request functions, require
+// [TemplateRenderer.ExecuteRequest].
+func NewWithConfig(tmpl fs.FS, baseDirName string, conf Config) (*TemplateRenderer, error) {
+	if conf.LayoutsDir == "" {
+		conf.LayoutsDir = DefaultLayoutsDir
+	}
+
+	if conf.PartialsDir == "" {
+		conf.PartialsDir = DefaultPartialsDir
+	}
+
+	if conf.DefaultLanguage == "" {
+		conf.DefaultLanguage = DefaultLanguage
+	}
+
+	if conf.MaxBufferSize == 0 {
+		conf.MaxBufferSize = DefaultMaxBufferSize
+	}
+
 	tr := &TemplateRenderer{
-		templates: make(map[string]*template.Template),
+		tmpl:        tmpl,
+		baseDirName: baseDirName,
+		conf:        conf,
+		languages:   languages(conf),
+		buffers: sync.Pool{
+			New: func() any {
+				return &bytes.Buffer{}
+			},
+		},
 	}
 
-	err := tr.loadTemplates(tmpl, baseDirName)
+	pages, err := tr.loadTemplates()
 	if err != nil {
 		return nil, fmt.Errorf("failed to load templates: %w", err)
 	}
 
+	tr.pages = pages
+
 	return tr, nil
 }
 
-func (tr *TemplateRenderer) loadTemplates(tmpl embed.FS, baseDirName string) error {
-	return fs.WalkDir(tmpl, ".", func(path string, d fs.DirEntry, err error) error {
+func (tr *TemplateRenderer) loadTemplates() (map[string]*page, error) {
+	var shared, pages []string
+
+	err := fs.WalkDir(tr.tmpl, tr.baseDirName, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
@@ -49,43 +153,181 @@ func (tr *TemplateRenderer) loadTemplates(tmpl embed.FS, baseDirName string) err
 			return nil
 		}
 
-		content, err := tmpl.ReadFile(path)
+		name := strings.TrimPrefix(path, tr.baseDirName+"/")
+		if tr.isShared(name) {
+			shared = append(shared, path)
+		} else {
+			pages = append(pages, path)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	base := template.New("").Funcs(tr.parseFuncs())
+
+	for _, path := range shared {
+		_, err := parseFile(base, tr.tmpl, path, tr.baseDirName)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	parsed := make(map[string]*page, len(pages))
+
+	for _, path := range pages {
+		set, err := base.Clone()
 		if err != nil {
-			return fmt.Errorf("failed to read template %s: %w", path, err)
+			return nil, fmt.Errorf("failed to clone shared templates for %s: %w", path, err)
 		}
 
-		t := template.New(filepath.Base(path))
+		master, err := parseFile(set, tr.tmpl, path, tr.baseDirName)
+		if err != nil {
+			return nil, err
+		}
 
-		_, err = t.Parse(string(content))
+		plain, err := master.Clone()
 		if err != nil {
-			return fmt.Errorf("failed to parse template %s: %w", path, err)
+			return nil, fmt.Errorf("failed to clone template %s: %w", path, err)
 		}
 
-		tr.templates[strings.TrimPrefix(path, baseDirName+"/")] = t
+		parsed[master.Name()] = &page{
+			master: master,
+			plain:  plain,
+		}
+	}
 
-		return nil
-	})
+	return parsed, nil
+}
+
+// parseFile parses template at [path] in [set], naming it after its path relative to [baseDirName].
+func parseFile(set *template.Template, tmpl fs.FS, path string, baseDirName string) (*template.Template, error) {
+	content, err := fs.ReadFile(tmpl, path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read template %s: %w", path, err)
+	}
+
+	t, err := set.New(strings.TrimPrefix(path, baseDirName+"/")).Parse(string(content))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template %s: %w", path, err)
+	}
+
+	return t, nil
+}
+
+// isShared reports whether template [name] is a layout or a partial.
+func (tr *TemplateRenderer) isShared(name string) bool {
+	dir, _, _ := strings.Cut(path.Clean(name), "/")
+
+	return dir == tr.conf.LayoutsDir || dir == tr.conf.PartialsDir
+}
+
+// parseFuncs returns all functions templates can use. Request functions are placeholders, bound upon
+// execution.
+func (tr *TemplateRenderer) parseFuncs() template.FuncMap {
+	funcs := template.FuncMap{
+		"date":  formatDate,
+		"t":     unboundRequestFunc,
+		"lang":  unboundRequestFunc,
+		"nonce": unboundRequestFunc,
+	}
+
+	for name := range tr.conf.RequestFuncs {
+		funcs[name] = unboundRequestFunc
+	}
+
+	for name, fn := range tr.conf.Funcs {
+		funcs[name] = fn
+	}
+
+	return funcs
+}
+
+// requestFuncs returns request functions bound to [r].
+func (tr *TemplateRenderer) requestFuncs(r *http.Request) template.FuncMap {
+	lang := tr.negotiateLanguage(r)
+
+	funcs := template.FuncMap{
+		"t": tr.translator(lang),
+		"lang": func() string {
+			return lang
+		},
+		"nonce": func() string {
+			return sechead.Nonce(r.Context())
+		},
+	}
+
+	for name, fn := range tr.conf.RequestFuncs {
+		funcs[name] = fn(r)
+	}
+
+	return funcs
+}
+
+// lookup returns page [templateName], re-parsing templates first in reload mode.
+func (tr *TemplateRenderer) lookup(w http.ResponseWriter, templateName string) (*page, error) {
+	pages := tr.pages
+
+	if tr.conf.Reload {
+		var err error
+
+		pages, err = tr.loadTemplates()
+		if err != nil {
+			writeErrorPage(w, err)
+
+			return nil, fmt.Errorf("%w: failed to reload templates: %w", ErrErrorPageSent, err)
+		}
+	}
+
+	p, exists := pages[strings.TrimPrefix(templateName, "/")]
+	if !exists {
+		return nil, fmt.Errorf("%s: %w", templateName, ErrTemplateNotFound)
+	}
+
+	return p, nil
 }
 
-// Execute executes a template with the given data.
+// Execute executes a template with the given data, see [TemplateRenderer.ExecuteRequest]. As no request is
+// available, rendering is not traced, and conditional requests are not handled.
 func (tr *TemplateRenderer) Execute(
 	w http.ResponseWriter,
 	templateName string,
 	data any,
 	contentType string,
 ) error {
-	t, exists := tr.templates[strings.TrimPrefix(templateName, "/")]
-
-	if !exists {
-		return fmt.Errorf("%s: %w", templateName, ErrTemplateNotFound)
+	p, err := tr.lookup(w, templateName)
+	if err != nil {
+		return err
 	}
 
-	w.Header().Set(headkey.ContentType, contentType)
+	return tr.execute(w, nil, p.plain, templateName, data, contentType)
+}
+
+// ExecuteRequest executes a template with the given data, binding request functions to [r]. As template is
+// cloned for each call, prefer [TemplateRenderer.Execute] for templates not using request functions.
+//
+// Output is rendered in memory up to configured maximum buffer size, so that no partial response is sent
+// upon execution error, and callers can send a proper error response. Buffered responses are sent along with
+// Content-Length and ETag headers, and a 304 status when matching request If-None-Match header. Larger
+// outputs are streamed, and execution errors then wrap [ErrPartialResponse].
+func (tr *TemplateRenderer) ExecuteRequest(
+	w http.ResponseWriter,
+	r *http.Request,
+	templateName string,
+	data any,
+	contentType string,
+) error {
+	p, err := tr.lookup(w, templateName)
+	if err != nil {
+		return err
+	}
 
-	err := t.Execute(w, data)
+	t, err := p.master.Clone()
 	if err != nil {
-		return fmt.Errorf("error executing template %s: %w", templateName, err)
+		return fmt.Errorf("error cloning template %s: %w", templateName, err)
 	}
 
-	return nil
+	return tr.execute(w, r, t.Funcs(tr.requestFuncs(r)), templateName, data, contentType)
 }
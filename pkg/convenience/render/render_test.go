@@ -0,0 +1,394 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package render_test
+
+import (
+	"errors"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/kemadev/go-framework/pkg/convenience/headkey"
+	"github.com/kemadev/go-framework/pkg/convenience/headval"
+	"github.com/kemadev/go-framework/pkg/convenience/render"
+	"github.com/kemadev/go-framework/pkg/convenience/sechead"
+)
+
+var templates = fstest.MapFS{
+	"tmpl/layouts/base.gotmpl.html": {Data: []byte(
+		`<html lang="{{ lang }}"><title>{{ block "title" . }}default{{ end }}</title>` +
+			`{{ block "content" . }}{{ end }}{{ template "partials/footer.gotmpl.html" . }}</html>`,
+	)},
+	"tmpl/partials/footer.gotmpl.html": {Data: []byte(`<footer>{{ .Date | date "2006-01-02" }}</footer>`)},
+	"tmpl/hello.gotmpl.html": {Data: []byte(
+		`{{ template "layouts/base.gotmpl.html" . }}` +
+			`{{ define "title" }}{{ t "title" }}{{ end }}` +
+			`{{ define "content" }}<h1>{{ t "greeting" .Name }}</h1>{{ end }}`,
+	)},
+	"tmpl/funcs.gotmpl.html": {Data: []byte(`{{ shout .Name }} {{ path }} {{ t "missing" }}`)},
+	"tmpl/plain.gotmpl.html": {Data: []byte(`{{ shout .Name }}`)},
+}
+
+type pageData struct {
+	Name string
+	Date time.Time
+}
+
+func newRenderer(t *testing.T) *render.TemplateRenderer {
+	t.Helper()
+
+	tr, err := render.NewWithConfig(templates, "tmpl", render.Config{
+		Funcs: template.FuncMap{
+			"shout": strings.ToUpper,
+		},
+		RequestFuncs: map[string]func(r *http.Request) any{
+			"path": func(r *http.Request) any {
+				return func() string {
+					return r.URL.Path
+				}
+			},
+		},
+		Translations: map[string]map[string]string{
+			"en": {"title": "Hello", "greeting": "Hello, %s!"},
+			"fr": {"greeting": "Bonjour, %s !"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewWithConfig: %s", err)
+	}
+
+	return tr
+}
+
+func TestExecuteRequest(t *testing.T) {
+	t.Parallel()
+
+	tr := newRenderer(t)
+	data := pageData{Name: "gopher", Date: time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC)}
+
+	tests := []struct {
+		Name           string
+		Template       string
+		AcceptLanguage string
+		ExpectedBody   string
+		ExpectedErr    error
+	}{
+		{
+			Name:         "layout and partial",
+			Template:     "hello.gotmpl.html",
+			ExpectedBody: `<html lang="en"><title>Hello</title><h1>Hello, gopher!</h1><footer>2025-01-02</footer></html>`,
+		},
+		{
+			Name:           "negotiated language with fallback",
+			Template:       "/hello.gotmpl.html",
+			AcceptLanguage: "fr-CH, fr;q=0.9, en;q=0.5",
+			ExpectedBody: `<html lang="fr"><title>Hello</title><h1>Bonjour, gopher !</h1>` +
+				`<footer>2025-01-02</footer></html>`,
+		},
+		{
+			Name:           "unsupported language",
+			Template:       "hello.gotmpl.html",
+			AcceptLanguage: "de",
+			ExpectedBody:   `<html lang="en"><title>Hello</title><h1>Hello, gopher!</h1><footer>2025-01-02</footer></html>`,
+		},
+		{
+			Name:         "configured and request functions",
+			Template:     "funcs.gotmpl.html",
+			ExpectedBody: `GOPHER /page missing`,
+		},
+		{
+			Name:        "shared templates are not pages",
+			Template:    "layouts/base.gotmpl.html",
+			ExpectedErr: render.ErrTemplateNotFound,
+		},
+		{
+			Name:        "unknown template",
+			Template:    "missing.gotmpl.html",
+			ExpectedErr: render.ErrTemplateNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			t.Parallel()
+
+			r := httptest.NewRequest(http.MethodGet, "/page", nil)
+			if tt.AcceptLanguage != "" {
+				r.Header.Set(headkey.AcceptLanguage, tt.AcceptLanguage)
+			}
+
+			rr := httptest.NewRecorder()
+
+			err := tr.ExecuteRequest(rr, r, tt.Template, data, headval.MIMETextHTMLCharsetUTF8)
+			if !errors.Is(err, tt.ExpectedErr) {
+				t.Fatalf("expected error %v but was %v", tt.ExpectedErr, err)
+			}
+
+			if rr.Body.String() != tt.ExpectedBody {
+				t.Errorf("expected body %q but was %q", tt.ExpectedBody, rr.Body.String())
+			}
+
+			if tt.ExpectedErr == nil && rr.Header().Get(headkey.ContentType) != headval.MIMETextHTMLCharsetUTF8 {
+				t.Errorf(
+					"expected content type %q but was %q",
+					headval.MIMETextHTMLCharsetUTF8,
+					rr.Header().Get(headkey.ContentType),
+				)
+			}
+		})
+	}
+}
+
+func TestExecute(t *testing.T) {
+	t.Parallel()
+
+	tr := newRenderer(t)
+
+	rr := httptest.NewRecorder()
+
+	err := tr.Execute(rr, "plain.gotmpl.html", pageData{Name: "gopher"}, headval.MIMETextHTMLCharsetUTF8)
+	if err != nil {
+		t.Fatalf("Execute: %s", err)
+	}
+
+	if rr.Body.String() != "GOPHER" {
+		t.Errorf("expected body %q but was %q", "GOPHER", rr.Body.String())
+	}
+
+	// Request functions require a request
+	rr = httptest.NewRecorder()
+
+	err = tr.Execute(rr, "hello.gotmpl.html", pageData{Name: "gopher"}, headval.MIMETextHTMLCharsetUTF8)
+	if !errors.Is(err, render.ErrRequestFuncUnbound) {
+		t.Errorf("expected error %v but was %v", render.ErrRequestFuncUnbound, err)
+	}
+}
+
+func TestNewWithConfig(t *testing.T) {
+	t.Parallel()
+
+	_, err := render.New(fstest.MapFS{
+		"tmpl/broken.gotmpl.html": {Data: []byte(`{{ if }}`)},
+	}, "tmpl")
+	if err == nil {
+		t.Errorf("expected parse error")
+	}
+
+	_, err = render.New(fstest.MapFS{
+		"tmpl/unknown.gotmpl.html": {Data: []byte(`{{ unknown }}`)},
+	}, "tmpl")
+	if err == nil {
+		t.Errorf("expected undefined function error")
+	}
+}
+
+func TestReload(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"tmpl/page.gotmpl.html": {Data: []byte(`v1`)},
+	}
+
+	tr, err := render.NewWithConfig(fsys, "tmpl", render.Config{Reload: true})
+	if err != nil {
+		t.Fatalf("NewWithConfig: %s", err)
+	}
+
+	tests := []struct {
+		Name           string
+		Template       string
+		ExpectedStatus int
+		ExpectedBody   string
+		ExpectedErr    error
+	}{
+		{
+			Name:           "changes apply",
+			Template:       `v2`,
+			ExpectedStatus: http.StatusOK,
+			ExpectedBody:   "v2",
+		},
+		{
+			Name:           "parse error",
+			Template:       `{{ if }}`,
+			ExpectedStatus: http.StatusInternalServerError,
+			ExpectedBody:   "failed to parse template tmpl/page.gotmpl.html",
+			ExpectedErr:    render.ErrErrorPageSent,
+		},
+		{
+			Name:           "execution error",
+			Template:       `{{ .Missing }}`,
+			ExpectedStatus: http.StatusInternalServerError,
+			ExpectedBody:   "error executing template page.gotmpl.html",
+			ExpectedErr:    render.ErrErrorPageSent,
+		},
+	}
+
+	// Subtests are sequential, as they share file system
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			fsys["tmpl/page.gotmpl.html"] = &fstest.MapFile{Data: []byte(tt.Template)}
+
+			rr := httptest.NewRecorder()
+
+			err := tr.ExecuteRequest(
+				rr,
+				httptest.NewRequest(http.MethodGet, "/", nil),
+				"page.gotmpl.html",
+				pageData{},
+				headval.MIMETextHTMLCharsetUTF8,
+			)
+			if !errors.Is(err, tt.ExpectedErr) {
+				t.Fatalf("expected error %v but was %v", tt.ExpectedErr, err)
+			}
+
+			if rr.Code != tt.ExpectedStatus {
+				t.Errorf("expected status %d but was %d", tt.ExpectedStatus, rr.Code)
+			}
+
+			if !strings.Contains(rr.Body.String(), tt.ExpectedBody) {
+				t.Errorf("expected body to contain %q but was %q", tt.ExpectedBody, rr.Body.String())
+			}
+
+			if tt.ExpectedErr != nil && rr.Header().Get(headkey.CacheControl) != "no-store" {
+				t.Errorf("expected error page not to be stored but Cache-Control was %q", rr.Header().Get(headkey.CacheControl))
+			}
+		})
+	}
+}
+
+func TestExecuteBuffering(t *testing.T) {
+	t.Parallel()
+
+	tr, err := render.NewWithConfig(fstest.MapFS{
+		"tmpl/list.gotmpl.html":   {Data: []byte(`{{ range . }}{{ . }}{{ end }}`)},
+		"tmpl/broken.gotmpl.html": {Data: []byte(`{{ range . }}{{ . }}{{ end }}{{ index . 100 }}`)},
+	}, "tmpl", render.Config{MaxBufferSize: 16})
+	if err != nil {
+		t.Fatalf("NewWithConfig: %s", err)
+	}
+
+	execute := func(templateName string, data []string, ifNoneMatch string) (*httptest.ResponseRecorder, error) {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		if ifNoneMatch != "" {
+			r.Header.Set(headkey.IfNoneMatch, ifNoneMatch)
+		}
+
+		rr := httptest.NewRecorder()
+
+		return rr, tr.ExecuteRequest(rr, r, templateName, data, headval.MIMETextHTMLCharsetUTF8)
+	}
+
+	// Buffered output is sent with validators
+	rr, err := execute("list.gotmpl.html", []string{"a", "b"}, "")
+	if err != nil {
+		t.Fatalf("ExecuteRequest: %s", err)
+	}
+
+	etag := rr.Header().Get(headkey.ETag)
+	if etag == "" || strings.HasPrefix(etag, "W/") {
+		t.Errorf("expected strong ETag but was %q", etag)
+	}
+
+	if rr.Header().Get(headkey.ContentLength) != "2" || rr.Body.String() != "ab" {
+		t.Errorf("expected body %q of length 2 but was %q of length %q", "ab", rr.Body.String(), rr.Header().Get(headkey.ContentLength))
+	}
+
+	tests := []struct {
+		Name           string
+		IfNoneMatch    string
+		Data           []string
+		ExpectedStatus int
+	}{
+		{Name: "matching", IfNoneMatch: etag, Data: []string{"a", "b"}, ExpectedStatus: http.StatusNotModified},
+		{Name: "weak matching", IfNoneMatch: `"other", W/` + etag, Data: []string{"a", "b"}, ExpectedStatus: http.StatusNotModified},
+		{Name: "any", IfNoneMatch: "*", Data: []string{"a", "b"}, ExpectedStatus: http.StatusNotModified},
+		{Name: "changed", IfNoneMatch: etag, Data: []string{"a", "c"}, ExpectedStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		rr, err := execute("list.gotmpl.html", tt.Data, tt.IfNoneMatch)
+		if err != nil {
+			t.Fatalf("%s: ExecuteRequest: %s", tt.Name, err)
+		}
+
+		if rr.Code != tt.ExpectedStatus {
+			t.Errorf("%s: expected status %d but was %d", tt.Name, tt.ExpectedStatus, rr.Code)
+		}
+
+		if tt.ExpectedStatus == http.StatusNotModified && rr.Body.Len() != 0 {
+			t.Errorf("%s: expected no body but was %q", tt.Name, rr.Body.String())
+		}
+	}
+
+	// Execution errors of buffered output send nothing, so that callers can respond properly
+	rr, err = execute("broken.gotmpl.html", []string{"a"}, "")
+	if err == nil || errors.Is(err, render.ErrPartialResponse) {
+		t.Errorf("expected execution error but was %v", err)
+	}
+
+	if rr.Body.Len() != 0 {
+		t.Errorf("expected no partial output but was %q", rr.Body.String())
+	}
+
+	// Outputs larger than buffer are streamed, without validators
+	large := strings.Split(strings.Repeat("x", 32), "")
+
+	rr, err = execute("list.gotmpl.html", large, "")
+	if err != nil {
+		t.Fatalf("ExecuteRequest: %s", err)
+	}
+
+	if rr.Header().Get(headkey.ETag) != "" || rr.Header().Get(headkey.ContentLength) != "" {
+		t.Errorf("expected streamed output to have no validators")
+	}
+
+	if rr.Body.String() != strings.Repeat("x", 32) {
+		t.Errorf("expected whole output but was %q", rr.Body.String())
+	}
+
+	rr, err = execute("broken.gotmpl.html", large, "")
+	if !errors.Is(err, render.ErrPartialResponse) {
+		t.Errorf("expected error %v but was %v", render.ErrPartialResponse, err)
+	}
+
+	if rr.Body.Len() == 0 {
+		t.Errorf("expected partial output")
+	}
+}
+
+func TestNonce(t *testing.T) {
+	t.Parallel()
+
+	tr, err := render.New(fstest.MapFS{
+		"tmpl/page.gotmpl.html": {Data: []byte(`<script nonce="{{ nonce }}"></script>`)},
+	}, "tmpl")
+	if err != nil {
+		t.Fatalf("New: %s", err)
+	}
+
+	var nonce string
+
+	handler := sechead.NewMiddleware(sechead.SecurityHeadersConfig{
+		ContentSecurityPolicy: sechead.ContentSecurityPolicy{Nonce: true},
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		nonce = sechead.Nonce(r.Context())
+
+		err := tr.ExecuteRequest(w, r, "page.gotmpl.html", nil, headval.MIMETextHTMLCharsetUTF8)
+		if err != nil {
+			t.Errorf("ExecuteRequest: %s", err)
+		}
+	}))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	expected := `<script nonce="` + nonce + `"></script>`
+	if nonce == "" || rr.Body.String() != expected {
+		t.Errorf("expected body %q but was %q", expected, rr.Body.String())
+	}
+}
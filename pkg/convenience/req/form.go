@@ -0,0 +1,460 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package req
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/kemadev/go-framework/pkg/convenience/headkey"
+	"github.com/kemadev/go-framework/pkg/convenience/headutil"
+	"github.com/kemadev/go-framework/pkg/convenience/headval"
+)
+
+var (
+	ErrNotForm          = errors.New("content type is not a URL-encoded form")
+	ErrNotMultipart     = errors.New("content type is not multipart form")
+	ErrFormTarget       = errors.New("form target must be a struct")
+	ErrFormValue        = errors.New("form value is invalid")
+	ErrPartTooLarge     = errors.New("multipart part is too large")
+	ErrTooManyParts     = errors.New("multipart form has too many parts")
+	ErrPartTypeRejected = errors.New("multipart part content type is not allowed")
+	ErrSpool            = errors.New("error spooling multipart part")
+)
+
+// Default multipart configuration values.
+const (
+	DefaultMaxPartSize    = 10 << 20
+	DefaultMaxParts       = 100
+	DefaultSpoolThreshold = 1 << 20
+)
+
+// Number of bytes used to sniff content type, see [net/http.DetectContentType].
+const sniffLen = 512
+
+// FormInto parses [r]'s body as an URL-encoded form into a new instance of type T, which must be a struct, and
+// returns the parsed object along with an appropriate HTTP status code for any error encountered during processing
+// (or ok status if there is no error). Fields are bound using their `form` tag, or their name if there is none,
+// and fields tagged with `form:"-"` are ignored. Supported field types are strings, booleans, integers, floats,
+// durations and slices of these. Values not matching any field are ignored.
+// Please note that [net/http.MaxBytesReader] is not called, it is the responsibility of the caller to set it accordingly.
+func FormInto[T any](w http.ResponseWriter, r *http.Request) (T, int, error) {
+	var zero T
+
+	if !headutil.IsMIME(r.Header, headval.MIMEApplicationForm) {
+		return zero, http.StatusBadRequest, fmt.Errorf(
+			"error processing form with content type %q: %w",
+			r.Header.Get(headkey.ContentType),
+			ErrNotForm,
+		)
+	}
+
+	err := r.ParseForm()
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return zero, http.StatusRequestEntityTooLarge, fmt.Errorf(
+				"request body too large: %w",
+				err,
+			)
+		}
+
+		return zero, http.StatusBadRequest, fmt.Errorf("error processing form: %w", err)
+	}
+
+	var result T
+
+	v := reflect.ValueOf(&result).Elem()
+	if v.Kind() != reflect.Struct {
+		return zero, http.StatusInternalServerError, fmt.Errorf("%s: %w", v.Type(), ErrFormTarget)
+	}
+
+	err = bindForm(v, r.PostForm)
+	if err != nil {
+		return zero, http.StatusBadRequest, err
+	}
+
+	return result, http.StatusOK, nil
+}
+
+func bindForm(v reflect.Value, values map[string][]string) error {
+	t := v.Type()
+
+	for i := range v.NumField() {
+		field := v.Field(i)
+		fieldType := t.Field(i)
+
+		if !field.CanSet() {
+			continue
+		}
+
+		name := fieldType.Tag.Get("form")
+		if name == "-" {
+			continue
+		}
+
+		if name == "" {
+			name = fieldType.Name
+		}
+
+		vals, ok := values[name]
+		if !ok || len(vals) == 0 {
+			continue
+		}
+
+		if field.Kind() == reflect.Slice {
+			slice := reflect.MakeSlice(field.Type(), len(vals), len(vals))
+
+			for j, val := range vals {
+				err := setFormValue(slice.Index(j), val)
+				if err != nil {
+					return fmt.Errorf("invalid value for field %q: %w", name, err)
+				}
+			}
+
+			field.Set(slice)
+
+			continue
+		}
+
+		err := setFormValue(field, vals[0])
+		if err != nil {
+			return fmt.Errorf("invalid value for field %q: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+func setFormValue(field reflect.Value, value string) error {
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		// Checkboxes send "on" when checked
+		if value == "on" {
+			value = "true"
+		}
+
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%q: %w", value, ErrFormValue)
+		}
+
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if field.Type() == reflect.TypeOf(time.Duration(0)) {
+			d, err := time.ParseDuration(value)
+			if err != nil {
+				return fmt.Errorf("%q: %w", value, ErrFormValue)
+			}
+
+			field.SetInt(int64(d))
+
+			return nil
+		}
+
+		n, err := strconv.ParseInt(value, 10, field.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("%q: %w", value, ErrFormValue)
+		}
+
+		field.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value, 10, field.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("%q: %w", value, ErrFormValue)
+		}
+
+		field.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, field.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("%q: %w", value, ErrFormValue)
+		}
+
+		field.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported type %s: %w", field.Type(), ErrFormValue)
+	}
+
+	return nil
+}
+
+// MultipartConfig defines the configuration for multipart parsing.
+type MultipartConfig struct {
+	// MaxPartSize is the maximum size of a single part
+	MaxPartSize int64
+	// MaxParts is the maximum number of parts
+	MaxParts int
+	// SpoolThreshold is the size above which parts are spooled to a temporary file instead of kept in memory
+	SpoolThreshold int64
+	// TempDir is the directory where parts are spooled, see [os.CreateTemp]
+	TempDir string
+	// AllowedTypes restricts file parts to given sniffed MIME types (e.g. "image/png"), all types are allowed when
+	// empty. Non-file fields are not restricted
+	AllowedTypes []string
+}
+
+// Part is a multipart form part, fully read either in memory or in a temporary file.
+type Part struct {
+	// FormName is the name of the form field
+	FormName string
+	// FileName is the sanitized file name, empty for non-file fields
+	FileName string
+	// ContentType is the content type sniffed from part's content, not the one declared by the client
+	ContentType string
+	// Size is the size of part's content
+	Size int64
+
+	data []byte
+	file string
+}
+
+// Open returns a reader over part's content. It can be called multiple times.
+func (p *Part) Open() (io.ReadCloser, error) {
+	if p.file == "" {
+		return io.NopCloser(bytes.NewReader(p.data)), nil
+	}
+
+	f, err := os.Open(p.file)
+	if err != nil {
+		return nil, fmt.Errorf("error opening spooled part: %w", err)
+	}
+
+	return f, nil
+}
+
+// Close releases resources held by the part, removing its temporary file if it was spooled to disk.
+func (p *Part) Close() error {
+	p.data = nil
+
+	if p.file == "" {
+		return nil
+	}
+
+	err := os.Remove(p.file)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("error removing spooled part: %w", err)
+	}
+
+	p.file = ""
+
+	return nil
+}
+
+// Multipart parses [r]'s body as a multipart form, reading one part at a time so that whole body is never held
+// in memory. Each part is fully read before being yielded, and must be closed by the caller using [Part.Close].
+// Iteration stops on the first error, which is yielded along with a nil part. Use [ErrorStatus] to get the HTTP
+// status code matching a yielded error.
+// Please note that [net/http.MaxBytesReader] is not called, it is the responsibility of the caller to set it accordingly.
+func Multipart(r *http.Request, conf MultipartConfig) iter.Seq2[*Part, error] {
+	if conf.MaxPartSize <= 0 {
+		conf.MaxPartSize = DefaultMaxPartSize
+	}
+
+	if conf.MaxParts <= 0 {
+		conf.MaxParts = DefaultMaxParts
+	}
+
+	if conf.SpoolThreshold <= 0 {
+		conf.SpoolThreshold = DefaultSpoolThreshold
+	}
+
+	return func(yield func(*Part, error) bool) {
+		if !headutil.IsMIME(r.Header, headval.MIMEMultipartForm) {
+			yield(nil, fmt.Errorf(
+				"error processing multipart form with content type %q: %w",
+				r.Header.Get(headkey.ContentType),
+				ErrNotMultipart,
+			))
+
+			return
+		}
+
+		mr, err := r.MultipartReader()
+		if err != nil {
+			yield(nil, fmt.Errorf("error reading multipart form: %w", err))
+
+			return
+		}
+
+		for count := 0; ; count++ {
+			mp, err := mr.NextPart()
+			if err != nil {
+				if !errors.Is(err, io.EOF) {
+					yield(nil, fmt.Errorf("error reading multipart part: %w", err))
+				}
+
+				return
+			}
+
+			if count >= conf.MaxParts {
+				mp.Close()
+				yield(nil, fmt.Errorf("more than %d parts: %w", conf.MaxParts, ErrTooManyParts))
+
+				return
+			}
+
+			part, err := readPart(mp, conf)
+			mp.Close()
+
+			if err != nil {
+				yield(nil, err)
+
+				return
+			}
+
+			if !yield(part, nil) {
+				return
+			}
+		}
+	}
+}
+
+func readPart(mp *multipart.Part, conf MultipartConfig) (*Part, error) {
+	part := &Part{
+		FormName: mp.FormName(),
+		FileName: SanitizeFileName(mp.FileName()),
+	}
+
+	// Read one more byte than allowed to detect oversized parts
+	lr := io.LimitReader(mp, conf.MaxPartSize+1)
+
+	head := make([]byte, sniffLen)
+
+	n, err := io.ReadFull(lr, head)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, fmt.Errorf("error reading multipart part: %w", err)
+	}
+
+	head = head[:n]
+	part.ContentType = http.DetectContentType(head)
+
+	if len(conf.AllowedTypes) > 0 && mp.FileName() != "" {
+		mediaType, _, _ := strings.Cut(part.ContentType, ";")
+		if !slices.Contains(conf.AllowedTypes, strings.TrimSpace(mediaType)) {
+			return nil, fmt.Errorf("%q: %w", part.ContentType, ErrPartTypeRejected)
+		}
+	}
+
+	var buf bytes.Buffer
+	buf.Write(head)
+
+	// Keep in memory up to spool threshold
+	_, err = io.CopyN(&buf, lr, conf.SpoolThreshold-int64(buf.Len())+1)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("error reading multipart part: %w", err)
+	}
+
+	if int64(buf.Len()) <= conf.SpoolThreshold {
+		part.data = buf.Bytes()
+		part.Size = int64(buf.Len())
+
+		if part.Size > conf.MaxPartSize {
+			return nil, fmt.Errorf("part %q: %w", part.FormName, ErrPartTooLarge)
+		}
+
+		return part, nil
+	}
+
+	f, err := os.CreateTemp(conf.TempDir, "multipart-*")
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrSpool, err)
+	}
+	defer f.Close()
+
+	part.file = f.Name()
+
+	sw := &spoolWriter{w: f}
+
+	size, err := io.Copy(sw, io.MultiReader(&buf, lr))
+	if err != nil {
+		part.Close()
+
+		if sw.err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrSpool, err)
+		}
+
+		return nil, fmt.Errorf("error reading multipart part: %w", err)
+	}
+
+	part.Size = size
+
+	if part.Size > conf.MaxPartSize {
+		part.Close()
+
+		return nil, fmt.Errorf("part %q: %w", part.FormName, ErrPartTooLarge)
+	}
+
+	return part, nil
+}
+
+// spoolWriter records write errors, so that they can be told apart from errors reading request body.
+type spoolWriter struct {
+	w   io.Writer
+	err error
+}
+
+// Write implements [io.Writer].
+func (sw *spoolWriter) Write(b []byte) (int, error) {
+	n, err := sw.w.Write(b)
+	if err != nil {
+		sw.err = err
+	}
+
+	return n, err
+}
+
+// SanitizeFileName returns a file name that is safe to use on a file system, stripping any directory,
+// control characters and leading dots from name. It returns an empty string if nothing usable remains.
+func SanitizeFileName(name string) string {
+	// Clients may send Windows paths
+	name = strings.ReplaceAll(name, `\`, "/")
+	name = path.Base(path.Clean("/" + name))
+
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || r == unicode.ReplacementChar {
+			return -1
+		}
+
+		switch r {
+		case '<', '>', ':', '"', '|', '?', '*':
+			return '_'
+		}
+
+		return r
+	}, name)
+
+	name = strings.TrimLeft(strings.TrimSpace(name), ".")
+
+	maxLen := 255
+	if len(name) > maxLen {
+		ext := path.Ext(name)
+		if len(ext) > maxLen/2 {
+			ext = ""
+		}
+
+		name = strings.ToValidUTF8(name[:maxLen-len(ext)], "") + ext
+	}
+
+	if name == "/" {
+		return ""
+	}
+
+	return name
+}
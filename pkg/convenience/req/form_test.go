@@ -0,0 +1,361 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package req_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kemadev/go-framework/pkg/convenience/headkey"
+	"github.com/kemadev/go-framework/pkg/convenience/headval"
+	"github.com/kemadev/go-framework/pkg/convenience/req"
+)
+
+type formTarget struct {
+	Name     string        `form:"name"`
+	Admin    bool          `form:"admin"`
+	Age      int           `form:"age"`
+	Delay    time.Duration `form:"delay"`
+	Tags     []string      `form:"tag"`
+	Internal string        `form:"-"`
+	Plain    string
+}
+
+func TestFormInto(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Name           string
+		ContentType    string
+		Body           string
+		MaxBytes       int64
+		ExpectedResult formTarget
+		ExpectedStatus int
+	}{
+		{
+			Name:        "all fields",
+			ContentType: headval.MIMEApplicationForm,
+			Body:        "name=gopher&admin=on&age=13&delay=1s&tag=a&tag=b&Internal=x&Plain=y&unknown=z",
+			ExpectedResult: formTarget{
+				Name:  "gopher",
+				Admin: true,
+				Age:   13,
+				Delay: time.Second,
+				Tags:  []string{"a", "b"},
+				Plain: "y",
+			},
+			ExpectedStatus: http.StatusOK,
+		},
+		{
+			Name:           "invalid integer",
+			ContentType:    headval.MIMEApplicationForm,
+			Body:           "age=old",
+			ExpectedStatus: http.StatusBadRequest,
+		},
+		{
+			Name:           "not a form",
+			ContentType:    headval.MIMEApplicationJSON,
+			Body:           `{"name":"gopher"}`,
+			ExpectedStatus: http.StatusBadRequest,
+		},
+		{
+			Name:           "body too large",
+			ContentType:    headval.MIMEApplicationForm,
+			Body:           "name=" + strings.Repeat("a", 100),
+			MaxBytes:       10,
+			ExpectedStatus: http.StatusRequestEntityTooLarge,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			t.Parallel()
+
+			r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.Body))
+			r.Header.Set(headkey.ContentType, tt.ContentType)
+
+			rr := httptest.NewRecorder()
+			if tt.MaxBytes > 0 {
+				r.Body = http.MaxBytesReader(rr, r.Body, tt.MaxBytes)
+			}
+
+			result, status, err := req.FormInto[formTarget](rr, r)
+			if status != tt.ExpectedStatus {
+				t.Fatalf("expected status %d but was %d (error %v)", tt.ExpectedStatus, status, err)
+			}
+
+			if !reflect.DeepEqual(result, tt.ExpectedResult) {
+				t.Errorf("expected result %+v but was %+v", tt.ExpectedResult, result)
+			}
+		})
+	}
+}
+
+// pngData is a PNG signature, enough for content sniffing.
+var pngData = []byte("\x89PNG\x0D\x0A\x1A\x0A" + strings.Repeat("\x00", 64))
+
+type formPart struct {
+	Name     string
+	FileName string
+	Data     []byte
+}
+
+// multipartRequest returns a request whose body is a multipart form made of [parts].
+func multipartRequest(t *testing.T, parts []formPart) *http.Request {
+	t.Helper()
+
+	var body bytes.Buffer
+
+	mw := multipart.NewWriter(&body)
+
+	for _, p := range parts {
+		var (
+			w   io.Writer
+			err error
+		)
+
+		if p.FileName != "" {
+			w, err = mw.CreateFormFile(p.Name, p.FileName)
+		} else {
+			w, err = mw.CreateFormField(p.Name)
+		}
+
+		if err == nil {
+			_, err = w.Write(p.Data)
+		}
+
+		if err != nil {
+			t.Fatalf("error writing multipart body: %s", err)
+		}
+	}
+
+	err := mw.Close()
+	if err != nil {
+		t.Fatalf("error writing multipart body: %s", err)
+	}
+
+	r := httptest.NewRequest(http.MethodPost, "/", &body)
+	r.Header.Set(headkey.ContentType, mw.FormDataContentType())
+
+	return r
+}
+
+func TestMultipart(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Name              string
+		Parts             []formPart
+		Conf              req.MultipartConfig
+		ExpectedParts     []formPart
+		ExpectedSpooled   int
+		ExpectedErrStatus int
+	}{
+		{
+			Name: "fields and files",
+			Parts: []formPart{
+				{Name: "title", Data: []byte("holidays")},
+				{Name: "photo", FileName: "../../etc/beach.png", Data: pngData},
+			},
+			Conf: req.MultipartConfig{AllowedTypes: []string{"image/png"}},
+			ExpectedParts: []formPart{
+				{Name: "title", Data: []byte("holidays")},
+				{Name: "photo", FileName: "beach.png", Data: pngData},
+			},
+			ExpectedErrStatus: http.StatusOK,
+		},
+		{
+			Name: "file type rejected",
+			Parts: []formPart{
+				{Name: "photo", FileName: "beach.png", Data: []byte("#!/bin/sh\necho pwned")},
+			},
+			Conf:              req.MultipartConfig{AllowedTypes: []string{"image/png"}},
+			ExpectedErrStatus: http.StatusUnsupportedMediaType,
+		},
+		{
+			Name: "part in memory at max size",
+			Parts: []formPart{
+				{Name: "bio", Data: bytes.Repeat([]byte("a"), 64)},
+			},
+			Conf: req.MultipartConfig{MaxPartSize: 64},
+			ExpectedParts: []formPart{
+				{Name: "bio", Data: bytes.Repeat([]byte("a"), 64)},
+			},
+			ExpectedErrStatus: http.StatusOK,
+		},
+		{
+			Name: "part in memory too large",
+			Parts: []formPart{
+				{Name: "bio", Data: bytes.Repeat([]byte("a"), 65)},
+			},
+			Conf:              req.MultipartConfig{MaxPartSize: 64},
+			ExpectedErrStatus: http.StatusRequestEntityTooLarge,
+		},
+		{
+			Name: "spooled part",
+			Parts: []formPart{
+				{Name: "small", Data: []byte("tiny")},
+				{Name: "doc", FileName: "doc.txt", Data: bytes.Repeat([]byte("b"), 2048)},
+			},
+			Conf: req.MultipartConfig{SpoolThreshold: 1024},
+			ExpectedParts: []formPart{
+				{Name: "small", Data: []byte("tiny")},
+				{Name: "doc", FileName: "doc.txt", Data: bytes.Repeat([]byte("b"), 2048)},
+			},
+			ExpectedSpooled:   1,
+			ExpectedErrStatus: http.StatusOK,
+		},
+		{
+			Name: "spooled part too large",
+			Parts: []formPart{
+				{Name: "doc", FileName: "doc.txt", Data: bytes.Repeat([]byte("b"), 4096)},
+			},
+			Conf:              req.MultipartConfig{SpoolThreshold: 1024, MaxPartSize: 2048},
+			ExpectedErrStatus: http.StatusRequestEntityTooLarge,
+		},
+		{
+			Name: "too many parts",
+			Parts: []formPart{
+				{Name: "a", Data: []byte("1")},
+				{Name: "b", Data: []byte("2")},
+				{Name: "c", Data: []byte("3")},
+			},
+			Conf: req.MultipartConfig{MaxParts: 2},
+			ExpectedParts: []formPart{
+				{Name: "a", Data: []byte("1")},
+				{Name: "b", Data: []byte("2")},
+			},
+			ExpectedErrStatus: http.StatusRequestEntityTooLarge,
+		},
+		{
+			Name: "spool file can't be created",
+			Parts: []formPart{
+				{Name: "doc", FileName: "doc.txt", Data: bytes.Repeat([]byte("b"), 2048)},
+			},
+			Conf:              req.MultipartConfig{SpoolThreshold: 1024, TempDir: "/nonexistent/dir"},
+			ExpectedErrStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			t.Parallel()
+
+			if tt.Conf.TempDir == "" {
+				tt.Conf.TempDir = t.TempDir()
+			}
+
+			var (
+				got     []formPart
+				spooled int
+				lastErr error
+			)
+
+			for part, err := range req.Multipart(multipartRequest(t, tt.Parts), tt.Conf) {
+				if err != nil {
+					lastErr = err
+
+					break
+				}
+
+				rc, err := part.Open()
+				if err != nil {
+					t.Fatalf("Open: %s", err)
+				}
+
+				data, err := io.ReadAll(rc)
+				rc.Close()
+
+				if err != nil {
+					t.Fatalf("error reading part: %s", err)
+				}
+
+				if int64(len(data)) != part.Size {
+					t.Errorf("expected size %d but was %d", len(data), part.Size)
+				}
+
+				entries, _ := os.ReadDir(tt.Conf.TempDir)
+				spooled = max(spooled, len(entries))
+
+				got = append(got, formPart{Name: part.FormName, FileName: part.FileName, Data: data})
+
+				err = part.Close()
+				if err != nil {
+					t.Errorf("Close: %s", err)
+				}
+			}
+
+			if req.ErrorStatus(lastErr) != tt.ExpectedErrStatus {
+				t.Errorf("expected status %d but was %d (error %v)", tt.ExpectedErrStatus, req.ErrorStatus(lastErr), lastErr)
+			}
+
+			if !reflect.DeepEqual(got, tt.ExpectedParts) {
+				t.Errorf("expected parts %q but was %q", tt.ExpectedParts, got)
+			}
+
+			if spooled != tt.ExpectedSpooled {
+				t.Errorf("expected %d spooled parts but was %d", tt.ExpectedSpooled, spooled)
+			}
+
+			// Spool files are removed once parts are closed, or upon errors
+			entries, _ := os.ReadDir(tt.Conf.TempDir)
+			if len(entries) != 0 {
+				t.Errorf("expected spool files to be removed, found %s", filepath.Join(tt.Conf.TempDir, entries[0].Name()))
+			}
+		})
+	}
+}
+
+func TestMultipartNotMultipart(t *testing.T) {
+	t.Parallel()
+
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("name=gopher"))
+	r.Header.Set(headkey.ContentType, headval.MIMEApplicationForm)
+
+	for _, err := range req.Multipart(r, req.MultipartConfig{}) {
+		if !errors.Is(err, req.ErrNotMultipart) {
+			t.Errorf("expected error %v but was %v", req.ErrNotMultipart, err)
+		}
+	}
+}
+
+func TestSanitizeFileName(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Name         string
+		ExpectedName string
+	}{
+		{Name: "report.pdf", ExpectedName: "report.pdf"},
+		{Name: "../../etc/passwd", ExpectedName: "passwd"},
+		{Name: `C:\Users\gopher\photo.jpg`, ExpectedName: "photo.jpg"},
+		{Name: ".htaccess", ExpectedName: "htaccess"},
+		{Name: "a<b>:c|d?.txt", ExpectedName: "a_b__c_d_.txt"},
+		{Name: "bad\x00name\n.txt", ExpectedName: "badname.txt"},
+		{Name: "/", ExpectedName: ""},
+		{Name: "..", ExpectedName: ""},
+		{Name: strings.Repeat("a", 300) + ".txt", ExpectedName: strings.Repeat("a", 251) + ".txt"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			t.Parallel()
+
+			name := req.SanitizeFileName(tt.Name)
+			if name != tt.ExpectedName {
+				t.Errorf("expected name %q but was %q", tt.ExpectedName, name)
+			}
+		})
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"iter"
 	"net"
 	"net/http"
 	"net/url"
@@ -19,6 +20,7 @@ import (
 
 var (
 	ErrNotJSON                    = errors.New("content type is not JSON")
+	ErrNotJSONStream              = errors.New("content type is not a JSON stream")
 	ErrNoForwardedHeader          = errors.New("no Forwarded header found in request")
 	ErrForwardedDirectiveNotFound = errors.New("Forwarded directive not found in request header")
 	ErrForwardedDirectiveInvalid  = errors.New("Forwarded directive is invalid")
@@ -47,36 +49,12 @@ func JSONFromBody[T any](w http.ResponseWriter, r *http.Request) (T, int, error)
 
 	err := dec.Decode(&result)
 	if err != nil {
-		var maxBytesErr *http.MaxBytesError
-		if errors.As(err, &maxBytesErr) {
-			return zero, http.StatusRequestEntityTooLarge, fmt.Errorf(
-				"request body too large: %w",
-				err,
-			)
-		}
-
-		var syntaxErr *json.SyntaxError
-		if errors.As(err, &syntaxErr) {
-			return zero, http.StatusBadRequest, fmt.Errorf(
-				"invalid JSON syntax at position %d: %w",
-				syntaxErr.Offset,
-				err,
-			)
-		}
-
-		var unmarshalTypeErr *json.UnmarshalTypeError
-		if errors.As(err, &unmarshalTypeErr) {
-			return zero, http.StatusBadRequest, fmt.Errorf(
-				"invalid value for field %q: %w",
-				unmarshalTypeErr.Field,
-				err,
-			)
-		}
+		status, err := decodeError(err)
 
-		return zero, http.StatusBadRequest, fmt.Errorf("error processing JSON: %w", err)
+		return zero, status, err
 	}
 
-	// Check for additional JSON content (streaming not supported)
+	// Check for additional JSON content (streaming not supported, see [JSONStream])
 	err = dec.Decode(&struct{}{})
 	if err != nil {
 		if !errors.Is(err, io.EOF) {
@@ -90,6 +68,107 @@ func JSONFromBody[T any](w http.ResponseWriter, r *http.Request) (T, int, error)
 	return result, http.StatusOK, nil
 }
 
+// JSONStream parses [r]'s body as a stream of JSON values (e.g. NDJSON or JSON Lines), decoding each
+// of them into a new instance of type T as the body is read. Iteration stops on the first error, which
+// is yielded along with a zero T. Use [ErrorStatus] to get the HTTP status code matching a yielded error.
+// Please note that [net/http.MaxBytesReader] is not called, it is the responsibility of the caller to set it accordingly.
+func JSONStream[T any](r *http.Request) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+
+		ct := r.Header.Get(headkey.ContentType)
+		if !strings.HasPrefix(ct, headval.MIMEApplicationNDJSON) &&
+			!strings.HasPrefix(ct, headval.MIMEApplicationJSONLines) {
+			yield(zero, fmt.Errorf(
+				"error processing JSON stream with content type %q: %w",
+				ct,
+				ErrNotJSONStream,
+			))
+
+			return
+		}
+
+		dec := json.NewDecoder(r.Body)
+		dec.DisallowUnknownFields()
+
+		for {
+			var item T
+
+			err := dec.Decode(&item)
+			if err != nil {
+				if errors.Is(err, io.EOF) {
+					return
+				}
+
+				_, err = decodeError(err)
+				yield(zero, err)
+
+				return
+			}
+
+			if !yield(item, nil) {
+				return
+			}
+		}
+	}
+}
+
+// ErrorStatus returns the HTTP status code matching an error returned while reading request body,
+// e.g. by [JSONStream] or [Multipart], or ok status if err is nil.
+func ErrorStatus(err error) int {
+	if err == nil {
+		return http.StatusOK
+	}
+
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) ||
+		errors.Is(err, ErrPartTooLarge) ||
+		errors.Is(err, ErrTooManyParts) {
+		return http.StatusRequestEntityTooLarge
+	}
+
+	if errors.Is(err, ErrPartTypeRejected) {
+		return http.StatusUnsupportedMediaType
+	}
+
+	if errors.Is(err, ErrSpool) {
+		return http.StatusInternalServerError
+	}
+
+	return http.StatusBadRequest
+}
+
+// decodeError maps a JSON decoding error to an appropriate HTTP status code and a descriptive error.
+func decodeError(err error) (int, error) {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return http.StatusRequestEntityTooLarge, fmt.Errorf(
+			"request body too large: %w",
+			err,
+		)
+	}
+
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return http.StatusBadRequest, fmt.Errorf(
+			"invalid JSON syntax at position %d: %w",
+			syntaxErr.Offset,
+			err,
+		)
+	}
+
+	var unmarshalTypeErr *json.UnmarshalTypeError
+	if errors.As(err, &unmarshalTypeErr) {
+		return http.StatusBadRequest, fmt.Errorf(
+			"invalid value for field %q: %w",
+			unmarshalTypeErr.Field,
+			err,
+		)
+	}
+
+	return http.StatusBadRequest, fmt.Errorf("error processing JSON: %w", err)
+}
+
 // IP parses the Forwarded headers and returns the forwarded IP (the first to appear in the headers), and an error if any occurs.
 // Please note that this functions does not check proxy trust, it is the caller's responsibility to ensure the header is trusted.
 func IP(r *http.Request, proxyHeader string) (net.IP, error) {
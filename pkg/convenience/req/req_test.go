@@ -0,0 +1,116 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package req_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kemadev/go-framework/pkg/convenience/headkey"
+	"github.com/kemadev/go-framework/pkg/convenience/headval"
+	"github.com/kemadev/go-framework/pkg/convenience/req"
+)
+
+type streamItem struct {
+	ID int `json:"id"`
+}
+
+func TestJSONStream(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Name              string
+		ContentType       string
+		Body              string
+		MaxBytes          int64
+		ExpectedItems     []streamItem
+		ExpectedErrStatus int
+	}{
+		{
+			Name:              "ndjson",
+			ContentType:       headval.MIMEApplicationNDJSON,
+			Body:              "{\"id\":1}\n{\"id\":2}\n\n{\"id\":3}\n",
+			ExpectedItems:     []streamItem{{ID: 1}, {ID: 2}, {ID: 3}},
+			ExpectedErrStatus: http.StatusOK,
+		},
+		{
+			Name:              "json lines without trailing newline",
+			ContentType:       headval.MIMEApplicationJSONLines + "; charset=utf-8",
+			Body:              "{\"id\":1}\n{\"id\":2}",
+			ExpectedItems:     []streamItem{{ID: 1}, {ID: 2}},
+			ExpectedErrStatus: http.StatusOK,
+		},
+		{
+			Name:              "empty",
+			ContentType:       headval.MIMEApplicationNDJSON,
+			ExpectedErrStatus: http.StatusOK,
+		},
+		{
+			Name:              "not a stream",
+			ContentType:       headval.MIMEApplicationJSON,
+			Body:              "{\"id\":1}",
+			ExpectedErrStatus: http.StatusBadRequest,
+		},
+		{
+			Name:              "syntax error after valid items",
+			ContentType:       headval.MIMEApplicationNDJSON,
+			Body:              "{\"id\":1}\n{\"id\":\n",
+			ExpectedItems:     []streamItem{{ID: 1}},
+			ExpectedErrStatus: http.StatusBadRequest,
+		},
+		{
+			Name:              "unknown field",
+			ContentType:       headval.MIMEApplicationNDJSON,
+			Body:              "{\"id\":1,\"admin\":true}\n",
+			ExpectedErrStatus: http.StatusBadRequest,
+		},
+		{
+			Name:              "body too large",
+			ContentType:       headval.MIMEApplicationNDJSON,
+			Body:              "{\"id\":1}\n{\"id\":2}\n{\"id\":3}\n",
+			MaxBytes:          12,
+			ExpectedItems:     []streamItem{{ID: 1}},
+			ExpectedErrStatus: http.StatusRequestEntityTooLarge,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			t.Parallel()
+
+			r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.Body))
+			r.Header.Set(headkey.ContentType, tt.ContentType)
+
+			if tt.MaxBytes > 0 {
+				r.Body = http.MaxBytesReader(httptest.NewRecorder(), r.Body, tt.MaxBytes)
+			}
+
+			var (
+				items   []streamItem
+				lastErr error
+			)
+
+			for item, err := range req.JSONStream[streamItem](r) {
+				if err != nil {
+					lastErr = err
+
+					break
+				}
+
+				items = append(items, item)
+			}
+
+			if req.ErrorStatus(lastErr) != tt.ExpectedErrStatus {
+				t.Errorf("expected status %d but was %d (error %v)", tt.ExpectedErrStatus, req.ErrorStatus(lastErr), lastErr)
+			}
+
+			if !reflect.DeepEqual(items, tt.ExpectedItems) {
+				t.Errorf("expected items %v but was %v", tt.ExpectedItems, items)
+			}
+		})
+	}
+}
@@ -0,0 +1,32 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package resp
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/kemadev/go-framework/pkg/convenience/headkey"
+	"github.com/kemadev/go-framework/pkg/convenience/headutil"
+)
+
+// Negotiate returns the media type from offers (by order of preference) that best matches r's Accept header,
+// and sets Vary header accordingly. If no offer is acceptable, it sends a 406 response listing available media
+// types and returns false, in which case the caller should stop processing the request.
+func Negotiate(w http.ResponseWriter, r *http.Request, offers ...string) (string, bool) {
+	headutil.AddVary(w.Header(), headkey.Accept)
+
+	mediaType, ok := headutil.Negotiate(r.Header, offers)
+	if !ok {
+		http.Error(
+			w,
+			http.StatusText(http.StatusNotAcceptable)+": available types are "+strings.Join(offers, ", "),
+			http.StatusNotAcceptable,
+		)
+
+		return "", false
+	}
+
+	return mediaType, true
+}
@@ -0,0 +1,56 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package resp
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/kemadev/go-framework/pkg/convenience/headkey"
+	"github.com/kemadev/go-framework/pkg/convenience/headval"
+)
+
+// ProblemTypeBlank is the default problem type, meaning problem has no additional semantics than its status
+// code, see RFC 9457 section 4.2.1.
+const ProblemTypeBlank = "about:blank"
+
+// Problem describes an error in an HTTP response, see RFC 9457.
+type Problem struct {
+	// URI reference identifying problem type
+	Type string `json:"type,omitempty"`
+	// Short summary of problem type
+	Title string `json:"title,omitempty"`
+	// HTTP status code
+	Status int `json:"status,omitempty"`
+	// Explanation specific to this occurrence of problem
+	Detail string `json:"detail,omitempty"`
+	// URI reference identifying this occurrence of problem
+	Instance string `json:"instance,omitempty"`
+}
+
+// NewProblem returns a [Problem] of [ProblemTypeBlank] type for [status], with given [detail].
+func NewProblem(status int, detail string) Problem {
+	return Problem{
+		Type:   ProblemTypeBlank,
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+	}
+}
+
+// ProblemJSON sends [p] as application/problem+json, using its status as response status code.
+func ProblemJSON(w http.ResponseWriter, p Problem) error {
+	body, err := json.Marshal(p)
+	if err != nil {
+		return fmt.Errorf("error marshalling problem: %w", err)
+	}
+
+	w.Header().Set(headkey.ContentType, headval.MIMEApplicationProblemJSON)
+	w.Header().Set(headkey.XContentTypeOptions, "nosniff")
+	w.WriteHeader(p.Status)
+	w.Write(body)
+
+	return nil
+}
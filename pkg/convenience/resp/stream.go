@@ -0,0 +1,67 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package resp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/kemadev/go-framework/pkg/convenience/headkey"
+	"github.com/kemadev/go-framework/pkg/convenience/headval"
+)
+
+// JSONStream writes a stream of JSON values, one per line (e.g. NDJSON or JSON Lines), flushing
+// each of them to the client as soon as it is sent.
+type JSONStream struct {
+	w           http.ResponseWriter
+	rc          *http.ResponseController
+	enc         *json.Encoder
+	contentType string
+	wroteHeader bool
+}
+
+// NewJSONStream returns a [JSONStream] writing to w. Content type should be either
+// [headval.MIMEApplicationNDJSON] or [headval.MIMEApplicationJSONLines], and defaults to the former.
+// Full duplex is enabled on HTTP/1 connections so that request body can still be read (e.g. using
+// [github.com/kemadev/go-framework/pkg/convenience/req.JSONStream]) while the response is streamed.
+func NewJSONStream(w http.ResponseWriter, contentType string) *JSONStream {
+	if contentType == "" {
+		contentType = headval.MIMEApplicationNDJSON
+	}
+
+	rc := http.NewResponseController(w)
+	// HTTP/2 is always full duplex, and will return an error
+	_ = rc.EnableFullDuplex()
+
+	return &JSONStream{
+		w:           w,
+		rc:          rc,
+		enc:         json.NewEncoder(w),
+		contentType: contentType,
+	}
+}
+
+// Send writes payload as a single line of JSON, and flushes it to the client. Underlying writers that are
+// unable to flush (see [net/http.Flusher]) are silently buffered.
+func (s *JSONStream) Send(payload any) error {
+	if !s.wroteHeader {
+		s.w.Header().Set(headkey.ContentType, s.contentType)
+		s.w.Header().Del(headkey.ContentLength)
+		s.wroteHeader = true
+	}
+
+	err := s.enc.Encode(payload)
+	if err != nil {
+		return fmt.Errorf("error encoding json stream item: %w", err)
+	}
+
+	err = s.rc.Flush()
+	if err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return fmt.Errorf("error flushing json stream: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,87 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package resp_test
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kemadev/go-framework/pkg/convenience/headkey"
+	"github.com/kemadev/go-framework/pkg/convenience/headval"
+	"github.com/kemadev/go-framework/pkg/convenience/resp"
+)
+
+func TestJSONStream(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Name                string
+		ContentType         string
+		Payloads            []any
+		ExpectedContentType string
+		ExpectedBody        string
+		ExpectedError       bool
+	}{
+		{
+			Name:                "default content type",
+			Payloads:            []any{map[string]int{"id": 1}, []string{"a", "b"}},
+			ExpectedContentType: headval.MIMEApplicationNDJSON,
+			ExpectedBody:        "{\"id\":1}\n[\"a\",\"b\"]\n",
+		},
+		{
+			Name:                "json lines",
+			ContentType:         headval.MIMEApplicationJSONLines,
+			Payloads:            []any{"line\nbreak"},
+			ExpectedContentType: headval.MIMEApplicationJSONLines,
+			ExpectedBody:        "\"line\\nbreak\"\n",
+		},
+		{
+			Name:                "unsupported value",
+			Payloads:            []any{1, func() {}},
+			ExpectedContentType: headval.MIMEApplicationNDJSON,
+			ExpectedBody:        "1\n",
+			ExpectedError:       true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			t.Parallel()
+
+			rr := httptest.NewRecorder()
+			rr.Header().Set(headkey.ContentLength, "42")
+
+			stream := resp.NewJSONStream(rr, tt.ContentType)
+
+			var err error
+			for _, payload := range tt.Payloads {
+				err = stream.Send(payload)
+				if err != nil {
+					break
+				}
+
+				// Each value is flushed as soon as it is sent
+				if !rr.Flushed {
+					t.Errorf("expected stream to be flushed")
+				}
+			}
+
+			if (err != nil) != tt.ExpectedError {
+				t.Errorf("unexpected error %v", err)
+			}
+
+			if rr.Header().Get(headkey.ContentType) != tt.ExpectedContentType {
+				t.Errorf("expected content type %q but was %q", tt.ExpectedContentType, rr.Header().Get(headkey.ContentType))
+			}
+
+			if rr.Header().Get(headkey.ContentLength) != "" {
+				t.Errorf("expected no content length but was %q", rr.Header().Get(headkey.ContentLength))
+			}
+
+			if rr.Body.String() != tt.ExpectedBody {
+				t.Errorf("expected body %q but was %q", tt.ExpectedBody, rr.Body.String())
+			}
+		})
+	}
+}
@@ -4,9 +4,10 @@
 package sechead
 
 import (
-	"fmt"
+	"maps"
 	"net/http"
 	"net/url"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -14,6 +15,10 @@ import (
 	"github.com/kemadev/go-framework/pkg/convenience/headkey"
 )
 
+// AccessControl statically sends Access-Control-* headers on every response.
+//
+// Deprecated: use [github.com/kemadev/go-framework/pkg/cors], which supports multiple origins and preflight
+// requests.
 type AccessControl struct {
 	// https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Access-Control-Allow-Credentials
 	AccessControlAllowCredentials bool
@@ -32,29 +37,79 @@ type AccessControl struct {
 // https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Content-Security-Policy#fetch_directives
 type ContentSecurityPolicyFetchDirectives struct {
 	// https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Content-Security-Policy/child-src
-	ChildSource string
+	ChildSource []string
 	// https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Content-Security-Policy/connect-src
-	ConnectSource string
+	ConnectSource []string
 	// https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Content-Security-Policy/default-src
-	DefaultSource string
+	DefaultSource []string
 	// https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Content-Security-Policy/font-src
-	FontSource string
+	FontSource []string
 	// https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Content-Security-Policy/frame-src
-	FrameSource string
+	FrameSource []string
 	// https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Content-Security-Policy/img-src
-	ImageSource string
+	ImageSource []string
 	// https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Content-Security-Policy/manifest-src
-	ManifestSource string
+	ManifestSource []string
 	// https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Content-Security-Policy/media-src
-	MediaSource string
+	MediaSource []string
 	// https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Content-Security-Policy/object-src
-	ObjectSource string
+	ObjectSource []string
 	// https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Content-Security-Policy/script-src
-	ScriptSource string
+	ScriptSource []string
+	// https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Content-Security-Policy/script-src-attr
+	ScriptSourceAttr []string
+	// https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Content-Security-Policy/script-src-elem
+	ScriptSourceElem []string
 	// https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Content-Security-Policy/style-src
-	StyleSource string
+	StyleSource []string
+	// https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Content-Security-Policy/style-src-attr
+	StyleSourceAttr []string
+	// https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Content-Security-Policy/style-src-elem
+	StyleSourceElem []string
 	// https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Content-Security-Policy/worker-src
-	WorkerSource string
+	WorkerSource []string
+}
+
+// Source expressions keywords, usable in fetch directives as well as base-uri, form-action and frame-ancestors.
+// Hosts and schemes (e.g. "https://example.com", "https:") can be used as is.
+const (
+	// https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Content-Security-Policy#none
+	SourceNone = "'none'"
+	// https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Content-Security-Policy#self
+	SourceSelf = "'self'"
+	// https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Content-Security-Policy#strict-dynamic
+	SourceStrictDynamic = "'strict-dynamic'"
+	// https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Content-Security-Policy#report-sample
+	SourceReportSample = "'report-sample'"
+	// https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Content-Security-Policy#unsafe-inline
+	SourceUnsafeInline = "'unsafe-inline'"
+	// https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Content-Security-Policy#unsafe-eval
+	SourceUnsafeEval = "'unsafe-eval'"
+	// https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Content-Security-Policy#unsafe-hashes
+	SourceUnsafeHashes = "'unsafe-hashes'"
+	// https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Content-Security-Policy#wasm-unsafe-eval
+	SourceWasmUnsafeEval = "'wasm-unsafe-eval'"
+	// https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Content-Security-Policy#scheme-source
+	SourceData = "data:"
+	// https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Content-Security-Policy#scheme-source
+	SourceBlob = "blob:"
+	// https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Content-Security-Policy#scheme-source
+	SourceHTTPS = "https:"
+)
+
+// SourceNonce returns a nonce source expression for [nonce].
+//
+// https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Content-Security-Policy#nonce-nonce_value
+func SourceNonce(nonce string) string {
+	return "'nonce-" + nonce + "'"
+}
+
+// SourceHash returns a hash source expression for base64-encoded [hash] computed with [algorithm] (one of
+// sha256, sha384 or sha512).
+//
+// https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Content-Security-Policy#hash_algorithm-hash_value
+func SourceHash(algorithm string, hash string) string {
+	return "'" + algorithm + "-" + hash + "'"
 }
 
 const (
@@ -91,7 +146,7 @@ const (
 // https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Content-Security-Policy#document_directives
 type ContentSecurityPolicyDocumentDirectives struct {
 	// https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Content-Security-Policy/base-uri
-	BaseURI string
+	BaseURI []string
 	// https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Content-Security-Policy/sandbox
 	Sandbox string
 }
@@ -99,19 +154,58 @@ type ContentSecurityPolicyDocumentDirectives struct {
 // https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Content-Security-Policy#navigation_directives
 type ContentSecurityPolicyNavigationDirectives struct {
 	// https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Content-Security-Policy/form-action
-	FormAction string
+	FormAction []string
 	// https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Content-Security-Policy/frame-ancestors
-	FrameAncestors string
+	FrameAncestors []string
+}
+
+const (
+	// https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Content-Security-Policy/require-trusted-types-for#script
+	RequireTrustedTypesForScript = "'script'"
+	// https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Content-Security-Policy/trusted-types#allow-duplicates
+	TrustedTypesAllowDuplicates = "'allow-duplicates'"
+)
+
+// https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Content-Security-Policy#other_directives
+type ContentSecurityPolicyOtherDirectives struct {
+	// https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Content-Security-Policy/upgrade-insecure-requests
+	UpgradeInsecureRequests bool
+	// https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Content-Security-Policy/require-trusted-types-for
+	RequireTrustedTypesFor []string
+	// https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Content-Security-Policy/trusted-types
+	TrustedTypes []string
+}
+
+// https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Content-Security-Policy#reporting_directives
+type ContentSecurityPolicyReportingDirectives struct {
+	// Name of an endpoint defined in [Reporting], see
+	// https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Content-Security-Policy/report-to
+	ReportTo string
+	// Deprecated in favor of ReportTo, but still needed for browsers lacking Reporting API support, see
+	// https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Content-Security-Policy/report-uri
+	ReportURI []string
 }
 
 // https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Content-Security-Policy
 type ContentSecurityPolicy struct {
+	// Generate a nonce for each request, added to script-src and style-src, see [Nonce]
+	Nonce bool
 	// https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Content-Security-Policy/child-src
 	FetchDirectives ContentSecurityPolicyFetchDirectives
 	// https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Content-Security-Policy#document_directives
 	DocumentDirectives ContentSecurityPolicyDocumentDirectives
 	// https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Content-Security-Policy#navigation_directives
 	NavigationDirectives ContentSecurityPolicyNavigationDirectives
+	// https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Content-Security-Policy#other_directives
+	OtherDirectives ContentSecurityPolicyOtherDirectives
+	// https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Content-Security-Policy#reporting_directives
+	ReportingDirectives ContentSecurityPolicyReportingDirectives
+}
+
+// https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Reporting-Endpoints
+type Reporting struct {
+	// Reporting endpoints URLs by name
+	Endpoints map[string]string
 }
 
 const (
@@ -176,6 +270,91 @@ type ReferrerPolicy struct {
 	ReferrerPolicy string
 }
 
+// https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Strict-Transport-Security
+type StrictTransportSecurity struct {
+	// Header is only sent when greater than zero, see
+	// https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Strict-Transport-Security#max-ageexpire-time
+	MaxAge time.Duration
+	// https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Strict-Transport-Security#includesubdomains
+	IncludeSubDomains bool
+	// https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Strict-Transport-Security#preload
+	Preload bool
+}
+
+// Allowlist members of Permissions-Policy directives. Origins (e.g. "https://example.com") can be used as is.
+const (
+	// https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Permissions-Policy#self
+	PermissionsPolicyAllowSelf = "self"
+	// https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Permissions-Policy#src
+	PermissionsPolicyAllowSrc = "src"
+	// https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Permissions-Policy#*
+	PermissionsPolicyAllowAll = "*"
+)
+
+// PermissionsPolicyDirective controls a browser feature.
+type PermissionsPolicyDirective struct {
+	// Feature name, e.g. camera or geolocation, see
+	// https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Permissions-Policy#directives
+	Feature string
+	// Origins allowed to use the feature, the feature being disabled when empty
+	Allowlist []string
+}
+
+// https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Permissions-Policy
+type PermissionsPolicy struct {
+	Directives []PermissionsPolicyDirective
+}
+
+// PermissionsPolicyPowerfulFeatures lists features granting access to sensitive capabilities, which most
+// applications do not need.
+var PermissionsPolicyPowerfulFeatures = []string{
+	"accelerometer",
+	"autoplay",
+	"bluetooth",
+	"camera",
+	"display-capture",
+	"geolocation",
+	"gyroscope",
+	"hid",
+	"magnetometer",
+	"microphone",
+	"midi",
+	"payment",
+	"publickey-credentials-get",
+	"screen-wake-lock",
+	"serial",
+	"usb",
+	"xr-spatial-tracking",
+}
+
+// PermissionsPolicyDisable returns directives disabling all [features].
+func PermissionsPolicyDisable(features []string) []PermissionsPolicyDirective {
+	directives := make([]PermissionsPolicyDirective, 0, len(features))
+
+	for _, feature := range features {
+		directives = append(directives, PermissionsPolicyDirective{Feature: feature})
+	}
+
+	return directives
+}
+
+const (
+	// https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Integrity-Policy#blocked-destinations
+	IntegrityPolicyDestinationScript = "script"
+	// https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Integrity-Policy#sources
+	IntegrityPolicySourceInline = "inline"
+)
+
+// https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Integrity-Policy
+type IntegrityPolicy struct {
+	// Request destinations that must carry integrity metadata, header is only sent when set
+	BlockedDestinations []string
+	// Integrity sources, defaulting to inline in browsers
+	Sources []string
+	// Names of endpoints defined in [Reporting] that violations are reported to
+	Endpoints []string
+}
+
 const (
 	// https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/X-Frame-Options#deny
 	FrameOptionsDeny = "DENY"
@@ -183,19 +362,59 @@ const (
 	FrameOptionsSameOrigin = "SAMEORIGIN"
 )
 
+const (
+	// https://owasp.org/www-project-secure-headers/#x-permitted-cross-domain-policies
+	PermittedCrossDomainPoliciesNone = "none"
+	// https://owasp.org/www-project-secure-headers/#x-permitted-cross-domain-policies
+	PermittedCrossDomainPoliciesMasterOnly = "master-only"
+	// https://owasp.org/www-project-secure-headers/#x-permitted-cross-domain-policies
+	PermittedCrossDomainPoliciesByContentType = "by-content-type"
+	// https://owasp.org/www-project-secure-headers/#x-permitted-cross-domain-policies
+	PermittedCrossDomainPoliciesAll = "all"
+)
+
+const (
+	// https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Clear-Site-Data#cache
+	ClearSiteDataCache = "cache"
+	// https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Clear-Site-Data#clienthints
+	ClearSiteDataClientHints = "clientHints"
+	// https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Clear-Site-Data#cookies
+	ClearSiteDataCookies = "cookies"
+	// https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Clear-Site-Data#executioncontexts
+	ClearSiteDataExecutionContexts = "executionContexts"
+	// https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Clear-Site-Data#storage
+	ClearSiteDataStorage = "storage"
+	// https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Clear-Site-Data#wildcard
+	ClearSiteDataAll = "*"
+)
+
 type OtherOptions struct {
 	// https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/X-Content-Type-Options
 	ContentTypeOptionsNoSniff bool
 	// https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/X-Frame-Options
 	FrameOptions string
+	// https://owasp.org/www-project-secure-headers/#x-permitted-cross-domain-policies
+	PermittedCrossDomainPolicies string
+	// Data types cleared in the browser, typically only set on logout routes, see
+	// https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Clear-Site-Data
+	ClearSiteData []string
+	// https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Origin-Agent-Cluster
+	OriginAgentCluster bool
 }
 
 type SecurityHeadersConfig struct {
 	AccessControl         AccessControl
 	ContentSecurityPolicy ContentSecurityPolicy
-	CrossOriginPolicy     CrossOriginPolicy
-	ReferrerPolicy        ReferrerPolicy
-	OtherOptions          OtherOptions
+	// Policy that is only reported upon violation, not enforced, e.g. to trial a stricter policy alongside the
+	// enforced one
+	ContentSecurityPolicyReportOnly *ContentSecurityPolicy
+	Reporting                       Reporting
+	CrossOriginPolicy               CrossOriginPolicy
+	ReferrerPolicy                  ReferrerPolicy
+	StrictTransportSecurity         StrictTransportSecurity
+	PermissionsPolicy               PermissionsPolicy
+	IntegrityPolicy                 IntegrityPolicy
+	OtherOptions                    OtherOptions
 }
 
 func (conf *SecurityHeadersConfig) Headers() http.Header {
@@ -243,11 +462,22 @@ func (conf *SecurityHeadersConfig) Headers() http.Header {
 		)
 	}
 
-	csp := conf.ContentSecurityPolicy.buildCSPHeader()
+	csp := conf.ContentSecurityPolicy.buildCSPHeader("")
 	if csp != "" {
 		head.Set(headkey.ContentSecurityPolicy, csp)
 	}
 
+	if conf.ContentSecurityPolicyReportOnly != nil {
+		cspReportOnly := conf.ContentSecurityPolicyReportOnly.buildCSPHeader("")
+		if cspReportOnly != "" {
+			head.Set(headkey.ContentSecurityPolicyReportOnly, cspReportOnly)
+		}
+	}
+
+	if len(conf.Reporting.Endpoints) > 0 {
+		head.Set(headkey.ReportingEndpoints, conf.Reporting.build())
+	}
+
 	if conf.CrossOriginPolicy.CrossOriginEmbedderPolicy != "" {
 		head.Set(
 			headkey.CrossOriginEmbedderPolicy,
@@ -278,137 +508,208 @@ func (conf *SecurityHeadersConfig) Headers() http.Header {
 		head.Set(headkey.XFrameOptions, conf.OtherOptions.FrameOptions)
 	}
 
-	return head
-}
-
-func (conf *ContentSecurityPolicy) buildCSPHeader() string {
-	var directives []string
-
-	if conf.FetchDirectives.DefaultSource != "" {
-		directives = append(
-			directives,
-			fmt.Sprintf("default-src '%s'", conf.FetchDirectives.DefaultSource),
-		)
+	if conf.StrictTransportSecurity.MaxAge > 0 {
+		head.Set(headkey.StrictTransportSecurity, conf.StrictTransportSecurity.build())
 	}
 
-	if conf.FetchDirectives.ChildSource != "" {
-		directives = append(
-			directives,
-			fmt.Sprintf("child-src '%s'", conf.FetchDirectives.ChildSource),
-		)
+	if len(conf.PermissionsPolicy.Directives) > 0 {
+		head.Set(headkey.PermissionsPolicy, conf.PermissionsPolicy.build())
 	}
 
-	if conf.FetchDirectives.ConnectSource != "" {
-		directives = append(
-			directives,
-			fmt.Sprintf("connect-src '%s'", conf.FetchDirectives.ConnectSource),
-		)
+	if len(conf.IntegrityPolicy.BlockedDestinations) > 0 {
+		head.Set(headkey.IntegrityPolicy, conf.IntegrityPolicy.build())
 	}
 
-	if conf.FetchDirectives.FontSource != "" {
-		directives = append(
-			directives,
-			fmt.Sprintf("font-src '%s'", conf.FetchDirectives.FontSource),
+	if conf.OtherOptions.PermittedCrossDomainPolicies != "" {
+		head.Set(
+			headkey.XPermittedCrossDomainPolicies,
+			conf.OtherOptions.PermittedCrossDomainPolicies,
 		)
 	}
 
-	if conf.FetchDirectives.FrameSource != "" {
-		directives = append(
-			directives,
-			fmt.Sprintf("frame-src '%s'", conf.FetchDirectives.FrameSource),
-		)
+	if len(conf.OtherOptions.ClearSiteData) > 0 {
+		head.Set(headkey.ClearSiteData, `"`+strings.Join(conf.OtherOptions.ClearSiteData, `", "`)+`"`)
 	}
 
-	if conf.FetchDirectives.ImageSource != "" {
-		directives = append(
-			directives,
-			fmt.Sprintf("img-src '%s'", conf.FetchDirectives.ImageSource),
-		)
+	if conf.OtherOptions.OriginAgentCluster {
+		head.Set(headkey.OriginAgentCluster, "?1")
 	}
 
-	if conf.FetchDirectives.ManifestSource != "" {
-		directives = append(
-			directives,
-			fmt.Sprintf(
-				"manifest-src '%s'",
-				conf.FetchDirectives.ManifestSource,
-			),
-		)
-	}
+	return head
+}
 
-	if conf.FetchDirectives.MediaSource != "" {
-		directives = append(
-			directives,
-			fmt.Sprintf("media-src '%s'", conf.FetchDirectives.MediaSource),
-		)
+// build returns Strict-Transport-Security header value.
+func (conf *StrictTransportSecurity) build() string {
+	directives := []string{"max-age=" + strconv.FormatInt(int64(conf.MaxAge.Seconds()), 10)}
+
+	if conf.IncludeSubDomains {
+		directives = append(directives, "includeSubDomains")
 	}
 
-	if conf.FetchDirectives.ObjectSource != "" {
-		directives = append(
-			directives,
-			fmt.Sprintf("object-src '%s'", conf.FetchDirectives.ObjectSource),
-		)
+	if conf.Preload {
+		directives = append(directives, "preload")
 	}
 
-	if conf.FetchDirectives.ScriptSource != "" {
-		directives = append(
-			directives,
-			fmt.Sprintf("script-src '%s'", conf.FetchDirectives.ScriptSource),
-		)
+	return strings.Join(directives, "; ")
+}
+
+// build returns Permissions-Policy header value, a structured field dictionary (RFC 8941) of allowlists.
+func (conf *PermissionsPolicy) build() string {
+	directives := make([]string, 0, len(conf.Directives))
+
+	for _, directive := range conf.Directives {
+		if slices.Contains(directive.Allowlist, PermissionsPolicyAllowAll) {
+			directives = append(directives, directive.Feature+"="+PermissionsPolicyAllowAll)
+
+			continue
+		}
+
+		members := make([]string, 0, len(directive.Allowlist))
+
+		for _, member := range directive.Allowlist {
+			switch member {
+			case PermissionsPolicyAllowSelf, PermissionsPolicyAllowSrc:
+				members = append(members, member)
+			default:
+				// Origins are strings, keywords being tokens
+				members = append(members, strconv.Quote(member))
+			}
+		}
+
+		directives = append(directives, directive.Feature+"=("+strings.Join(members, " ")+")")
 	}
 
-	if conf.FetchDirectives.StyleSource != "" {
-		directives = append(
-			directives,
-			fmt.Sprintf("style-src '%s'", conf.FetchDirectives.StyleSource),
-		)
+	return strings.Join(directives, ", ")
+}
+
+// build returns Integrity-Policy header value, a structured field dictionary (RFC 8941) of inner lists.
+func (conf *IntegrityPolicy) build() string {
+	directives := []string{"blocked-destinations=(" + strings.Join(conf.BlockedDestinations, " ") + ")"}
+
+	if len(conf.Sources) > 0 {
+		directives = append(directives, "sources=("+strings.Join(conf.Sources, " ")+")")
 	}
 
-	if conf.FetchDirectives.WorkerSource != "" {
-		directives = append(
-			directives,
-			fmt.Sprintf("worker-src '%s'", conf.FetchDirectives.WorkerSource),
-		)
+	if len(conf.Endpoints) > 0 {
+		directives = append(directives, "endpoints=("+strings.Join(conf.Endpoints, " ")+")")
 	}
 
-	if conf.DocumentDirectives.BaseURI != "" {
-		directives = append(
-			directives,
-			fmt.Sprintf("base-uri '%s'", conf.DocumentDirectives.BaseURI),
-		)
+	return strings.Join(directives, ", ")
+}
+
+// buildCSPHeader returns Content-Security-Policy header value, adding [nonce] to script and style sources
+// unless empty.
+func (conf *ContentSecurityPolicy) buildCSPHeader(nonce string) string {
+	var directives []string
+
+	scriptSource := conf.FetchDirectives.ScriptSource
+	scriptSourceElem := conf.FetchDirectives.ScriptSourceElem
+	styleSource := conf.FetchDirectives.StyleSource
+	styleSourceElem := conf.FetchDirectives.StyleSourceElem
+
+	if nonce != "" {
+		scriptSource = withNonce(scriptSource, conf.FetchDirectives.DefaultSource, nonce)
+		styleSource = withNonce(styleSource, conf.FetchDirectives.DefaultSource, nonce)
+
+		// Unset element directives fall back to the ones above
+		if len(scriptSourceElem) > 0 {
+			scriptSourceElem = withNonce(scriptSourceElem, nil, nonce)
+		}
+
+		if len(styleSourceElem) > 0 {
+			styleSourceElem = withNonce(styleSourceElem, nil, nonce)
+		}
 	}
 
+	directives = appendDirective(directives, "default-src", conf.FetchDirectives.DefaultSource)
+	directives = appendDirective(directives, "child-src", conf.FetchDirectives.ChildSource)
+	directives = appendDirective(directives, "connect-src", conf.FetchDirectives.ConnectSource)
+	directives = appendDirective(directives, "font-src", conf.FetchDirectives.FontSource)
+	directives = appendDirective(directives, "frame-src", conf.FetchDirectives.FrameSource)
+	directives = appendDirective(directives, "img-src", conf.FetchDirectives.ImageSource)
+	directives = appendDirective(directives, "manifest-src", conf.FetchDirectives.ManifestSource)
+	directives = appendDirective(directives, "media-src", conf.FetchDirectives.MediaSource)
+	directives = appendDirective(directives, "object-src", conf.FetchDirectives.ObjectSource)
+	directives = appendDirective(directives, "script-src", scriptSource)
+	directives = appendDirective(directives, "script-src-attr", conf.FetchDirectives.ScriptSourceAttr)
+	directives = appendDirective(directives, "script-src-elem", scriptSourceElem)
+	directives = appendDirective(directives, "style-src", styleSource)
+	directives = appendDirective(directives, "style-src-attr", conf.FetchDirectives.StyleSourceAttr)
+	directives = appendDirective(directives, "style-src-elem", styleSourceElem)
+	directives = appendDirective(directives, "worker-src", conf.FetchDirectives.WorkerSource)
+	directives = appendDirective(directives, "base-uri", conf.DocumentDirectives.BaseURI)
+
 	if conf.DocumentDirectives.Sandbox != "" {
-		if conf.DocumentDirectives.Sandbox == "true" {
+		if conf.DocumentDirectives.Sandbox == ContentSecurityPolicySandboxStrict {
 			directives = append(directives, "sandbox")
 		} else {
-			directives = append(directives, fmt.Sprintf("sandbox '%s'", conf.DocumentDirectives.Sandbox))
+			// Sandbox flags are tokens, not source expressions
+			directives = append(directives, "sandbox "+conf.DocumentDirectives.Sandbox)
 		}
 	}
 
-	if conf.NavigationDirectives.FormAction != "" {
-		directives = append(
-			directives,
-			fmt.Sprintf(
-				"form-action '%s'",
-				conf.NavigationDirectives.FormAction,
-			),
-		)
+	directives = appendDirective(directives, "form-action", conf.NavigationDirectives.FormAction)
+	directives = appendDirective(directives, "frame-ancestors", conf.NavigationDirectives.FrameAncestors)
+
+	if conf.OtherDirectives.UpgradeInsecureRequests {
+		directives = append(directives, "upgrade-insecure-requests")
 	}
 
-	if conf.NavigationDirectives.FrameAncestors != "" {
-		directives = append(
-			directives,
-			fmt.Sprintf(
-				"frame-ancestors '%s'",
-				conf.NavigationDirectives.FrameAncestors,
-			),
-		)
+	directives = appendDirective(
+		directives,
+		"require-trusted-types-for",
+		conf.OtherDirectives.RequireTrustedTypesFor,
+	)
+	directives = appendDirective(directives, "trusted-types", conf.OtherDirectives.TrustedTypes)
+
+	if conf.ReportingDirectives.ReportTo != "" {
+		directives = append(directives, "report-to "+conf.ReportingDirectives.ReportTo)
 	}
 
+	directives = appendDirective(directives, "report-uri", conf.ReportingDirectives.ReportURI)
+
 	return strings.Join(directives, "; ")
 }
 
+// build returns Reporting-Endpoints header value, endpoints being sorted by name.
+func (conf *Reporting) build() string {
+	names := slices.Sorted(maps.Keys(conf.Endpoints))
+	endpoints := make([]string, 0, len(names))
+
+	for _, name := range names {
+		endpoints = append(endpoints, name+`="`+conf.Endpoints[name]+`"`)
+	}
+
+	return strings.Join(endpoints, ", ")
+}
+
+// appendDirective appends directive [name] with its [sources] to [directives], unless sources are empty.
+func appendDirective(directives []string, name string, sources []string) []string {
+	if len(sources) == 0 {
+		return directives
+	}
+
+	return append(directives, name+" "+strings.Join(sources, " "))
+}
+
+// withNonce returns [sources] allowing [nonce]. Unset sources inherit [defaultSources], as directive would
+// otherwise only allow nonce, and 'none' is dropped as it must be the only source.
+func withNonce(sources []string, defaultSources []string, nonce string) []string {
+	if len(sources) == 0 {
+		sources = defaultSources
+	}
+
+	res := make([]string, 0, len(sources)+1)
+
+	for _, source := range sources {
+		if source != SourceNone {
+			res = append(res, source)
+		}
+	}
+
+	return append(res, SourceNonce(nonce))
+}
+
 var SecHeadersDefaultStrict = SecurityHeadersConfig{
 	AccessControl: AccessControl{
 		AccessControlAllowCredentials: false,
@@ -420,26 +721,26 @@ var SecHeadersDefaultStrict = SecurityHeadersConfig{
 	},
 	ContentSecurityPolicy: ContentSecurityPolicy{
 		FetchDirectives: ContentSecurityPolicyFetchDirectives{
-			ChildSource:    "none",
-			ConnectSource:  "none",
-			DefaultSource:  "none",
-			FontSource:     "none",
-			FrameSource:    "none",
-			ImageSource:    "none",
-			ManifestSource: "none",
-			MediaSource:    "none",
-			ObjectSource:   "none",
-			ScriptSource:   "none",
-			StyleSource:    "none",
-			WorkerSource:   "none",
+			ChildSource:    []string{SourceNone},
+			ConnectSource:  []string{SourceNone},
+			DefaultSource:  []string{SourceNone},
+			FontSource:     []string{SourceNone},
+			FrameSource:    []string{SourceNone},
+			ImageSource:    []string{SourceNone},
+			ManifestSource: []string{SourceNone},
+			MediaSource:    []string{SourceNone},
+			ObjectSource:   []string{SourceNone},
+			ScriptSource:   []string{SourceNone},
+			StyleSource:    []string{SourceNone},
+			WorkerSource:   []string{SourceNone},
 		},
 		DocumentDirectives: ContentSecurityPolicyDocumentDirectives{
-			BaseURI: "none",
+			BaseURI: []string{SourceNone},
 			Sandbox: "",
 		},
 		NavigationDirectives: ContentSecurityPolicyNavigationDirectives{
-			FormAction:     "none",
-			FrameAncestors: "none",
+			FormAction:     []string{SourceNone},
+			FrameAncestors: []string{SourceNone},
 		},
 	},
 	CrossOriginPolicy: CrossOriginPolicy{
@@ -0,0 +1,27 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package sechead
+
+import (
+	"context"
+	"crypto/rand"
+)
+
+type nonceKey struct{}
+
+// Nonce returns the Content-Security-Policy nonce of current request, or an empty string if nonces are not
+// enabled, see [ContentSecurityPolicy]. Inline scripts and styles must carry it in their nonce attribute.
+func Nonce(c context.Context) string {
+	nonce, _ := c.Value(nonceKey{}).(string)
+
+	return nonce
+}
+
+// newNonceContext returns a context carrying a new nonce, along with the nonce.
+func newNonceContext(c context.Context) (context.Context, string) {
+	// 128 bits of entropy, using a charset allowed in nonce source expressions
+	nonce := rand.Text()
+
+	return context.WithValue(c, nonceKey{}, nonce), nonce
+}
@@ -0,0 +1,138 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package sechead
+
+import "time"
+
+const (
+	// hstsMaxAge is the HSTS max-age of presets, as recommended for preload list submission.
+	hstsMaxAge = 2 * 365 * 24 * time.Hour
+	// hstsPreloadMinMaxAge is the minimum HSTS max-age required for preload list submission.
+	hstsPreloadMinMaxAge = 365 * 24 * time.Hour
+)
+
+// SecHeadersStrictAPI is a preset for APIs that only serve data, and never render documents. Browsers are
+// forbidden from using responses as anything else than data.
+var SecHeadersStrictAPI = SecurityHeadersConfig{
+	ContentSecurityPolicy: ContentSecurityPolicy{
+		FetchDirectives: ContentSecurityPolicyFetchDirectives{
+			DefaultSource: []string{SourceNone},
+		},
+		DocumentDirectives: ContentSecurityPolicyDocumentDirectives{
+			BaseURI: []string{SourceNone},
+			Sandbox: ContentSecurityPolicySandboxStrict,
+		},
+		NavigationDirectives: ContentSecurityPolicyNavigationDirectives{
+			FormAction:     []string{SourceNone},
+			FrameAncestors: []string{SourceNone},
+		},
+	},
+	CrossOriginPolicy: CrossOriginPolicy{
+		CrossOriginEmbedderPolicy: CrossOriginEmbedderPolicyRequireCORP,
+		CrossOriginOpenerPolicy:   CrossOriginOpenerPolicySameOrigin,
+		CrossOriginResourcePolicy: CrossOriginResourcePolicySameOrigin,
+	},
+	ReferrerPolicy: ReferrerPolicy{
+		ReferrerPolicy: ReferrerPolicyNoReferrer,
+	},
+	StrictTransportSecurity: StrictTransportSecurity{
+		MaxAge:            hstsMaxAge,
+		IncludeSubDomains: true,
+	},
+	PermissionsPolicy: PermissionsPolicy{
+		Directives: PermissionsPolicyDisable(PermissionsPolicyPowerfulFeatures),
+	},
+	OtherOptions: OtherOptions{
+		ContentTypeOptionsNoSniff:    true,
+		FrameOptions:                 FrameOptionsDeny,
+		PermittedCrossDomainPolicies: PermittedCrossDomainPoliciesNone,
+	},
+}
+
+// SecHeadersStrictHTMLApp is a preset for HTML applications serving their own assets. Inline scripts and
+// styles must carry the per-request nonce, see [Nonce], and cross-origin resources can only be loaded without
+// credentials.
+var SecHeadersStrictHTMLApp = SecurityHeadersConfig{
+	ContentSecurityPolicy: ContentSecurityPolicy{
+		Nonce: true,
+		FetchDirectives: ContentSecurityPolicyFetchDirectives{
+			DefaultSource:  []string{SourceNone},
+			ConnectSource:  []string{SourceSelf},
+			FontSource:     []string{SourceSelf},
+			ImageSource:    []string{SourceSelf, SourceData},
+			ManifestSource: []string{SourceSelf},
+			MediaSource:    []string{SourceSelf},
+			ScriptSource:   []string{SourceSelf},
+			StyleSource:    []string{SourceSelf},
+			WorkerSource:   []string{SourceSelf},
+		},
+		DocumentDirectives: ContentSecurityPolicyDocumentDirectives{
+			BaseURI: []string{SourceNone},
+		},
+		NavigationDirectives: ContentSecurityPolicyNavigationDirectives{
+			FormAction:     []string{SourceSelf},
+			FrameAncestors: []string{SourceNone},
+		},
+		OtherDirectives: ContentSecurityPolicyOtherDirectives{
+			UpgradeInsecureRequests: true,
+		},
+	},
+	CrossOriginPolicy: CrossOriginPolicy{
+		CrossOriginEmbedderPolicy: CrossOriginEmbedderPolicyCredentialLess,
+		CrossOriginOpenerPolicy:   CrossOriginOpenerPolicySameOrigin,
+		CrossOriginResourcePolicy: CrossOriginResourcePolicySameOrigin,
+	},
+	ReferrerPolicy: ReferrerPolicy{
+		ReferrerPolicy: ReferrerPolicyStrictOriginWhenCrossOrigin,
+	},
+	StrictTransportSecurity: StrictTransportSecurity{
+		MaxAge:            hstsMaxAge,
+		IncludeSubDomains: true,
+	},
+	PermissionsPolicy: PermissionsPolicy{
+		Directives: PermissionsPolicyDisable(PermissionsPolicyPowerfulFeatures),
+	},
+	OtherOptions: OtherOptions{
+		ContentTypeOptionsNoSniff:    true,
+		FrameOptions:                 FrameOptionsDeny,
+		PermittedCrossDomainPolicies: PermittedCrossDomainPoliciesNone,
+		OriginAgentCluster:           true,
+	},
+}
+
+// SecHeadersRelaxedDev is a preset for HTML applications in local environment. It does not send HSTS, which
+// browsers would remember for localhost, nor upgrade requests to HTTPS, and lets development tooling connect
+// using WebSockets and load resources from any HTTPS origin.
+var SecHeadersRelaxedDev = SecurityHeadersConfig{
+	ContentSecurityPolicy: ContentSecurityPolicy{
+		Nonce: true,
+		FetchDirectives: ContentSecurityPolicyFetchDirectives{
+			DefaultSource: []string{SourceSelf},
+			ConnectSource: []string{SourceSelf, "ws:", "wss:"},
+			FontSource:    []string{SourceSelf, SourceHTTPS, SourceData},
+			ImageSource:   []string{SourceSelf, SourceHTTPS, SourceData, SourceBlob},
+			ScriptSource:  []string{SourceSelf},
+			StyleSource:   []string{SourceSelf},
+		},
+		DocumentDirectives: ContentSecurityPolicyDocumentDirectives{
+			BaseURI: []string{SourceSelf},
+		},
+		NavigationDirectives: ContentSecurityPolicyNavigationDirectives{
+			FormAction:     []string{SourceSelf},
+			FrameAncestors: []string{SourceSelf},
+		},
+	},
+	CrossOriginPolicy: CrossOriginPolicy{
+		CrossOriginEmbedderPolicy: CrossOriginEmbedderPolicyUnsafeNone,
+		CrossOriginOpenerPolicy:   CrossOriginOpenerPolicySameOrigin,
+		CrossOriginResourcePolicy: CrossOriginResourcePolicySameSite,
+	},
+	ReferrerPolicy: ReferrerPolicy{
+		ReferrerPolicy: ReferrerPolicyStrictOriginWhenCrossOrigin,
+	},
+	OtherOptions: OtherOptions{
+		ContentTypeOptionsNoSniff: true,
+		FrameOptions:              FrameOptionsSameOrigin,
+	},
+}
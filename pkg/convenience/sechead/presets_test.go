@@ -0,0 +1,202 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package sechead_test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kemadev/go-framework/pkg/convenience/headkey"
+	"github.com/kemadev/go-framework/pkg/convenience/sechead"
+)
+
+func TestPresets(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Name            string
+		Conf            sechead.SecurityHeadersConfig
+		ExpectedHeaders map[string]string
+	}{
+		{
+			Name: "strict API",
+			Conf: sechead.SecHeadersStrictAPI,
+			ExpectedHeaders: map[string]string{
+				headkey.ContentSecurityPolicy: "default-src 'none'; base-uri 'none'; sandbox; form-action 'none'; " +
+					"frame-ancestors 'none'",
+				headkey.CrossOriginEmbedderPolicy:     sechead.CrossOriginEmbedderPolicyRequireCORP,
+				headkey.CrossOriginResourcePolicy:     sechead.CrossOriginResourcePolicySameOrigin,
+				headkey.ReferrerPolicy:                sechead.ReferrerPolicyNoReferrer,
+				headkey.StrictTransportSecurity:       "max-age=63072000; includeSubDomains",
+				headkey.XContentTypeOptions:           "nosniff",
+				headkey.XFrameOptions:                 sechead.FrameOptionsDeny,
+				headkey.XPermittedCrossDomainPolicies: sechead.PermittedCrossDomainPoliciesNone,
+				headkey.OriginAgentCluster:            "",
+			},
+		},
+		{
+			Name: "strict HTML application",
+			Conf: sechead.SecHeadersStrictHTMLApp,
+			ExpectedHeaders: map[string]string{
+				headkey.ContentSecurityPolicy: "default-src 'none'; connect-src 'self'; font-src 'self'; " +
+					"img-src 'self' data:; manifest-src 'self'; media-src 'self'; script-src 'self'; " +
+					"style-src 'self'; worker-src 'self'; base-uri 'none'; form-action 'self'; " +
+					"frame-ancestors 'none'; upgrade-insecure-requests",
+				headkey.CrossOriginEmbedderPolicy: sechead.CrossOriginEmbedderPolicyCredentialLess,
+				headkey.ReferrerPolicy:            sechead.ReferrerPolicyStrictOriginWhenCrossOrigin,
+				headkey.StrictTransportSecurity:   "max-age=63072000; includeSubDomains",
+				headkey.OriginAgentCluster:        "?1",
+			},
+		},
+		{
+			Name: "relaxed development",
+			Conf: sechead.SecHeadersRelaxedDev,
+			ExpectedHeaders: map[string]string{
+				headkey.ContentSecurityPolicy: "default-src 'self'; connect-src 'self' ws: wss:; " +
+					"font-src 'self' https: data:; img-src 'self' https: data: blob:; script-src 'self'; " +
+					"style-src 'self'; base-uri 'self'; form-action 'self'; frame-ancestors 'self'",
+				headkey.CrossOriginResourcePolicy:     sechead.CrossOriginResourcePolicySameSite,
+				headkey.StrictTransportSecurity:       "",
+				headkey.PermissionsPolicy:             "",
+				headkey.XFrameOptions:                 sechead.FrameOptionsSameOrigin,
+				headkey.XPermittedCrossDomainPolicies: "",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			t.Parallel()
+
+			err := tt.Conf.Validate()
+			if err != nil {
+				t.Errorf("expected preset to be valid but was %v", err)
+			}
+
+			head := tt.Conf.Headers()
+
+			for key, expected := range tt.ExpectedHeaders {
+				if value := head.Get(key); value != expected {
+					t.Errorf("expected %s %q but was %q", key, expected, value)
+				}
+			}
+		})
+	}
+
+	// Strict presets disable all powerful features
+	policy := sechead.SecHeadersStrictAPI.Headers().Get(headkey.PermissionsPolicy)
+	for _, feature := range sechead.PermissionsPolicyPowerfulFeatures {
+		if !strings.Contains(policy, feature+"=()") {
+			t.Errorf("expected %s to be disabled in %q", feature, policy)
+		}
+	}
+}
+
+func TestHeaders(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Name            string
+		Conf            sechead.SecurityHeadersConfig
+		ExpectedHeaders http.Header
+	}{
+		{
+			Name: "HSTS preload",
+			Conf: sechead.SecurityHeadersConfig{
+				StrictTransportSecurity: sechead.StrictTransportSecurity{
+					MaxAge:            365 * 24 * time.Hour,
+					IncludeSubDomains: true,
+					Preload:           true,
+				},
+			},
+			ExpectedHeaders: http.Header{
+				headkey.StrictTransportSecurity: {"max-age=31536000; includeSubDomains; preload"},
+			},
+		},
+		{
+			Name: "HSTS disabled",
+			Conf: sechead.SecurityHeadersConfig{
+				StrictTransportSecurity: sechead.StrictTransportSecurity{IncludeSubDomains: true},
+			},
+			ExpectedHeaders: http.Header{},
+		},
+		{
+			Name: "Permissions-Policy allowlists",
+			Conf: sechead.SecurityHeadersConfig{
+				PermissionsPolicy: sechead.PermissionsPolicy{
+					Directives: []sechead.PermissionsPolicyDirective{
+						{Feature: "camera"},
+						{
+							Feature: "geolocation",
+							Allowlist: []string{
+								sechead.PermissionsPolicyAllowSelf,
+								"https://maps.example.com",
+							},
+						},
+						{
+							Feature: "fullscreen",
+							Allowlist: []string{
+								sechead.PermissionsPolicyAllowSrc,
+								sechead.PermissionsPolicyAllowAll,
+							},
+						},
+					},
+				},
+			},
+			ExpectedHeaders: http.Header{
+				headkey.PermissionsPolicy: {`camera=(), geolocation=(self "https://maps.example.com"), fullscreen=*`},
+			},
+		},
+		{
+			Name: "Integrity-Policy",
+			Conf: sechead.SecurityHeadersConfig{
+				IntegrityPolicy: sechead.IntegrityPolicy{
+					BlockedDestinations: []string{sechead.IntegrityPolicyDestinationScript},
+					Sources:             []string{sechead.IntegrityPolicySourceInline},
+					Endpoints:           []string{"integrity"},
+				},
+			},
+			ExpectedHeaders: http.Header{
+				headkey.IntegrityPolicy: {"blocked-destinations=(script), sources=(inline), endpoints=(integrity)"},
+			},
+		},
+		{
+			Name: "other options",
+			Conf: sechead.SecurityHeadersConfig{
+				OtherOptions: sechead.OtherOptions{
+					ContentTypeOptionsNoSniff:    true,
+					PermittedCrossDomainPolicies: sechead.PermittedCrossDomainPoliciesMasterOnly,
+					ClearSiteData:                []string{sechead.ClearSiteDataCache, sechead.ClearSiteDataCookies},
+					OriginAgentCluster:           true,
+				},
+			},
+			ExpectedHeaders: http.Header{
+				headkey.XContentTypeOptions:           {"nosniff"},
+				headkey.XPermittedCrossDomainPolicies: {sechead.PermittedCrossDomainPoliciesMasterOnly},
+				headkey.ClearSiteData:                 {`"cache", "cookies"`},
+				headkey.OriginAgentCluster:            {"?1"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			t.Parallel()
+
+			head := tt.Conf.Headers()
+
+			if len(head) != len(tt.ExpectedHeaders) {
+				t.Errorf("expected headers %v but was %v", tt.ExpectedHeaders, head)
+			}
+
+			for key := range tt.ExpectedHeaders {
+				if head.Get(key) != tt.ExpectedHeaders.Get(key) {
+					t.Errorf("expected %s %q but was %q", key, tt.ExpectedHeaders.Get(key), head.Get(key))
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,227 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package sechead
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"mime"
+	"net/http"
+
+	"github.com/kemadev/go-framework/pkg/convenience/headkey"
+	"github.com/kemadev/go-framework/pkg/convenience/headval"
+	"github.com/kemadev/go-framework/pkg/convenience/log"
+)
+
+// maxReportSize bounds violation reports body size, browsers batching a few reports at most.
+const maxReportSize = 64 << 10
+
+// maxReportViolations bounds violations logged per request, so that clients can't flood logs.
+const maxReportViolations = 10
+
+// cspViolationReportType is the type of CSP violation reports sent using Reporting API.
+const cspViolationReportType = "csp-violation"
+
+// legacyReport is a violation report sent to report-uri endpoints, see
+// https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Content-Security-Policy/report-uri
+type legacyReport struct {
+	CSPReport struct {
+		DocumentURI        string `json:"document-uri"`
+		Referrer           string `json:"referrer"`
+		BlockedURI         string `json:"blocked-uri"`
+		EffectiveDirective string `json:"effective-directive"`
+		ViolatedDirective  string `json:"violated-directive"`
+		OriginalPolicy     string `json:"original-policy"`
+		Disposition        string `json:"disposition"`
+		StatusCode         int    `json:"status-code"`
+		ScriptSample       string `json:"script-sample"`
+		SourceFile         string `json:"source-file"`
+		LineNumber         int    `json:"line-number"`
+		ColumnNumber       int    `json:"column-number"`
+	} `json:"csp-report"`
+}
+
+// report is a report sent to report-to endpoints using Reporting API, see
+// https://developer.mozilla.org/en-US/docs/Web/API/CSPViolationReportBody
+type report struct {
+	Type      string `json:"type"`
+	URL       string `json:"url"`
+	UserAgent string `json:"user_agent"`
+	Body      struct {
+		DocumentURL        string `json:"documentURL"`
+		Referrer           string `json:"referrer"`
+		BlockedURL         string `json:"blockedURL"`
+		EffectiveDirective string `json:"effectiveDirective"`
+		OriginalPolicy     string `json:"originalPolicy"`
+		Disposition        string `json:"disposition"`
+		StatusCode         int    `json:"statusCode"`
+		Sample             string `json:"sample"`
+		SourceFile         string `json:"sourceFile"`
+		LineNumber         int    `json:"lineNumber"`
+		ColumnNumber       int    `json:"columnNumber"`
+	} `json:"body"`
+}
+
+// violation is a CSP violation, whichever the format it was reported in.
+type violation struct {
+	documentURL        string
+	referrer           string
+	blockedURL         string
+	effectiveDirective string
+	originalPolicy     string
+	disposition        string
+	statusCode         int
+	sample             string
+	sourceFile         string
+	lineNumber         int
+	columnNumber       int
+}
+
+// ReportHandler returns a handler receiving CSP violation reports, to be used as report-uri and report-to
+// endpoint, see [ContentSecurityPolicyReportingDirectives] and [Reporting]. Both legacy application/csp-report
+// and Reporting API application/reports+json formats are accepted, and each violation is logged as a warning,
+// up to 10 per request, others being counted. As browsers send reports without credentials, the endpoint should
+// not be protected by CORF checks, and should be rate limited instead, see
+// [github.com/kemadev/go-framework/pkg/ratelimit].
+func ReportHandler() http.HandlerFunc {
+	logger := log.Logger(packageName)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		mediaType, _, err := mime.ParseMediaType(r.Header.Get(headkey.ContentType))
+		if err != nil {
+			http.Error(
+				w,
+				http.StatusText(http.StatusUnsupportedMediaType),
+				http.StatusUnsupportedMediaType,
+			)
+
+			return
+		}
+
+		r.Body = http.MaxBytesReader(w, r.Body, maxReportSize)
+		decoder := json.NewDecoder(r.Body)
+
+		var (
+			violations []violation
+			dropped    int
+		)
+
+		switch mediaType {
+		case headval.MIMEApplicationCSPReport:
+			var rep legacyReport
+
+			err = decoder.Decode(&rep)
+			if err == nil {
+				violations = append(violations, violation{
+					documentURL:        rep.CSPReport.DocumentURI,
+					referrer:           rep.CSPReport.Referrer,
+					blockedURL:         rep.CSPReport.BlockedURI,
+					effectiveDirective: effectiveDirective(rep),
+					originalPolicy:     rep.CSPReport.OriginalPolicy,
+					disposition:        rep.CSPReport.Disposition,
+					statusCode:         rep.CSPReport.StatusCode,
+					sample:             rep.CSPReport.ScriptSample,
+					sourceFile:         rep.CSPReport.SourceFile,
+					lineNumber:         rep.CSPReport.LineNumber,
+					columnNumber:       rep.CSPReport.ColumnNumber,
+				})
+			}
+		case headval.MIMEApplicationReportsJSON:
+			var reps []report
+
+			err = decoder.Decode(&reps)
+			if err == nil {
+				for _, rep := range reps {
+					// Endpoint may be shared with other report types
+					if rep.Type != cspViolationReportType {
+						continue
+					}
+
+					if len(violations) == maxReportViolations {
+						dropped++
+
+						continue
+					}
+
+					violations = append(violations, violation{
+						documentURL:        rep.Body.DocumentURL,
+						referrer:           rep.Body.Referrer,
+						blockedURL:         rep.Body.BlockedURL,
+						effectiveDirective: rep.Body.EffectiveDirective,
+						originalPolicy:     rep.Body.OriginalPolicy,
+						disposition:        rep.Body.Disposition,
+						statusCode:         rep.Body.StatusCode,
+						sample:             rep.Body.Sample,
+						sourceFile:         rep.Body.SourceFile,
+						lineNumber:         rep.Body.LineNumber,
+						columnNumber:       rep.Body.ColumnNumber,
+					})
+				}
+			}
+		default:
+			http.Error(
+				w,
+				http.StatusText(http.StatusUnsupportedMediaType),
+				http.StatusUnsupportedMediaType,
+			)
+
+			return
+		}
+
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				http.Error(
+					w,
+					http.StatusText(http.StatusRequestEntityTooLarge),
+					http.StatusRequestEntityTooLarge,
+				)
+
+				return
+			}
+
+			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+
+			return
+		}
+
+		for _, v := range violations {
+			logger.WarnContext(
+				r.Context(),
+				"content security policy violation",
+				slog.String("csp.document_url", v.documentURL),
+				slog.String("csp.referrer", v.referrer),
+				slog.String("csp.blocked_url", v.blockedURL),
+				slog.String("csp.effective_directive", v.effectiveDirective),
+				slog.String("csp.original_policy", v.originalPolicy),
+				slog.String("csp.disposition", v.disposition),
+				slog.Int("csp.status_code", v.statusCode),
+				slog.String("csp.sample", v.sample),
+				slog.String("csp.source_file", v.sourceFile),
+				slog.Int("csp.line_number", v.lineNumber),
+				slog.Int("csp.column_number", v.columnNumber),
+			)
+		}
+
+		if dropped > 0 {
+			logger.WarnContext(
+				r.Context(),
+				"content security policy violations not logged",
+				slog.Int("csp.violations.dropped", dropped),
+			)
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// effectiveDirective returns the directive that was violated, older browsers only sending violated-directive.
+func effectiveDirective(rep legacyReport) string {
+	if rep.CSPReport.EffectiveDirective != "" {
+		return rep.CSPReport.EffectiveDirective
+	}
+
+	return rep.CSPReport.ViolatedDirective
+}
@@ -0,0 +1,182 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package sechead_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/kemadev/go-framework/pkg/convenience/headkey"
+	"github.com/kemadev/go-framework/pkg/convenience/headval"
+	"github.com/kemadev/go-framework/pkg/convenience/sechead"
+	"go.opentelemetry.io/otel/log"
+	"go.opentelemetry.io/otel/log/global"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+)
+
+func TestReportHandler(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Name           string
+		ContentType    string
+		Body           string
+		ExpectedStatus int
+	}{
+		{
+			Name:        "legacy report",
+			ContentType: headval.MIMEApplicationCSPReport,
+			Body: `{"csp-report":{"document-uri":"https://example.com/","blocked-uri":"inline",` +
+				`"effective-directive":"script-src-elem","disposition":"enforce","line-number":3}}`,
+			ExpectedStatus: http.StatusNoContent,
+		},
+		{
+			Name:           "legacy report with violated directive only",
+			ContentType:    headval.MIMEApplicationCSPReport,
+			Body:           `{"csp-report":{"document-uri":"https://example.com/","violated-directive":"img-src"}}`,
+			ExpectedStatus: http.StatusNoContent,
+		},
+		{
+			Name:        "reporting API reports",
+			ContentType: headval.MIMEApplicationReportsJSON,
+			Body: `[{"type":"csp-violation","url":"https://example.com/","body":{"blockedURL":"eval",` +
+				`"effectiveDirective":"script-src","disposition":"report"}},` +
+				`{"type":"deprecation","url":"https://example.com/","body":{"id":"x"}}]`,
+			ExpectedStatus: http.StatusNoContent,
+		},
+		{
+			Name:           "content type parameters",
+			ContentType:    headval.MIMEApplicationReportsJSON + "; charset=utf-8",
+			Body:           `[]`,
+			ExpectedStatus: http.StatusNoContent,
+		},
+		{
+			Name:           "unknown content type",
+			ContentType:    headval.MIMEApplicationJSON,
+			Body:           `{"csp-report":{}}`,
+			ExpectedStatus: http.StatusUnsupportedMediaType,
+		},
+		{
+			Name:           "missing content type",
+			Body:           `{"csp-report":{}}`,
+			ExpectedStatus: http.StatusUnsupportedMediaType,
+		},
+		{
+			Name:           "invalid JSON",
+			ContentType:    headval.MIMEApplicationCSPReport,
+			Body:           `{"csp-report":`,
+			ExpectedStatus: http.StatusBadRequest,
+		},
+		{
+			Name:           "unexpected shape",
+			ContentType:    headval.MIMEApplicationReportsJSON,
+			Body:           `{"type":"csp-violation"}`,
+			ExpectedStatus: http.StatusBadRequest,
+		},
+		{
+			Name:        "body too large",
+			ContentType: headval.MIMEApplicationCSPReport,
+			Body: `{"csp-report":{"document-uri":"https://example.com/","script-sample":"` +
+				strings.Repeat("a", 64<<10) + `"}}`,
+			ExpectedStatus: http.StatusRequestEntityTooLarge,
+		},
+	}
+
+	handler := sechead.ReportHandler()
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			t.Parallel()
+
+			r := httptest.NewRequest(http.MethodPost, "/csp-report", strings.NewReader(tt.Body))
+			if tt.ContentType != "" {
+				r.Header.Set(headkey.ContentType, tt.ContentType)
+			}
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, r)
+
+			if rr.Code != tt.ExpectedStatus {
+				t.Errorf("expected status %d but was %d", tt.ExpectedStatus, rr.Code)
+			}
+		})
+	}
+}
+
+// recordProcessor records log records emitted for a given document URL.
+type recordProcessor struct {
+	documentURL string
+	mu          sync.Mutex
+	violations  int
+	dropped     int64
+}
+
+func (p *recordProcessor) OnEmit(_ context.Context, record *sdklog.Record) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	record.WalkAttributes(func(kv log.KeyValue) bool {
+		switch {
+		case kv.Key == "csp.document_url" && kv.Value.AsString() == p.documentURL:
+			p.violations++
+		case kv.Key == "csp.violations.dropped":
+			p.dropped = kv.Value.AsInt64()
+		}
+
+		return true
+	})
+
+	return nil
+}
+
+func (p *recordProcessor) Shutdown(context.Context) error {
+	return nil
+}
+
+func (p *recordProcessor) ForceFlush(context.Context) error {
+	return nil
+}
+
+// TestReportHandlerFlood is not parallel, as it sets global logger provider.
+func TestReportHandlerFlood(t *testing.T) {
+	processor := &recordProcessor{documentURL: "https://flood.example.com/"}
+	global.SetLoggerProvider(sdklog.NewLoggerProvider(sdklog.WithProcessor(processor)))
+
+	reports := make([]string, 0, 50)
+	for range 50 {
+		reports = append(
+			reports,
+			`{"type":"csp-violation","body":{"documentURL":"https://flood.example.com/","blockedURL":"eval"}}`,
+		)
+	}
+
+	r := httptest.NewRequest(
+		http.MethodPost,
+		"/csp-report",
+		strings.NewReader("["+strings.Join(reports, ",")+"]"),
+	)
+	r.Header.Set(headkey.ContentType, headval.MIMEApplicationReportsJSON)
+
+	rr := httptest.NewRecorder()
+	sechead.ReportHandler().ServeHTTP(rr, r)
+
+	if rr.Code != http.StatusNoContent {
+		t.Errorf("expected status %d but was %d", http.StatusNoContent, rr.Code)
+	}
+
+	processor.mu.Lock()
+	defer processor.mu.Unlock()
+
+	if processor.violations != 10 {
+		t.Errorf("expected 10 violations to be logged but was %d", processor.violations)
+	}
+
+	if processor.dropped != 40 {
+		t.Errorf("expected 40 violations to be dropped but was %d", processor.dropped)
+	}
+}
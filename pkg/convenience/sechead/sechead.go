@@ -7,21 +7,42 @@ import (
 	"log/slog"
 	"net/http"
 
+	"github.com/kemadev/go-framework/pkg/convenience/headkey"
 	"github.com/kemadev/go-framework/pkg/convenience/log"
 )
 
 const packageName = "github.com/kemadev/go-framework/pkg/convenience/sechead"
 
-// NewMiddleware returns a middleware adding security headers.
+// NewMiddleware returns a middleware adding security headers. When nonces are enabled in Content-Security-Policy,
+// a nonce is generated for each request, and made available to handlers through [Nonce].
 func NewMiddleware(conf SecurityHeadersConfig) func(http.Handler) http.Handler {
+	headers := conf.Headers()
+
+	for key, val := range headers {
+		if len(val) != 1 {
+			log.GetPackageLogger(packageName).Error("multiple values found in header", slog.String("header", key))
+		}
+	}
+
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			for key, val := range conf.Headers() {
-				if len(val) != 1 {
-					log.GetPackageLogger(packageName).Error("multiple values found in header", slog.String("header", key))
+			for key, val := range headers {
+				w.Header().Add(key, val[0])
+			}
+
+			reportOnly := conf.ContentSecurityPolicyReportOnly
+
+			if conf.ContentSecurityPolicy.Nonce || (reportOnly != nil && reportOnly.Nonce) {
+				c, nonce := newNonceContext(r.Context())
+				r = r.WithContext(c)
+
+				if conf.ContentSecurityPolicy.Nonce {
+					w.Header().Set(headkey.ContentSecurityPolicy, conf.ContentSecurityPolicy.buildCSPHeader(nonce))
 				}
 
-				w.Header().Add(key, val[0])
+				if reportOnly != nil && reportOnly.Nonce {
+					w.Header().Set(headkey.ContentSecurityPolicyReportOnly, reportOnly.buildCSPHeader(nonce))
+				}
 			}
 
 			next.ServeHTTP(w, r)
@@ -0,0 +1,231 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package sechead_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kemadev/go-framework/pkg/convenience/headkey"
+	"github.com/kemadev/go-framework/pkg/convenience/sechead"
+)
+
+func TestContentSecurityPolicy(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Name     string
+		Policy   sechead.ContentSecurityPolicy
+		Expected string
+	}{
+		{
+			Name: "source lists",
+			Policy: sechead.ContentSecurityPolicy{
+				FetchDirectives: sechead.ContentSecurityPolicyFetchDirectives{
+					DefaultSource: []string{sechead.SourceSelf},
+					ImageSource:   []string{sechead.SourceSelf, sechead.SourceData, "https://cdn.example.com"},
+					ScriptSource: []string{
+						sechead.SourceSelf,
+						sechead.SourceHash("sha256", "abc="),
+						sechead.SourceStrictDynamic,
+					},
+				},
+			},
+			Expected: "default-src 'self'; img-src 'self' data: https://cdn.example.com; " +
+				"script-src 'self' 'sha256-abc=' 'strict-dynamic'",
+		},
+		{
+			Name: "strict sandbox",
+			Policy: sechead.ContentSecurityPolicy{
+				FetchDirectives: sechead.ContentSecurityPolicyFetchDirectives{
+					DefaultSource: []string{sechead.SourceNone},
+				},
+				DocumentDirectives: sechead.ContentSecurityPolicyDocumentDirectives{
+					Sandbox: sechead.ContentSecurityPolicySandboxStrict,
+				},
+			},
+			Expected: "default-src 'none'; sandbox",
+		},
+		{
+			Name: "document, navigation, other and reporting directives",
+			Policy: sechead.ContentSecurityPolicy{
+				DocumentDirectives: sechead.ContentSecurityPolicyDocumentDirectives{
+					BaseURI: []string{sechead.SourceSelf},
+					Sandbox: sechead.ContentSecurityPolicySandboxAllowScripts,
+				},
+				NavigationDirectives: sechead.ContentSecurityPolicyNavigationDirectives{
+					FormAction:     []string{sechead.SourceSelf, "https://pay.example.com"},
+					FrameAncestors: []string{sechead.SourceNone},
+				},
+				OtherDirectives: sechead.ContentSecurityPolicyOtherDirectives{
+					UpgradeInsecureRequests: true,
+					RequireTrustedTypesFor:  []string{sechead.RequireTrustedTypesForScript},
+					TrustedTypes:            []string{"default", sechead.TrustedTypesAllowDuplicates},
+				},
+				ReportingDirectives: sechead.ContentSecurityPolicyReportingDirectives{
+					ReportTo:  "csp",
+					ReportURI: []string{"/csp-report"},
+				},
+			},
+			Expected: "base-uri 'self'; sandbox allow-scripts; form-action 'self' https://pay.example.com; " +
+				"frame-ancestors 'none'; upgrade-insecure-requests; require-trusted-types-for 'script'; " +
+				"trusted-types default 'allow-duplicates'; report-to csp; report-uri /csp-report",
+		},
+		{
+			Name:     "empty",
+			Expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			t.Parallel()
+
+			conf := sechead.SecurityHeadersConfig{ContentSecurityPolicy: tt.Policy}
+
+			csp := conf.Headers().Get(headkey.ContentSecurityPolicy)
+			if csp != tt.Expected {
+				t.Errorf("expected policy %q but was %q", tt.Expected, csp)
+			}
+		})
+	}
+}
+
+func TestMiddlewareNonce(t *testing.T) {
+	t.Parallel()
+
+	mw := sechead.NewMiddleware(sechead.SecurityHeadersConfig{
+		ContentSecurityPolicy: sechead.ContentSecurityPolicy{
+			Nonce: true,
+			FetchDirectives: sechead.ContentSecurityPolicyFetchDirectives{
+				DefaultSource:    []string{sechead.SourceNone},
+				StyleSource:      []string{sechead.SourceSelf},
+				ScriptSourceElem: []string{sechead.SourceSelf},
+			},
+		},
+	})
+
+	var nonce string
+
+	handler := mw(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		nonce = sechead.Nonce(r.Context())
+	}))
+
+	seen := make(map[string]bool)
+
+	for range 3 {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		if nonce == "" || seen[nonce] {
+			t.Fatalf("expected a new nonce for each request but was %q", nonce)
+		}
+
+		seen[nonce] = true
+
+		source := sechead.SourceNonce(nonce)
+
+		// Unset script-src inherits default-src, 'none' being dropped as nonce is allowed
+		expected := "default-src 'none'; script-src " + source + "; script-src-elem 'self' " + source +
+			"; style-src 'self' " + source
+
+		csp := rr.Header().Get(headkey.ContentSecurityPolicy)
+		if csp != expected {
+			t.Errorf("expected policy %q but was %q", expected, csp)
+		}
+
+		if len(rr.Header().Values(headkey.ContentSecurityPolicy)) != 1 {
+			t.Errorf("expected a single policy but was %v", rr.Header().Values(headkey.ContentSecurityPolicy))
+		}
+	}
+
+	// Nonces are not generated unless enabled
+	handler = sechead.NewMiddleware(sechead.SecHeadersDefaultStrict)(
+		http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+			nonce = sechead.Nonce(r.Context())
+		}),
+	)
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if nonce != "" || strings.Contains(rr.Header().Get(headkey.ContentSecurityPolicy), "nonce") {
+		t.Errorf("expected no nonce but was %q", nonce)
+	}
+}
+
+func TestReportOnly(t *testing.T) {
+	t.Parallel()
+
+	conf := sechead.SecurityHeadersConfig{
+		ContentSecurityPolicy: sechead.ContentSecurityPolicy{
+			FetchDirectives: sechead.ContentSecurityPolicyFetchDirectives{
+				DefaultSource: []string{sechead.SourceSelf},
+			},
+			ReportingDirectives: sechead.ContentSecurityPolicyReportingDirectives{
+				ReportTo: "csp",
+			},
+		},
+		ContentSecurityPolicyReportOnly: &sechead.ContentSecurityPolicy{
+			Nonce: true,
+			FetchDirectives: sechead.ContentSecurityPolicyFetchDirectives{
+				DefaultSource: []string{sechead.SourceNone},
+			},
+			ReportingDirectives: sechead.ContentSecurityPolicyReportingDirectives{
+				ReportTo: "csp",
+			},
+		},
+		Reporting: sechead.Reporting{
+			Endpoints: map[string]string{
+				"csp":     "https://example.com/csp-report",
+				"default": "https://example.com/reports",
+			},
+		},
+	}
+
+	head := conf.Headers()
+
+	expectedEndpoints := `csp="https://example.com/csp-report", default="https://example.com/reports"`
+	if endpoints := head.Get(headkey.ReportingEndpoints); endpoints != expectedEndpoints {
+		t.Errorf("expected endpoints %q but was %q", expectedEndpoints, endpoints)
+	}
+
+	expectedCSP := "default-src 'self'; report-to csp"
+	if csp := head.Get(headkey.ContentSecurityPolicy); csp != expectedCSP {
+		t.Errorf("expected policy %q but was %q", expectedCSP, csp)
+	}
+
+	// Nonces are only added per request
+	expectedReportOnly := "default-src 'none'; report-to csp"
+	if csp := head.Get(headkey.ContentSecurityPolicyReportOnly); csp != expectedReportOnly {
+		t.Errorf("expected report-only policy %q but was %q", expectedReportOnly, csp)
+	}
+
+	var nonce string
+
+	handler := sechead.NewMiddleware(conf)(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		nonce = sechead.Nonce(r.Context())
+	}))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if nonce == "" {
+		t.Fatal("expected a nonce for report-only policy")
+	}
+
+	source := sechead.SourceNonce(nonce)
+
+	// Enforced policy doesn't allow nonce
+	if csp := rr.Header().Get(headkey.ContentSecurityPolicy); csp != expectedCSP {
+		t.Errorf("expected policy %q but was %q", expectedCSP, csp)
+	}
+
+	expectedReportOnly = "default-src 'none'; script-src " + source + "; style-src " + source + "; report-to csp"
+	if csp := rr.Header().Get(headkey.ContentSecurityPolicyReportOnly); csp != expectedReportOnly {
+		t.Errorf("expected report-only policy %q but was %q", expectedReportOnly, csp)
+	}
+}
@@ -0,0 +1,178 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package sechead
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/kemadev/go-framework/pkg/convenience/headkey"
+)
+
+var (
+	ErrContradictoryHeaders = errors.New("contradictory security headers")
+	ErrInsecureHeaders      = errors.New("insecure security headers")
+)
+
+// directive is a Content-Security-Policy directive along with its sources.
+type directive struct {
+	name    string
+	sources []string
+}
+
+// Validate reports contradictory combinations, that browsers reject or that defeat each other, as errors
+// wrapping [ErrContradictoryHeaders], and insecure combinations as errors wrapping [ErrInsecureHeaders]. All
+// problems found are joined, nil being returned for a sound configuration.
+func (conf *SecurityHeadersConfig) Validate() error {
+	var errs []error
+
+	contradictory := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf("%w: "+format, append([]any{ErrContradictoryHeaders}, args...)...))
+	}
+
+	insecure := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf("%w: "+format, append([]any{ErrInsecureHeaders}, args...)...))
+	}
+
+	if conf.AccessControl.AccessControlAllowCredentials {
+		switch conf.AccessControl.AccessControlAllowOrigin.String() {
+		case "*":
+			contradictory("credentials can't be allowed for any origin")
+		case "null":
+			insecure("allowing credentials for null origin allows sandboxed documents of any origin")
+		}
+	}
+
+	hsts := conf.StrictTransportSecurity
+	if hsts.MaxAge <= 0 && (hsts.IncludeSubDomains || hsts.Preload) {
+		contradictory("HSTS options are set but header is disabled by max-age")
+	}
+
+	if hsts.Preload && (!hsts.IncludeSubDomains || hsts.MaxAge < hstsPreloadMinMaxAge) {
+		contradictory("HSTS preload requires includeSubDomains and a max-age of at least one year")
+	}
+
+	policies := map[string]*ContentSecurityPolicy{
+		headkey.ContentSecurityPolicy:           &conf.ContentSecurityPolicy,
+		headkey.ContentSecurityPolicyReportOnly: conf.ContentSecurityPolicyReportOnly,
+	}
+
+	for _, header := range slices.Sorted(maps.Keys(policies)) {
+		policy := policies[header]
+		if policy == nil {
+			continue
+		}
+
+		for _, d := range policy.directives() {
+			if len(d.sources) > 1 && slices.Contains(d.sources, SourceNone) {
+				contradictory("%s %s: 'none' must be the only source", header, d.name)
+			}
+
+			if !strings.HasPrefix(d.name, "script-src") {
+				continue
+			}
+
+			for _, source := range d.sources {
+				switch source {
+				case SourceUnsafeEval:
+					insecure("%s %s: 'unsafe-eval' allows executing strings as code", header, d.name)
+				case SourceUnsafeInline:
+					// Browsers ignore 'unsafe-inline' when a nonce or a hash is present
+					if !policy.Nonce && !slices.ContainsFunc(d.sources, isHashSource) {
+						insecure("%s %s: 'unsafe-inline' allows injected scripts", header, d.name)
+					}
+				case "*", SourceHTTPS, "http:", SourceData:
+					insecure("%s %s: %s allows scripts from any origin", header, d.name, source)
+				}
+			}
+		}
+
+		sandbox := strings.Fields(policy.DocumentDirectives.Sandbox)
+		if slices.Contains(sandbox, ContentSecurityPolicySandboxAllowScripts) &&
+			slices.Contains(sandbox, ContentSecurityPolicySandboxAllowSameOrigin) {
+			insecure("%s sandbox: allowing both scripts and same origin lets documents remove sandbox", header)
+		}
+
+		if conf.OtherOptions.FrameOptions == FrameOptionsDeny &&
+			slices.ContainsFunc(policy.NavigationDirectives.FrameAncestors, func(source string) bool {
+				return source != SourceNone
+			}) {
+			contradictory("%s frame-ancestors allows framing that X-Frame-Options denies", header)
+		}
+
+		reportTo := policy.ReportingDirectives.ReportTo
+		if _, ok := conf.Reporting.Endpoints[reportTo]; reportTo != "" && !ok {
+			contradictory("%s report-to: endpoint %s is not defined", header, reportTo)
+		}
+	}
+
+	for _, endpoint := range conf.IntegrityPolicy.Endpoints {
+		if _, ok := conf.Reporting.Endpoints[endpoint]; !ok {
+			contradictory("Integrity-Policy: endpoint %s is not defined", endpoint)
+		}
+	}
+
+	if len(conf.IntegrityPolicy.BlockedDestinations) == 0 &&
+		(len(conf.IntegrityPolicy.Sources) > 0 || len(conf.IntegrityPolicy.Endpoints) > 0) {
+		contradictory("Integrity-Policy options are set but header is disabled by empty blocked destinations")
+	}
+
+	for _, d := range conf.PermissionsPolicy.Directives {
+		if slices.Contains(d.Allowlist, PermissionsPolicyAllowAll) &&
+			slices.Contains(PermissionsPolicyPowerfulFeatures, d.Feature) {
+			insecure("Permissions-Policy %s: powerful feature is allowed for any origin", d.Feature)
+		}
+	}
+
+	switch conf.ReferrerPolicy.ReferrerPolicy {
+	case ReferrerPolicyUnsafeURL, ReferrerPolicyNoReferrerWhenDowngrade:
+		insecure("Referrer-Policy %s leaks full URLs to other origins", conf.ReferrerPolicy.ReferrerPolicy)
+	}
+
+	if conf.OtherOptions.PermittedCrossDomainPolicies == PermittedCrossDomainPoliciesAll {
+		insecure("X-Permitted-Cross-Domain-Policies all lets any policy file grant cross-domain access")
+	}
+
+	if conf.CrossOriginPolicy.CrossOriginResourcePolicy == CrossOriginResourcePolicyCrossOrigin &&
+		conf.AccessControl.AccessControlAllowCredentials {
+		insecure("Cross-Origin-Resource-Policy cross-origin exposes credentialed responses to any origin")
+	}
+
+	return errors.Join(errs...)
+}
+
+// directives returns fetch, document and navigation directives holding sources.
+func (conf *ContentSecurityPolicy) directives() []directive {
+	return []directive{
+		{"default-src", conf.FetchDirectives.DefaultSource},
+		{"child-src", conf.FetchDirectives.ChildSource},
+		{"connect-src", conf.FetchDirectives.ConnectSource},
+		{"font-src", conf.FetchDirectives.FontSource},
+		{"frame-src", conf.FetchDirectives.FrameSource},
+		{"img-src", conf.FetchDirectives.ImageSource},
+		{"manifest-src", conf.FetchDirectives.ManifestSource},
+		{"media-src", conf.FetchDirectives.MediaSource},
+		{"object-src", conf.FetchDirectives.ObjectSource},
+		{"script-src", conf.FetchDirectives.ScriptSource},
+		{"script-src-attr", conf.FetchDirectives.ScriptSourceAttr},
+		{"script-src-elem", conf.FetchDirectives.ScriptSourceElem},
+		{"style-src", conf.FetchDirectives.StyleSource},
+		{"style-src-attr", conf.FetchDirectives.StyleSourceAttr},
+		{"style-src-elem", conf.FetchDirectives.StyleSourceElem},
+		{"worker-src", conf.FetchDirectives.WorkerSource},
+		{"base-uri", conf.DocumentDirectives.BaseURI},
+		{"form-action", conf.NavigationDirectives.FormAction},
+		{"frame-ancestors", conf.NavigationDirectives.FrameAncestors},
+	}
+}
+
+// isHashSource reports whether [source] is a hash source expression, see [SourceHash].
+func isHashSource(source string) bool {
+	return strings.HasPrefix(source, "'sha256-") ||
+		strings.HasPrefix(source, "'sha384-") ||
+		strings.HasPrefix(source, "'sha512-")
+}
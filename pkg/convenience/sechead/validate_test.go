@@ -0,0 +1,241 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package sechead_test
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/kemadev/go-framework/pkg/convenience/sechead"
+)
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	year := 365 * 24 * time.Hour
+
+	tests := []struct {
+		Name           string
+		Conf           sechead.SecurityHeadersConfig
+		ExpectedErrors []error
+	}{
+		{
+			Name: "sound",
+			Conf: sechead.SecurityHeadersConfig{
+				ContentSecurityPolicy: sechead.ContentSecurityPolicy{
+					Nonce: true,
+					FetchDirectives: sechead.ContentSecurityPolicyFetchDirectives{
+						DefaultSource: []string{sechead.SourceNone},
+						// Ignored by browsers as nonce is present
+						ScriptSource: []string{sechead.SourceSelf, sechead.SourceUnsafeInline},
+					},
+					NavigationDirectives: sechead.ContentSecurityPolicyNavigationDirectives{
+						FrameAncestors: []string{sechead.SourceNone},
+					},
+					ReportingDirectives: sechead.ContentSecurityPolicyReportingDirectives{
+						ReportTo: "csp",
+					},
+				},
+				Reporting: sechead.Reporting{
+					Endpoints: map[string]string{"csp": "https://example.com/csp-report"},
+				},
+				StrictTransportSecurity: sechead.StrictTransportSecurity{
+					MaxAge:            year,
+					IncludeSubDomains: true,
+					Preload:           true,
+				},
+				OtherOptions: sechead.OtherOptions{
+					FrameOptions: sechead.FrameOptionsDeny,
+				},
+			},
+		},
+		{
+			Name: "credentials for any origin",
+			Conf: sechead.SecurityHeadersConfig{
+				AccessControl: sechead.AccessControl{
+					AccessControlAllowCredentials: true,
+					AccessControlAllowOrigin:      url.URL{Path: "*"},
+				},
+			},
+			ExpectedErrors: []error{sechead.ErrContradictoryHeaders},
+		},
+		{
+			Name: "credentials for null origin",
+			Conf: sechead.SecurityHeadersConfig{
+				AccessControl: sechead.AccessControl{
+					AccessControlAllowCredentials: true,
+					AccessControlAllowOrigin:      url.URL{Path: "null"},
+				},
+			},
+			ExpectedErrors: []error{sechead.ErrInsecureHeaders},
+		},
+		{
+			Name: "HSTS options without max-age",
+			Conf: sechead.SecurityHeadersConfig{
+				StrictTransportSecurity: sechead.StrictTransportSecurity{IncludeSubDomains: true},
+			},
+			ExpectedErrors: []error{sechead.ErrContradictoryHeaders},
+		},
+		{
+			Name: "HSTS preload with short max-age",
+			Conf: sechead.SecurityHeadersConfig{
+				StrictTransportSecurity: sechead.StrictTransportSecurity{
+					MaxAge:            time.Hour,
+					IncludeSubDomains: true,
+					Preload:           true,
+				},
+			},
+			ExpectedErrors: []error{sechead.ErrContradictoryHeaders},
+		},
+		{
+			Name: "'none' with other sources",
+			Conf: sechead.SecurityHeadersConfig{
+				ContentSecurityPolicy: sechead.ContentSecurityPolicy{
+					FetchDirectives: sechead.ContentSecurityPolicyFetchDirectives{
+						ImageSource: []string{sechead.SourceNone, sechead.SourceSelf},
+					},
+				},
+			},
+			ExpectedErrors: []error{sechead.ErrContradictoryHeaders},
+		},
+		{
+			Name: "unsafe script sources",
+			Conf: sechead.SecurityHeadersConfig{
+				ContentSecurityPolicy: sechead.ContentSecurityPolicy{
+					FetchDirectives: sechead.ContentSecurityPolicyFetchDirectives{
+						ScriptSource:     []string{sechead.SourceSelf, sechead.SourceUnsafeEval},
+						ScriptSourceElem: []string{sechead.SourceUnsafeInline, sechead.SourceHTTPS},
+						// Only script directives are checked
+						StyleSource: []string{sechead.SourceUnsafeInline, sechead.SourceData},
+					},
+				},
+			},
+			ExpectedErrors: []error{
+				sechead.ErrInsecureHeaders,
+				sechead.ErrInsecureHeaders,
+				sechead.ErrInsecureHeaders,
+			},
+		},
+		{
+			Name: "'unsafe-inline' with hash",
+			Conf: sechead.SecurityHeadersConfig{
+				ContentSecurityPolicy: sechead.ContentSecurityPolicy{
+					FetchDirectives: sechead.ContentSecurityPolicyFetchDirectives{
+						ScriptSource: []string{sechead.SourceUnsafeInline, sechead.SourceHash("sha384", "abc=")},
+					},
+				},
+			},
+		},
+		{
+			Name: "sandbox escape",
+			Conf: sechead.SecurityHeadersConfig{
+				ContentSecurityPolicy: sechead.ContentSecurityPolicy{
+					DocumentDirectives: sechead.ContentSecurityPolicyDocumentDirectives{
+						Sandbox: sechead.ContentSecurityPolicySandboxAllowScripts + " " +
+							sechead.ContentSecurityPolicySandboxAllowSameOrigin,
+					},
+				},
+			},
+			ExpectedErrors: []error{sechead.ErrInsecureHeaders},
+		},
+		{
+			Name: "frame-ancestors allows framing denied by X-Frame-Options",
+			Conf: sechead.SecurityHeadersConfig{
+				ContentSecurityPolicy: sechead.ContentSecurityPolicy{
+					NavigationDirectives: sechead.ContentSecurityPolicyNavigationDirectives{
+						FrameAncestors: []string{sechead.SourceSelf},
+					},
+				},
+				OtherOptions: sechead.OtherOptions{
+					FrameOptions: sechead.FrameOptionsDeny,
+				},
+			},
+			ExpectedErrors: []error{sechead.ErrContradictoryHeaders},
+		},
+		{
+			Name: "undefined reporting endpoints",
+			Conf: sechead.SecurityHeadersConfig{
+				ContentSecurityPolicyReportOnly: &sechead.ContentSecurityPolicy{
+					ReportingDirectives: sechead.ContentSecurityPolicyReportingDirectives{
+						ReportTo: "csp",
+					},
+				},
+				IntegrityPolicy: sechead.IntegrityPolicy{
+					BlockedDestinations: []string{sechead.IntegrityPolicyDestinationScript},
+					Endpoints:           []string{"integrity"},
+				},
+			},
+			ExpectedErrors: []error{sechead.ErrContradictoryHeaders, sechead.ErrContradictoryHeaders},
+		},
+		{
+			Name: "Integrity-Policy options without blocked destinations",
+			Conf: sechead.SecurityHeadersConfig{
+				IntegrityPolicy: sechead.IntegrityPolicy{
+					Sources: []string{sechead.IntegrityPolicySourceInline},
+				},
+			},
+			ExpectedErrors: []error{sechead.ErrContradictoryHeaders},
+		},
+		{
+			Name: "powerful feature allowed for any origin",
+			Conf: sechead.SecurityHeadersConfig{
+				PermissionsPolicy: sechead.PermissionsPolicy{
+					Directives: []sechead.PermissionsPolicyDirective{
+						{Feature: "camera", Allowlist: []string{sechead.PermissionsPolicyAllowAll}},
+						{Feature: "fullscreen", Allowlist: []string{sechead.PermissionsPolicyAllowAll}},
+					},
+				},
+			},
+			ExpectedErrors: []error{sechead.ErrInsecureHeaders},
+		},
+		{
+			Name: "leaky and permissive options",
+			Conf: sechead.SecurityHeadersConfig{
+				AccessControl: sechead.AccessControl{
+					AccessControlAllowCredentials: true,
+					AccessControlAllowOrigin:      url.URL{Scheme: "https", Host: "example.com"},
+				},
+				CrossOriginPolicy: sechead.CrossOriginPolicy{
+					CrossOriginResourcePolicy: sechead.CrossOriginResourcePolicyCrossOrigin,
+				},
+				ReferrerPolicy: sechead.ReferrerPolicy{
+					ReferrerPolicy: sechead.ReferrerPolicyUnsafeURL,
+				},
+				OtherOptions: sechead.OtherOptions{
+					PermittedCrossDomainPolicies: sechead.PermittedCrossDomainPoliciesAll,
+				},
+			},
+			ExpectedErrors: []error{
+				sechead.ErrInsecureHeaders,
+				sechead.ErrInsecureHeaders,
+				sechead.ErrInsecureHeaders,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			t.Parallel()
+
+			err := tt.Conf.Validate()
+
+			var errs []error
+			if joined, ok := err.(interface{ Unwrap() []error }); ok {
+				errs = joined.Unwrap()
+			}
+
+			if len(errs) != len(tt.ExpectedErrors) {
+				t.Fatalf("expected %d errors but was %d (error %v)", len(tt.ExpectedErrors), len(errs), err)
+			}
+
+			for i, expected := range tt.ExpectedErrors {
+				if !errors.Is(errs[i], expected) {
+					t.Errorf("expected error %v but was %v", expected, errs[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,289 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package cors
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kemadev/go-framework/pkg/convenience/headkey"
+	"github.com/kemadev/go-framework/pkg/convenience/headutil"
+)
+
+const packageName = "github.com/kemadev/go-framework/pkg/cors"
+
+const (
+	// AllowAll allows any origin or header. With credentials, allowed origins and headers are reflected instead,
+	// as browsers reject wildcards for credentialed requests.
+	AllowAll = "*"
+	// DefaultMaxAge is the default duration browsers cache preflight responses for.
+	DefaultMaxAge = 10 * time.Minute
+)
+
+var (
+	ErrInvalidOrigin         = errors.New("invalid allowed origin")
+	ErrInvalidOriginPattern  = errors.New("invalid allowed origin pattern")
+	ErrCredentialsAnyOrigin  = errors.New("credentials can't be allowed for any origin")
+	ErrNoOriginAllowed       = errors.New("no origin allowed")
+	ErrWildcardInOriginHost  = errors.New("wildcard must be a leading label of origin host")
+	ErrCredentialsNullOrigin = errors.New("credentials can't be allowed for null origin")
+)
+
+// Config defines the configuration for CORS middleware.
+type Config struct {
+	// Allowed origins, as scheme://host[:port]. A leading wildcard label matches any subdomain, as in
+	// https://*.example.com, and [AllowAll] matches any origin.
+	AllowedOrigins []string
+	// Regular expressions matched against the whole origin, for origins that can't be listed
+	AllowedOriginPatterns []string
+	// Methods allowed in preflight requests. Defaults to GET, HEAD and POST, which browsers send without
+	// preflight anyway.
+	AllowedMethods []string
+	// Request headers allowed in preflight requests, case-insensitive, [AllowAll] allowing any header.
+	// CORS-safelisted request headers are always allowed by browsers.
+	AllowedHeaders []string
+	// Response headers exposed to scripts, besides CORS-safelisted response headers
+	ExposedHeaders []string
+	// Allow requests with credentials (cookies, authorization headers and TLS client certificates)
+	AllowCredentials bool
+	// Duration browsers cache preflight responses for, negative values disabling caching. Defaults to
+	// [DefaultMaxAge], browsers capping it (e.g. 2 hours for Chromium).
+	MaxAge time.Duration
+}
+
+// policy is a compiled [Config].
+type policy struct {
+	anyOrigin        bool
+	origins          []string
+	patterns         []*regexp.Regexp
+	methods          []string
+	anyHeader        bool
+	headers          []string
+	allowMethods     string
+	exposeHeaders    string
+	allowCredentials bool
+	maxAge           string
+}
+
+// NewMiddleware returns a CORS middleware. Preflight requests, that is OPTIONS requests carrying
+// Access-Control-Request-Method header, are answered with 204 status and never reach next handler, CORS
+// headers being omitted when request is not allowed, so that browsers block it. Other requests from allowed
+// origins are sent CORS headers and passed to next handler, requests from other origins being passed without
+// CORS headers.
+//
+// An error is returned when configuration allows no origin or is insecure, such as credentials for any origin.
+func NewMiddleware(conf Config) (func(http.Handler) http.Handler, error) {
+	p, err := compile(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get(headkey.Origin)
+			preflight := r.Method == http.MethodOptions &&
+				r.Header.Get(headkey.AccessControlRequestMethod) != ""
+
+			// Responses differ by origin, unless any origin is allowed with a wildcard
+			if !p.anyOrigin || p.allowCredentials {
+				headutil.AddVary(w.Header(), headkey.Origin)
+			}
+
+			if preflight {
+				headutil.AddVary(w.Header(), headkey.AccessControlRequestMethod)
+				headutil.AddVary(w.Header(), headkey.AccessControlRequestHeaders)
+
+				p.handlePreflight(w, r, origin)
+
+				return
+			}
+
+			if origin != "" && p.allowOrigin(origin) {
+				p.setOriginHeaders(w.Header(), origin)
+
+				if p.exposeHeaders != "" {
+					w.Header().Set(headkey.AccessControlExposeHeaders, p.exposeHeaders)
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}, nil
+}
+
+func compile(conf Config) (*policy, error) {
+	if len(conf.AllowedOrigins) == 0 && len(conf.AllowedOriginPatterns) == 0 {
+		return nil, ErrNoOriginAllowed
+	}
+
+	if len(conf.AllowedMethods) == 0 {
+		conf.AllowedMethods = []string{http.MethodGet, http.MethodHead, http.MethodPost}
+	}
+
+	if conf.MaxAge == 0 {
+		conf.MaxAge = DefaultMaxAge
+	}
+
+	p := &policy{
+		methods:          conf.AllowedMethods,
+		allowMethods:     strings.Join(conf.AllowedMethods, ", "),
+		exposeHeaders:    strings.Join(conf.ExposedHeaders, ", "),
+		allowCredentials: conf.AllowCredentials,
+	}
+
+	if conf.MaxAge > 0 {
+		p.maxAge = strconv.FormatInt(int64(conf.MaxAge.Seconds()), 10)
+	}
+
+	for _, origin := range conf.AllowedOrigins {
+		origin = strings.ToLower(origin)
+
+		switch {
+		case origin == AllowAll:
+			if conf.AllowCredentials {
+				return nil, ErrCredentialsAnyOrigin
+			}
+
+			p.anyOrigin = true
+		case origin == "null":
+			// Sent by sandboxed documents and local files, whatever their actual origin
+			if conf.AllowCredentials {
+				return nil, ErrCredentialsNullOrigin
+			}
+
+			p.origins = append(p.origins, origin)
+		case strings.Contains(origin, "*"):
+			pattern, err := wildcardPattern(origin)
+			if err != nil {
+				return nil, err
+			}
+
+			p.patterns = append(p.patterns, pattern)
+		default:
+			if !strings.Contains(origin, "://") || strings.HasSuffix(origin, "/") {
+				return nil, fmt.Errorf("%w: %s", ErrInvalidOrigin, origin)
+			}
+
+			p.origins = append(p.origins, origin)
+		}
+	}
+
+	for _, expr := range conf.AllowedOriginPatterns {
+		pattern, err := regexp.Compile("^(?:" + expr + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidOriginPattern, err)
+		}
+
+		p.patterns = append(p.patterns, pattern)
+	}
+
+	for _, header := range conf.AllowedHeaders {
+		if header == AllowAll {
+			p.anyHeader = true
+
+			continue
+		}
+
+		p.headers = append(p.headers, strings.ToLower(header))
+	}
+
+	return p, nil
+}
+
+// wildcardPattern compiles wildcard [origin], whose leading host label matches one or more subdomain labels.
+func wildcardPattern(origin string) (*regexp.Regexp, error) {
+	scheme, host, found := strings.Cut(origin, "://")
+	if !found || !strings.HasPrefix(host, "*.") || strings.Count(host, "*") != 1 {
+		return nil, fmt.Errorf("%w: %s", ErrWildcardInOriginHost, origin)
+	}
+
+	return regexp.MustCompile(
+		"^" + regexp.QuoteMeta(scheme+"://") + `[a-z0-9-]+(?:\.[a-z0-9-]+)*` +
+			regexp.QuoteMeta(strings.TrimPrefix(host, "*")) + "$",
+	), nil
+}
+
+// allowOrigin reports whether [origin] is allowed.
+func (p *policy) allowOrigin(origin string) bool {
+	if p.anyOrigin {
+		return true
+	}
+
+	origin = strings.ToLower(origin)
+
+	if slices.Contains(p.origins, origin) {
+		return true
+	}
+
+	// Opaque origins never match patterns
+	if origin == "null" {
+		return false
+	}
+
+	return slices.ContainsFunc(p.patterns, func(pattern *regexp.Regexp) bool {
+		return pattern.MatchString(origin)
+	})
+}
+
+// allowHeaders reports whether all headers of Access-Control-Request-Headers [value] are allowed.
+func (p *policy) allowHeaders(value string) bool {
+	if p.anyHeader {
+		return true
+	}
+
+	for header := range strings.SplitSeq(value, ",") {
+		header = strings.ToLower(strings.TrimSpace(header))
+		if header != "" && !slices.Contains(p.headers, header) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (p *policy) setOriginHeaders(h http.Header, origin string) {
+	if p.anyOrigin && !p.allowCredentials {
+		h.Set(headkey.AccessControlAllowOrigin, AllowAll)
+	} else {
+		h.Set(headkey.AccessControlAllowOrigin, origin)
+	}
+
+	if p.allowCredentials {
+		h.Set(headkey.AccessControlAllowCredentials, "true")
+	}
+}
+
+func (p *policy) handlePreflight(w http.ResponseWriter, r *http.Request, origin string) {
+	method := r.Header.Get(headkey.AccessControlRequestMethod)
+	requestHeaders := strings.Join(r.Header.Values(headkey.AccessControlRequestHeaders), ",")
+
+	// Omitting CORS headers makes browsers block actual request
+	if origin == "" || !p.allowOrigin(origin) || !slices.Contains(p.methods, method) ||
+		!p.allowHeaders(requestHeaders) {
+		w.WriteHeader(http.StatusNoContent)
+
+		return
+	}
+
+	head := w.Header()
+	p.setOriginHeaders(head, origin)
+	head.Set(headkey.AccessControlAllowMethods, p.allowMethods)
+
+	// Reflect requested headers, as browsers reject wildcards for credentialed requests
+	if requestHeaders != "" {
+		head.Set(headkey.AccessControlAllowHeaders, requestHeaders)
+	}
+
+	if p.maxAge != "" {
+		head.Set(headkey.AccessControlMaxAge, p.maxAge)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,181 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package cors_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kemadev/go-framework/pkg/convenience/headkey"
+	"github.com/kemadev/go-framework/pkg/cors"
+)
+
+func TestMiddleware(t *testing.T) {
+	t.Parallel()
+
+	mw, err := cors.NewMiddleware(cors.Config{
+		AllowedOrigins:        []string{"https://app.example.com", "https://*.example.org"},
+		AllowedOriginPatterns: []string{`https://pr-[0-9]+\.preview\.example\.net`},
+		AllowedMethods:        []string{http.MethodGet, http.MethodPut},
+		AllowedHeaders:        []string{headkey.ContentType},
+		ExposedHeaders:        []string{headkey.ETag},
+		AllowCredentials:      true,
+	})
+	if err != nil {
+		t.Fatalf("NewMiddleware: %s", err)
+	}
+
+	handler := mw(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+
+	tests := []struct {
+		Name           string
+		Method         string
+		Origin         string
+		RequestMethod  string
+		RequestHeaders string
+		ExpectedStatus int
+		ExpectedOrigin string
+	}{
+		{
+			Name:           "allowed origin",
+			Method:         http.MethodGet,
+			Origin:         "https://app.example.com",
+			ExpectedStatus: http.StatusTeapot,
+			ExpectedOrigin: "https://app.example.com",
+		},
+		{
+			Name:           "wildcard subdomain",
+			Method:         http.MethodGet,
+			Origin:         "https://a.b.example.org",
+			ExpectedStatus: http.StatusTeapot,
+			ExpectedOrigin: "https://a.b.example.org",
+		},
+		{
+			Name:           "wildcard does not match apex",
+			Method:         http.MethodGet,
+			Origin:         "https://example.org",
+			ExpectedStatus: http.StatusTeapot,
+		},
+		{
+			Name:           "pattern",
+			Method:         http.MethodGet,
+			Origin:         "https://pr-42.preview.example.net",
+			ExpectedStatus: http.StatusTeapot,
+			ExpectedOrigin: "https://pr-42.preview.example.net",
+		},
+		{
+			Name:           "disallowed origin",
+			Method:         http.MethodGet,
+			Origin:         "https://evil.example.com",
+			ExpectedStatus: http.StatusTeapot,
+		},
+		{
+			Name:           "preflight",
+			Method:         http.MethodOptions,
+			Origin:         "https://app.example.com",
+			RequestMethod:  http.MethodPut,
+			RequestHeaders: "content-type",
+			ExpectedStatus: http.StatusNoContent,
+			ExpectedOrigin: "https://app.example.com",
+		},
+		{
+			Name:           "preflight with disallowed method",
+			Method:         http.MethodOptions,
+			Origin:         "https://app.example.com",
+			RequestMethod:  http.MethodDelete,
+			ExpectedStatus: http.StatusNoContent,
+		},
+		{
+			Name:           "preflight with disallowed header",
+			Method:         http.MethodOptions,
+			Origin:         "https://app.example.com",
+			RequestMethod:  http.MethodPut,
+			RequestHeaders: "content-type, x-secret",
+			ExpectedStatus: http.StatusNoContent,
+		},
+	}
+
+	for _, test := range tests {
+		req, err := http.NewRequestWithContext(context.Background(), test.Method, "/", nil)
+		if err != nil {
+			t.Fatalf("NewRequestWithContext: %s", err)
+		}
+
+		req.Header.Set(headkey.Origin, test.Origin)
+
+		if test.RequestMethod != "" {
+			req.Header.Set(headkey.AccessControlRequestMethod, test.RequestMethod)
+		}
+
+		if test.RequestHeaders != "" {
+			req.Header.Set(headkey.AccessControlRequestHeaders, test.RequestHeaders)
+		}
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		if rr.Code != test.ExpectedStatus {
+			t.Errorf("%s: expected status %d but was %d", test.Name, test.ExpectedStatus, rr.Code)
+		}
+
+		origin := rr.Header().Get(headkey.AccessControlAllowOrigin)
+		if origin != test.ExpectedOrigin {
+			t.Errorf("%s: expected allowed origin %q but was %q", test.Name, test.ExpectedOrigin, origin)
+		}
+
+		if test.ExpectedOrigin != "" && rr.Header().Get(headkey.AccessControlAllowCredentials) != "true" {
+			t.Errorf("%s: expected credentials to be allowed", test.Name)
+		}
+	}
+}
+
+func TestConfigErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Name     string
+		Config   cors.Config
+		Expected error
+	}{
+		{
+			Name:     "no origin",
+			Config:   cors.Config{},
+			Expected: cors.ErrNoOriginAllowed,
+		},
+		{
+			Name: "credentials for any origin",
+			Config: cors.Config{
+				AllowedOrigins:   []string{cors.AllowAll},
+				AllowCredentials: true,
+			},
+			Expected: cors.ErrCredentialsAnyOrigin,
+		},
+		{
+			Name: "wildcard in middle of host",
+			Config: cors.Config{
+				AllowedOrigins: []string{"https://app.*.example.com"},
+			},
+			Expected: cors.ErrWildcardInOriginHost,
+		},
+		{
+			Name: "invalid pattern",
+			Config: cors.Config{
+				AllowedOriginPatterns: []string{"("},
+			},
+			Expected: cors.ErrInvalidOriginPattern,
+		},
+	}
+
+	for _, test := range tests {
+		_, err := cors.NewMiddleware(test.Config)
+		if !errors.Is(err, test.Expected) {
+			t.Errorf("%s: expected error %q but was %v", test.Name, test.Expected, err)
+		}
+	}
+}
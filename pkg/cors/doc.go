@@ -0,0 +1,7 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+// Package cors implements Cross-Origin Resource Sharing, letting browsers call routes from allowed origins.
+// Preflight requests are answered without reaching handlers, and policies can differ between route groups, as
+// [github.com/kemadev/go-framework/pkg/router.Router] registers OPTIONS routes behind group middlewares.
+package cors
@@ -0,0 +1,287 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package csrf
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"html/template"
+	"io"
+	"log/slog"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"slices"
+
+	"github.com/kemadev/go-framework/pkg/convenience/headkey"
+	"github.com/kemadev/go-framework/pkg/convenience/headutil"
+	"github.com/kemadev/go-framework/pkg/convenience/headval"
+	"github.com/kemadev/go-framework/pkg/convenience/log"
+	"github.com/kemadev/go-framework/pkg/convenience/resp"
+	"github.com/kemadev/go-framework/pkg/session"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+)
+
+const packageName = "github.com/kemadev/go-framework/pkg/csrf"
+
+const (
+	// DefaultFieldName is the default name of form fields carrying tokens.
+	DefaultFieldName = "csrf_token"
+	// DefaultHeader is the default name of headers carrying tokens.
+	DefaultHeader = headkey.XCSRFToken
+)
+
+const (
+	// secretKey is the session key under which token secret is stored.
+	secretKey = "csrf.secret"
+	// secretSize is the size of token secrets.
+	secretSize = 32
+	// firstPartMaxSize is the maximum number of bytes read from multipart bodies looking for tokens.
+	firstPartMaxSize = 16 << 10
+)
+
+var (
+	// ErrNoSession is returned when tokens are requested without session, see
+	// [github.com/kemadev/go-framework/pkg/session.NewMiddleware].
+	ErrNoSession = errors.New("no session")
+	// ErrMissingToken is returned when an unsafe request carries no token.
+	ErrMissingToken = errors.New("missing CSRF token")
+	// ErrInvalidToken is returned when an unsafe request carries a token not matching its session.
+	ErrInvalidToken = errors.New("invalid CSRF token")
+)
+
+// safeMethods are methods not requiring tokens, as they must not change state, see RFC 9110 section 9.2.1.
+var safeMethods = []string{
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodOptions,
+	http.MethodTrace,
+}
+
+// Config defines the configuration for CSRF middleware.
+type Config struct {
+	// Name of form fields carrying tokens, defaults to [DefaultFieldName]
+	FieldName string
+	// Name of headers carrying tokens, defaults to [DefaultHeader]
+	Header string
+	// Route patterns exempted from token validation, e.g. webhooks authenticated otherwise. Patterns are
+	// compared to [net/http.Request.Pattern], so that middleware must be used in routers chain, see
+	// [github.com/kemadev/go-framework/pkg/router.Router.Group].
+	Exempt []string
+	// Skip returns whether token validation should be bypassed for given request
+	Skip func(r *http.Request) bool
+}
+
+type configKey struct{}
+
+// NewMiddleware returns a middleware validating tokens of requests using unsafe methods, against secret of
+// their session. It must be used after session middleware. Tokens are read from configured header, then from
+// configured form field of URL-encoded forms, or of multipart forms as long as it is their first part, which is
+// the case when [Field] opens forms. Multipart bodies are restored once read, so that handlers can stream them,
+// see [github.com/kemadev/go-framework/pkg/convenience/req.Multipart]. Requests without valid token are rejected
+// with 403 status, using problem details, see [resp.ProblemJSON].
+func NewMiddleware(conf Config) func(http.Handler) http.Handler {
+	if conf.FieldName == "" {
+		conf.FieldName = DefaultFieldName
+	}
+
+	if conf.Header == "" {
+		conf.Header = DefaultHeader
+	}
+
+	logger := log.Logger(packageName)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			r = r.WithContext(context.WithValue(r.Context(), configKey{}, conf))
+
+			if slices.Contains(safeMethods, r.Method) ||
+				slices.Contains(conf.Exempt, r.Pattern) ||
+				(conf.Skip != nil && conf.Skip(r)) {
+				next.ServeHTTP(w, r)
+
+				return
+			}
+
+			err := validate(r, conf)
+			if err == nil {
+				next.ServeHTTP(w, r)
+
+				return
+			}
+
+			if errors.Is(err, ErrNoSession) {
+				log.ErrLog(packageName, "error validating CSRF token", err)
+				http.Error(
+					w,
+					http.StatusText(http.StatusInternalServerError),
+					http.StatusInternalServerError,
+				)
+
+				return
+			}
+
+			logger.WarnContext(
+				r.Context(),
+				"request rejected",
+				slog.String("csrf.reason", err.Error()),
+				slog.String(string(semconv.HTTPRouteKey), r.Pattern),
+			)
+
+			err = resp.ProblemJSON(w, resp.NewProblem(http.StatusForbidden, err.Error()))
+			if err != nil {
+				log.ErrLog(packageName, "error sending problem", err)
+			}
+		})
+	}
+}
+
+// validate returns an error if [r] carries no token matching its session secret.
+func validate(r *http.Request, conf Config) error {
+	s, ok := session.FromContext(r.Context())
+	if !ok {
+		return ErrNoSession
+	}
+
+	token := r.Header.Get(conf.Header)
+	if token == "" {
+		switch {
+		case headutil.IsMIME(r.Header, headval.MIMEApplicationForm):
+			token = r.PostFormValue(conf.FieldName)
+		case headutil.IsMIME(r.Header, headval.MIMEMultipartForm):
+			token = multipartToken(r, conf.FieldName)
+		}
+	}
+
+	if token == "" {
+		return ErrMissingToken
+	}
+
+	secret, ok := s.Get(secretKey)
+	if !ok {
+		// Session has never been issued a token, e.g. as it expired since form was rendered
+		return ErrInvalidToken
+	}
+
+	masked, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || len(masked) != 2*secretSize {
+		return ErrInvalidToken
+	}
+
+	unmasked := make([]byte, secretSize)
+	subtle.XORBytes(unmasked, masked[:secretSize], masked[secretSize:])
+
+	secretBytes, _ := secret.([]byte)
+	if subtle.ConstantTimeCompare(unmasked, secretBytes) != 1 {
+		return ErrInvalidToken
+	}
+
+	return nil
+}
+
+// multipartToken returns token carried by first part of multipart body of [r], if any, restoring body so that
+// it can be read again.
+func multipartToken(r *http.Request, fieldName string) string {
+	_, params, err := mime.ParseMediaType(r.Header.Get(headkey.ContentType))
+	if err != nil || params["boundary"] == "" {
+		return ""
+	}
+
+	// Parsing body using [net/http.Request.MultipartReader] would prevent handlers from doing so
+	var read bytes.Buffer
+
+	body := r.Body
+	mr := multipart.NewReader(io.TeeReader(io.LimitReader(body, firstPartMaxSize), &read), params["boundary"])
+
+	defer func() {
+		r.Body = replayedBody{
+			Reader: io.MultiReader(&read, body),
+			Closer: body,
+		}
+	}()
+
+	part, err := mr.NextPart()
+	if err != nil || part.FormName() != fieldName || part.FileName() != "" {
+		return ""
+	}
+
+	token, err := io.ReadAll(part)
+	if err != nil {
+		return ""
+	}
+
+	return string(token)
+}
+
+// replayedBody is a request body whose beginning was read, and is replayed.
+type replayedBody struct {
+	io.Reader
+	io.Closer
+}
+
+// Token returns a token for session of [r], creating its secret if needed. Tokens are masked with a random
+// value, so that each call returns a different token, which prevents compression side-channel attacks such as
+// BREACH.
+func Token(r *http.Request) (string, error) {
+	s, ok := session.FromContext(r.Context())
+	if !ok {
+		return "", ErrNoSession
+	}
+
+	secret, ok := s.Get(secretKey)
+
+	secretBytes, _ := secret.([]byte)
+	if !ok || len(secretBytes) != secretSize {
+		secretBytes = make([]byte, secretSize)
+		rand.Read(secretBytes)
+		s.Set(secretKey, secretBytes)
+	}
+
+	masked := make([]byte, 2*secretSize)
+	rand.Read(masked[:secretSize])
+	subtle.XORBytes(masked[secretSize:], masked[:secretSize], secretBytes)
+
+	return base64.RawURLEncoding.EncodeToString(masked), nil
+}
+
+// Field returns a hidden form field carrying a token for session of [r], see [Token].
+func Field(r *http.Request) (template.HTML, error) {
+	token, err := Token(r)
+	if err != nil {
+		return "", err
+	}
+
+	conf, ok := r.Context().Value(configKey{}).(Config)
+	if !ok {
+		conf.FieldName = DefaultFieldName
+	}
+
+	return template.HTML(
+		`<input type="hidden" name="` + template.HTMLEscapeString(conf.FieldName) +
+			`" value="` + token + `">`,
+	), nil
+}
+
+// RequestFuncs returns template functions to be used as
+// [github.com/kemadev/go-framework/pkg/convenience/render.Config] RequestFuncs:
+//   - csrfToken: returns a token, as in <meta name="csrf-token" content="{{ csrfToken }}">
+//   - csrfField: returns a hidden form field carrying a token, as in <form method="post">{{ csrfField }}
+func RequestFuncs() map[string]func(r *http.Request) any {
+	return map[string]func(r *http.Request) any{
+		"csrfToken": func(r *http.Request) any {
+			return func() (string, error) {
+				return Token(r)
+			}
+		},
+		"csrfField": func(r *http.Request) any {
+			return func() (template.HTML, error) {
+				return Field(r)
+			}
+		},
+	}
+}
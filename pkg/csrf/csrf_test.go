@@ -0,0 +1,249 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package csrf_test
+
+import (
+	"bytes"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/kemadev/go-framework/pkg/convenience/req"
+	"github.com/kemadev/go-framework/pkg/csrf"
+	"github.com/kemadev/go-framework/pkg/router"
+	"github.com/kemadev/go-framework/pkg/session"
+)
+
+var fieldRegexp = regexp.MustCompile(`<input type="hidden" name="csrf_token" value="([^"]+)">`)
+
+func TestMiddleware(t *testing.T) {
+	t.Parallel()
+
+	store, err := session.NewCookieStore(bytes.Repeat([]byte{1}, session.CookieKeySize))
+	if err != nil {
+		t.Fatalf("NewCookieStore: %s", err)
+	}
+
+	sessions, err := session.NewMiddleware(session.Config{
+		Store: store,
+	})
+	if err != nil {
+		t.Fatalf("NewMiddleware: %s", err)
+	}
+
+	handler := func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	app := router.New()
+	app.Use(sessions)
+	app.Group(func(r *router.Router) {
+		r.Use(csrf.NewMiddleware(csrf.Config{
+			Exempt: []string{"POST /webhook"},
+		}))
+
+		r.HandleFunc("GET /form", func(w http.ResponseWriter, r *http.Request) {
+			field, err := csrf.Field(r)
+			if err != nil {
+				t.Errorf("Field: %s", err)
+			}
+
+			w.Write([]byte(field))
+		})
+		r.HandleFunc("POST /form", handler)
+		r.HandleFunc("POST /upload", func(w http.ResponseWriter, r *http.Request) {
+			// Handlers must see whole multipart body, including part carrying token
+			var names []string
+
+			for part, err := range req.Multipart(r, req.MultipartConfig{}) {
+				if err != nil {
+					t.Errorf("Multipart: %s", err)
+
+					break
+				}
+
+				names = append(names, part.FormName)
+				part.Close()
+			}
+
+			if len(names) != 3 || names[2] != "photo" {
+				t.Errorf("expected all parts to be read; got %v", names)
+			}
+
+			w.WriteHeader(http.StatusOK)
+		})
+		r.HandleFunc("POST /webhook", handler)
+	})
+
+	// Render form, creating session and its token secret
+	rr := httptest.NewRecorder()
+	app.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/form", nil))
+
+	cookies := rr.Result().Cookies()
+	if len(cookies) != 1 {
+		t.Fatalf("expected session cookie; got %v", cookies)
+	}
+
+	match := fieldRegexp.FindStringSubmatch(rr.Body.String())
+	if match == nil {
+		t.Fatalf("expected hidden field; got %q", rr.Body.String())
+	}
+
+	token := match[1]
+
+	// Tokens are masked differently on each render
+	rr = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/form", nil)
+	req.AddCookie(cookies[0])
+	app.ServeHTTP(rr, req)
+
+	otherToken := fieldRegexp.FindStringSubmatch(rr.Body.String())[1]
+	if otherToken == token {
+		t.Errorf("expected tokens to differ between renders")
+	}
+
+	tests := []struct {
+		Name           string
+		Path           string
+		Cookie         bool
+		Field          string
+		Header         string
+		Multipart      bool
+		FieldNotFirst  bool
+		ExpectedStatus int
+	}{
+		{
+			Name:           "form field",
+			Path:           "/form",
+			Cookie:         true,
+			Field:          token,
+			ExpectedStatus: http.StatusOK,
+		},
+		{
+			Name:           "header",
+			Path:           "/form",
+			Cookie:         true,
+			Header:         otherToken,
+			ExpectedStatus: http.StatusOK,
+		},
+		{
+			Name:           "missing token",
+			Path:           "/form",
+			Cookie:         true,
+			ExpectedStatus: http.StatusForbidden,
+		},
+		{
+			Name:           "forged token",
+			Path:           "/form",
+			Cookie:         true,
+			Header:         strings.Repeat("A", len(token)),
+			ExpectedStatus: http.StatusForbidden,
+		},
+		{
+			Name:           "token without session",
+			Path:           "/form",
+			Field:          token,
+			ExpectedStatus: http.StatusForbidden,
+		},
+		{
+			Name:           "multipart field",
+			Path:           "/upload",
+			Cookie:         true,
+			Field:          token,
+			Multipart:      true,
+			ExpectedStatus: http.StatusOK,
+		},
+		{
+			Name:           "multipart header",
+			Path:           "/upload",
+			Cookie:         true,
+			Header:         token,
+			Multipart:      true,
+			ExpectedStatus: http.StatusOK,
+		},
+		{
+			Name:           "multipart field not first",
+			Path:           "/upload",
+			Cookie:         true,
+			Field:          token,
+			Multipart:      true,
+			FieldNotFirst:  true,
+			ExpectedStatus: http.StatusForbidden,
+		},
+		{
+			Name:           "exempt route",
+			Path:           "/webhook",
+			ExpectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(
+			http.MethodPost,
+			test.Path,
+			strings.NewReader(url.Values{"csrf_token": {test.Field}}.Encode()),
+		)
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+		if test.Multipart {
+			body, contentType := multipartBody(t, test.Field, test.FieldNotFirst)
+			req = httptest.NewRequest(http.MethodPost, test.Path, body)
+			req.Header.Set("Content-Type", contentType)
+		}
+
+		if test.Header != "" {
+			req.Header.Set("X-CSRF-Token", test.Header)
+		}
+
+		if test.Cookie {
+			req.AddCookie(cookies[0])
+		}
+
+		rr := httptest.NewRecorder()
+		app.ServeHTTP(rr, req)
+
+		if rr.Code != test.ExpectedStatus {
+			t.Errorf("%s: expected status %d but was %d", test.Name, test.ExpectedStatus, rr.Code)
+		}
+	}
+}
+
+// multipartBody returns a multipart form carrying [token], along with its content type.
+func multipartBody(t *testing.T, token string, fieldNotFirst bool) (io.Reader, string) {
+	t.Helper()
+
+	var body bytes.Buffer
+
+	mw := multipart.NewWriter(&body)
+
+	if !fieldNotFirst {
+		mw.WriteField("csrf_token", token)
+	}
+
+	mw.WriteField("title", "holidays")
+
+	if fieldNotFirst {
+		mw.WriteField("csrf_token", token)
+	}
+
+	w, err := mw.CreateFormFile("photo", "beach.png")
+	if err != nil {
+		t.Fatalf("CreateFormFile: %s", err)
+	}
+
+	// Larger than read when looking for token
+	w.Write(bytes.Repeat([]byte{0}, 64<<10))
+
+	err = mw.Close()
+	if err != nil {
+		t.Fatalf("Close: %s", err)
+	}
+
+	return &body, mw.FormDataContentType()
+}
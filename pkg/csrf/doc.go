@@ -0,0 +1,9 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+// Package csrf protects form-based routes against Cross-Site Request Forgery using synchronizer tokens bound
+// to the session, see [github.com/kemadev/go-framework/pkg/session]. It complements
+// [net/http.CrossOriginProtection], which relies on Sec-Fetch-Site and Origin headers that older browsers do
+// not send. Forms embed tokens using template helpers, see [RequestFuncs], and scripts send them using
+// X-CSRF-Token header.
+package csrf
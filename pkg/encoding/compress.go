@@ -11,37 +11,77 @@ import (
 	"io"
 	"net"
 	"net/http"
+	"strings"
 	"sync"
 
 	"github.com/kemadev/go-framework/pkg/convenience/headkey"
 	"github.com/kemadev/go-framework/pkg/convenience/headutil"
 	"github.com/kemadev/go-framework/pkg/convenience/headval"
 	"github.com/kemadev/go-framework/pkg/convenience/log"
+	"github.com/klauspost/compress/zstd"
 )
 
 // CompressConfig defines the configuration for compression middleware.
 type CompressConfig struct {
-	// Gzip compression level (-1 to 9, where -1 is default compression)
+	// Gzip compression level (-1 to 9, where -1 is default compression), used when Encoders is empty
 	Level int
 	// Minimum response length before compression is applied
 	MinLength int
+	// Encoders by order of server preference, which breaks ties between codings equally accepted by client.
+	// Defaults to zstd, br and gzip.
+	Encoders []Encoder
+	// Media types that are not compressed, typically because they already are. Entries ending with "/" match
+	// any subtype. Defaults to [DefaultSkipContentTypes].
+	SkipContentTypes []string
+}
+
+// DefaultSkipContentTypes lists media types that are already compressed, for which compression would only
+// waste CPU time.
+var DefaultSkipContentTypes = []string{
+	"image/png",
+	"image/jpeg",
+	"image/gif",
+	"image/webp",
+	"image/avif",
+	"video/",
+	"audio/",
+	"font/woff",
+	"font/woff2",
+	"application/zip",
+	"application/gzip",
+	"application/x-gzip",
+	"application/zstd",
+	"application/x-7z-compressed",
+	"application/vnd.rar",
+	"application/x-rar-compressed",
+	"application/x-bzip2",
+	"application/x-xz",
+	"application/pdf",
 }
 
 type compressResponseWriter struct {
 	http.ResponseWriter
-	writer            *gzip.Writer
-	buffer            *bytes.Buffer
-	minLength         int
-	wroteHeader       bool
-	wroteBody         bool
-	minLengthExceeded bool
-	statusCode        int
+	encoder     Encoder
+	writer      Writer
+	buffer      *bytes.Buffer
+	minLength   int
+	skipTypes   []string
+	wroteHeader bool
+	committed   bool
+	hijacked    bool
+	statusCode  int
 }
 
 // gzip overhead makes response bigger for small bodies, and thus wastes CPU time for counter-productive results.
 const CompressionMinThreshold = 2 * 1024
 
-// CompressMiddleware returns a middleware that performs automatic gzip compression of response body
+// Default levels favor speed, as responses are compressed on the fly.
+const (
+	DefaultBrotliLevel = 4
+	DefaultZstdLevel   = zstd.SpeedDefault
+)
+
+// CompressMiddleware returns a middleware that performs automatic compression of response body
 // when the client accepts it. It is inspired from [echo's implementation].
 //
 // [echo's implementation]: https://github.com/labstack/echo/blob/master/middleware/compress.go
@@ -58,38 +98,44 @@ func CompressMiddlewareWithConfig(conf CompressConfig) func(http.Handler) http.H
 		conf.Level = gzip.DefaultCompression
 	}
 
-	compressPool := gzipCompressPool(conf)
+	if len(conf.Encoders) == 0 {
+		conf.Encoders = []Encoder{
+			NewZstdEncoder(DefaultZstdLevel),
+			NewBrotliEncoder(DefaultBrotliLevel),
+			NewGzipEncoder(conf.Level),
+		}
+	}
+
+	if conf.SkipContentTypes == nil {
+		conf.SkipContentTypes = DefaultSkipContentTypes
+	}
+
+	encoders := make(map[string]Encoder, len(conf.Encoders))
+	offers := make([]string, 0, len(conf.Encoders)+1)
+
+	for _, encoder := range conf.Encoders {
+		encoders[encoder.Encoding()] = encoder
+		offers = append(offers, encoder.Encoding())
+	}
+
+	offers = append(offers, headval.EncodingIdentity)
+
 	bufferPool := bufferPool()
 
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			w.Header().Add(headkey.Vary, headkey.AcceptEncoding)
+			headutil.AddVary(w.Header(), headkey.AcceptEncoding)
 
-			if !headutil.AcceptsEncoding(r.Header, headval.EncodingGzip) {
-				next.ServeHTTP(w, r)
+			encoding, _ := headutil.NegotiateEncoding(r.Header, offers)
 
-				return
-			}
-
-			pe := compressPool.Get()
-			defer compressPool.Put(pe)
-
-			gzipWriter, ok := pe.(*gzip.Writer)
-			if !ok || gzipWriter == nil {
-				log.ErrLog(packageName, "error getting compressor from pool", ErrFailureGetFromPool)
-				http.Error(
-					w,
-					http.StatusText(http.StatusServiceUnavailable),
-					http.StatusServiceUnavailable,
-				)
+			encoder, ok := encoders[encoding]
+			if !ok {
+				next.ServeHTTP(w, r)
 
 				return
 			}
 
-			gzipWriter.Reset(w)
-
 			be := bufferPool.Get()
-			defer bufferPool.Put(be)
 
 			buffer, ok := be.(*bytes.Buffer)
 			if !ok || buffer == nil {
@@ -104,68 +150,66 @@ func CompressMiddlewareWithConfig(conf CompressConfig) func(http.Handler) http.H
 			}
 
 			buffer.Reset()
+			defer bufferPool.Put(buffer)
 
 			crw := &compressResponseWriter{
-				ResponseWriter:    w,
-				writer:            gzipWriter,
-				buffer:            buffer,
-				minLength:         conf.MinLength,
-				wroteHeader:       false,
-				wroteBody:         false,
-				minLengthExceeded: false,
-				statusCode:        http.StatusOK,
+				ResponseWriter: w,
+				encoder:        encoder,
+				buffer:         buffer,
+				minLength:      conf.MinLength,
+				skipTypes:      conf.SkipContentTypes,
+				statusCode:     http.StatusOK,
 			}
 
-			defer func() {
-				if !crw.wroteBody {
-					if crw.wroteHeader {
-						crw.ResponseWriter.WriteHeader(crw.statusCode)
-					}
-				} else if !crw.minLengthExceeded {
-					if crw.wroteHeader {
-						crw.ResponseWriter.WriteHeader(crw.statusCode)
-					}
-
-					_, err := crw.buffer.WriteTo(crw.ResponseWriter)
-					if err != nil {
-						log.ErrLog(packageName, "error writing uncompressed response", err)
-						http.Error(
-							w,
-							http.StatusText(http.StatusServiceUnavailable),
-							http.StatusServiceUnavailable,
-						)
-
-						return
-					}
-
-					gzipWriter.Reset(io.Discard)
-				}
-
-				err := gzipWriter.Close()
-				if err != nil {
-					log.ErrLog(packageName, "error closing gzip writer", err)
-					http.Error(
-						w,
-						http.StatusText(http.StatusServiceUnavailable),
-						http.StatusServiceUnavailable,
-					)
-
-					return
-				}
-
-				compressPool.Put(gzipWriter)
-				bufferPool.Put(buffer)
-			}()
+			defer crw.finish()
 
 			next.ServeHTTP(crw, r)
 		})
 	}
 }
 
+// finish sends any buffered data, uncompressed as minimum length was not reached, and terminates the
+// compressed stream if any.
+func (w *compressResponseWriter) finish() {
+	// Connection is no longer ours (e.g. websocket upgrade)
+	if w.hijacked {
+		return
+	}
+
+	if !w.committed {
+		err := w.commit(false)
+		if err != nil {
+			log.ErrLog(packageName, "error writing uncompressed response", err)
+		}
+
+		return
+	}
+
+	if w.writer == nil {
+		return
+	}
+
+	err := w.writer.Close()
+	if err != nil {
+		log.ErrLog(packageName, "error closing compressed response writer", err)
+	}
+
+	w.encoder.Release(w.writer)
+	w.writer = nil
+}
+
 // WriteHeader implements [net/http.ResponseWriter].
 func (w *compressResponseWriter) WriteHeader(statusCode int) {
-	// Remove Content-Length header as it will be invalid after compression
-	w.Header().Del(headkey.ContentLength)
+	// Informational responses carry no body and may be sent several times
+	if statusCode >= 100 && statusCode < 200 && statusCode != http.StatusSwitchingProtocols {
+		w.ResponseWriter.WriteHeader(statusCode)
+
+		return
+	}
+
+	if w.wroteHeader {
+		return
+	}
 
 	w.wroteHeader = true
 	w.statusCode = statusCode
@@ -173,90 +217,151 @@ func (w *compressResponseWriter) WriteHeader(statusCode int) {
 
 // Write implements [net/http.ResponseWriter].
 func (w *compressResponseWriter) Write(data []byte) (int, error) {
-	w.wroteBody = true
+	if w.committed {
+		if w.writer == nil {
+			return w.ResponseWriter.Write(data)
+		}
 
-	// If not exceeding minimum length, buffer
-	if !w.minLengthExceeded {
-		n, err := w.buffer.Write(data)
+		_, err := w.writer.Write(data)
 		if err != nil {
-			return n, err
+			return 0, fmt.Errorf("error writing compressed response: %w", err)
 		}
 
-		if w.buffer.Len() >= w.minLength {
-			w.minLengthExceeded = true
+		return len(data), nil
+	}
 
-			w.Header().Set(headkey.ContentEncoding, headval.EncodingGzip)
+	// If not exceeding minimum length, buffer
+	n, err := w.buffer.Write(data)
+	if err != nil {
+		return n, err
+	}
 
-			if w.wroteHeader {
-				w.ResponseWriter.WriteHeader(w.statusCode)
-			}
+	if w.buffer.Len() >= w.minLength {
+		err := w.commit(w.compressible())
+		if err != nil {
+			return 0, err
+		}
+	}
 
-			_, err := w.writer.Write(w.buffer.Bytes())
-			if err != nil {
-				return 0, fmt.Errorf("error writing compressed response: %w", err)
-			}
+	return len(data), nil
+}
 
-			return len(data), nil
-		}
+// compressible reports whether response may be compressed, based on its status and headers.
+func (w *compressResponseWriter) compressible() bool {
+	switch {
+	case w.statusCode < http.StatusOK,
+		w.statusCode == http.StatusNoContent,
+		w.statusCode == http.StatusPartialContent,
+		w.statusCode == http.StatusNotModified:
+		return false
+	}
 
-		return n, nil
+	h := w.Header()
+
+	// Already encoded, e.g. precompressed assets
+	if h.Get(headkey.ContentEncoding) != "" {
+		return false
 	}
 
-	// We already decided to compress, write to gzip writer
-	_, err := w.writer.Write(data)
-	if err != nil {
-		return 0, fmt.Errorf("error writing compressed response: %w", err)
+	for _, directive := range strings.Split(strings.Join(h.Values(headkey.CacheControl), ","), ",") {
+		if strings.EqualFold(strings.TrimSpace(directive), "no-transform") {
+			return false
+		}
 	}
 
-	return len(data), nil
+	return !MatchContentType(h.Get(headkey.ContentType), w.skipTypes)
 }
 
-// Write implements [net/http.ResponseWriter].
-func (w *compressResponseWriter) Flush() {
-	if !w.minLengthExceeded {
-		if w.wroteHeader {
-			w.ResponseWriter.WriteHeader(w.statusCode)
+// MatchContentType reports whether media type of [contentType] is one of [mediaTypes], whose entries ending
+// with "/" match any subtype.
+func MatchContentType(contentType string, mediaTypes []string) bool {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+
+	for _, mt := range mediaTypes {
+		if (strings.HasSuffix(mt, "/") && strings.HasPrefix(mediaType, mt)) || mediaType == mt {
+			return true
 		}
+	}
 
-		if w.buffer.Len() > 0 {
-			_, err := w.buffer.WriteTo(w.ResponseWriter)
-			if err != nil {
-				log.ErrLog(
-					packageName,
-					"error writing uncompressed buffered data during flush",
-					err,
-				)
-				http.Error(
-					w,
-					http.StatusText(http.StatusServiceUnavailable),
-					http.StatusServiceUnavailable,
-				)
+	return false
+}
 
-				return
-			}
+// commit sends headers and buffered data, through a compressor when [compress] is true. Subsequent writes
+// follow the same path.
+func (w *compressResponseWriter) commit(compress bool) error {
+	w.committed = true
 
-			w.buffer.Reset()
-		}
+	h := w.Header()
 
-		w.wroteBody = true
+	// Sniff content type from uncompressed data, as net/http would otherwise sniff compressed bytes
+	if h.Get(headkey.ContentType) == "" && w.buffer.Len() > 0 {
+		h.Set(headkey.ContentType, http.DetectContentType(w.buffer.Bytes()))
+
+		compress = compress && w.compressible()
+	}
 
-		if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
-			flusher.Flush()
+	if compress {
+		writer, err := w.encoder.Acquire(w.ResponseWriter)
+		if err != nil {
+			// Serving an uncompressed response beats failing it
+			log.ErrLog(packageName, "error acquiring compressed response writer", err)
+		} else {
+			w.writer = writer
+
+			h.Set(headkey.ContentEncoding, w.encoder.Encoding())
+			// Content-Length would be invalid after compression
+			h.Del(headkey.ContentLength)
+
+			// Strong validators are representation specific, see RFC 9110 section 8.8.3
+			etag := h.Get(headkey.ETag)
+			if etag != "" && !strings.HasPrefix(etag, "W/") {
+				h.Set(headkey.ETag, "W/"+etag)
+			}
 		}
+	}
 
-		return
+	if w.wroteHeader {
+		w.ResponseWriter.WriteHeader(w.statusCode)
+	}
+
+	if w.buffer.Len() == 0 {
+		return nil
 	}
 
-	err := w.writer.Flush()
+	var dst io.Writer = w.ResponseWriter
+	if w.writer != nil {
+		dst = w.writer
+	}
+
+	_, err := w.buffer.WriteTo(dst)
 	if err != nil {
-		log.ErrLog(packageName, "error flushing gzip writer", err)
-		http.Error(
-			w,
-			http.StatusText(http.StatusServiceUnavailable),
-			http.StatusServiceUnavailable,
-		)
+		return fmt.Errorf("error writing buffered response: %w", err)
+	}
 
-		return
+	return nil
+}
+
+// Flush implements [net/http.Flusher]. As flushed data can't be taken back, flushing commits the response
+// to being compressed even if minimum length is not reached, so that streamed responses (e.g. JSON streams)
+// are consistently encoded.
+func (w *compressResponseWriter) Flush() {
+	if !w.committed {
+		err := w.commit(w.compressible())
+		if err != nil {
+			log.ErrLog(packageName, "error writing buffered data during flush", err)
+
+			return
+		}
+	}
+
+	if w.writer != nil {
+		err := w.writer.Flush()
+		if err != nil {
+			log.ErrLog(packageName, "error flushing compressed response writer", err)
+
+			return
+		}
 	}
 
 	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
@@ -271,7 +376,12 @@ func (w *compressResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
 		return nil, nil, http.ErrNotSupported
 	}
 
-	return hijacker.Hijack()
+	conn, brw, err := hijacker.Hijack()
+	if err == nil {
+		w.hijacked = true
+	}
+
+	return conn, brw, err
 }
 
 // Push implements [net/http.Pusher] if the underlying ResponseWriter supports it.
@@ -289,19 +399,6 @@ func (w *compressResponseWriter) Unwrap() http.ResponseWriter {
 	return w.ResponseWriter
 }
 
-func gzipCompressPool(conf CompressConfig) sync.Pool {
-	return sync.Pool{
-		New: func() any {
-			w, err := gzip.NewWriterLevel(io.Discard, conf.Level)
-			if err != nil {
-				return err
-			}
-
-			return w
-		},
-	}
-}
-
 func bufferPool() sync.Pool {
 	return sync.Pool{
 		New: func() any {
@@ -0,0 +1,283 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package encoding_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/kemadev/go-framework/pkg/convenience/headkey"
+	"github.com/kemadev/go-framework/pkg/convenience/headval"
+	"github.com/kemadev/go-framework/pkg/encoding"
+	"github.com/klauspost/compress/zstd"
+)
+
+// decode returns [body] decoded according to [contentEncoding].
+func decode(t *testing.T, contentEncoding string, body []byte) []byte {
+	t.Helper()
+
+	var (
+		r   io.Reader
+		err error
+	)
+
+	switch contentEncoding {
+	case "":
+		return body
+	case headval.EncodingGzip:
+		r, err = gzip.NewReader(bytes.NewReader(body))
+	case headval.EncodingDeflate:
+		r, err = zlib.NewReader(bytes.NewReader(body))
+	case headval.EncodingBr:
+		r = brotli.NewReader(bytes.NewReader(body))
+	case headval.EncodingZstd:
+		var dec *zstd.Decoder
+
+		dec, err = zstd.NewReader(bytes.NewReader(body))
+		if err == nil {
+			defer dec.Close()
+		}
+
+		r = dec
+	default:
+		t.Fatalf("unexpected content encoding %q", contentEncoding)
+	}
+
+	if err != nil {
+		t.Fatalf("error creating %s reader: %s", contentEncoding, err)
+	}
+
+	decoded, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("error decoding %s body: %s", contentEncoding, err)
+	}
+
+	return decoded
+}
+
+func TestCompressMiddleware(t *testing.T) {
+	t.Parallel()
+
+	large := strings.Repeat("compressible text ", 1024)
+
+	tests := []struct {
+		Name                 string
+		Conf                 encoding.CompressConfig
+		AcceptEncoding       string
+		Headers              map[string]string
+		Status               int
+		Body                 string
+		ExpectedEncoding     string
+		ExpectedETag         string
+		ExpectedContentType  string
+		ExpectedLengthHeader bool
+	}{
+		{
+			Name:                "server preference breaks ties",
+			AcceptEncoding:      "gzip, br, zstd",
+			Body:                large,
+			ExpectedEncoding:    headval.EncodingZstd,
+			ExpectedContentType: "text/plain; charset=utf-8",
+		},
+		{
+			Name:                "client preference",
+			AcceptEncoding:      "gzip;q=1, br;q=0.5, zstd;q=0.1",
+			Body:                large,
+			ExpectedEncoding:    headval.EncodingGzip,
+			ExpectedContentType: "text/plain; charset=utf-8",
+		},
+		{
+			Name:                "wildcard",
+			AcceptEncoding:      "*, zstd;q=0",
+			Body:                large,
+			ExpectedEncoding:    headval.EncodingBr,
+			ExpectedContentType: "text/plain; charset=utf-8",
+		},
+		{
+			Name:                "configured encoders",
+			Conf:                encoding.CompressConfig{Encoders: []encoding.Encoder{encoding.NewDeflateEncoder(zlib.BestSpeed)}},
+			AcceptEncoding:      "gzip, deflate",
+			Body:                large,
+			ExpectedEncoding:    headval.EncodingDeflate,
+			ExpectedContentType: "text/plain; charset=utf-8",
+		},
+		{
+			Name:                "no acceptable encoding",
+			AcceptEncoding:      "compress",
+			Body:                large,
+			ExpectedContentType: "text/plain; charset=utf-8",
+		},
+		{
+			Name:                "identity refused",
+			AcceptEncoding:      "identity;q=0, br;q=0.1",
+			Body:                large,
+			ExpectedEncoding:    headval.EncodingBr,
+			ExpectedContentType: "text/plain; charset=utf-8",
+		},
+		{
+			Name:                "below minimum length",
+			AcceptEncoding:      "gzip",
+			Body:                "short",
+			ExpectedContentType: "text/plain; charset=utf-8",
+		},
+		{
+			Name:                "strong etag weakened",
+			AcceptEncoding:      "gzip",
+			Headers:             map[string]string{headkey.ETag: `"v1"`},
+			Body:                large,
+			ExpectedEncoding:    headval.EncodingGzip,
+			ExpectedETag:        `W/"v1"`,
+			ExpectedContentType: "text/plain; charset=utf-8",
+		},
+		{
+			Name:                "weak etag kept",
+			AcceptEncoding:      "gzip",
+			Headers:             map[string]string{headkey.ETag: `W/"v1"`},
+			Body:                large,
+			ExpectedEncoding:    headval.EncodingGzip,
+			ExpectedETag:        `W/"v1"`,
+			ExpectedContentType: "text/plain; charset=utf-8",
+		},
+		{
+			Name:           "no-transform",
+			AcceptEncoding: "gzip",
+			Headers: map[string]string{
+				headkey.CacheControl: "public, No-Transform",
+				headkey.ETag:         `"v1"`,
+			},
+			Body:                large,
+			ExpectedETag:        `"v1"`,
+			ExpectedContentType: "text/plain; charset=utf-8",
+		},
+		{
+			Name:                "already compressed media type",
+			AcceptEncoding:      "gzip",
+			Headers:             map[string]string{headkey.ContentType: "video/mp4"},
+			Body:                large,
+			ExpectedContentType: "video/mp4",
+		},
+		{
+			Name:           "already encoded",
+			AcceptEncoding: "gzip",
+			Headers:        map[string]string{headkey.ContentEncoding: headval.EncodingBr},
+			Body:           large,
+			// Body is left untouched, as encoded by handler
+			ExpectedEncoding:    headval.EncodingBr,
+			ExpectedContentType: "text/plain; charset=utf-8",
+		},
+		{
+			Name:                 "partial content",
+			AcceptEncoding:       "gzip",
+			Headers:              map[string]string{headkey.ContentLength: "18432"},
+			Status:               http.StatusPartialContent,
+			Body:                 large,
+			ExpectedContentType:  "text/plain; charset=utf-8",
+			ExpectedLengthHeader: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			t.Parallel()
+
+			if tt.Conf.MinLength == 0 {
+				tt.Conf.MinLength = encoding.CompressionMinThreshold
+			}
+
+			handler := encoding.CompressMiddlewareWithConfig(tt.Conf)(
+				http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+					for key, value := range tt.Headers {
+						w.Header().Set(key, value)
+					}
+
+					if tt.Status != 0 {
+						w.WriteHeader(tt.Status)
+					}
+
+					// Write in chunks, crossing minimum length
+					for chunk := range strings.SplitSeq(tt.Body, " ") {
+						w.Write([]byte(chunk + " "))
+					}
+				}),
+			)
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Set(headkey.AcceptEncoding, tt.AcceptEncoding)
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, r)
+
+			h := rr.Result().Header
+
+			if h.Get(headkey.ContentEncoding) != tt.ExpectedEncoding {
+				t.Errorf("expected content encoding %q but was %q", tt.ExpectedEncoding, h.Get(headkey.ContentEncoding))
+			}
+
+			if h.Get(headkey.Vary) != headkey.AcceptEncoding {
+				t.Errorf("expected Vary %q but was %q", headkey.AcceptEncoding, h.Get(headkey.Vary))
+			}
+
+			if h.Get(headkey.ETag) != tt.ExpectedETag {
+				t.Errorf("expected ETag %q but was %q", tt.ExpectedETag, h.Get(headkey.ETag))
+			}
+
+			if h.Get(headkey.ContentType) != tt.ExpectedContentType {
+				t.Errorf("expected content type %q but was %q", tt.ExpectedContentType, h.Get(headkey.ContentType))
+			}
+
+			if (h.Get(headkey.ContentLength) != "") != tt.ExpectedLengthHeader {
+				t.Errorf("unexpected content length %q", h.Get(headkey.ContentLength))
+			}
+
+			body := rr.Body.Bytes()
+			if tt.ExpectedEncoding != "" && tt.Headers[headkey.ContentEncoding] == "" {
+				body = decode(t, tt.ExpectedEncoding, body)
+			}
+
+			// Chunks are written with a trailing space
+			expected := tt.Body + " "
+			if string(body) != expected {
+				t.Errorf("expected body of %d bytes but was %d bytes", len(expected), len(body))
+			}
+		})
+	}
+}
+
+func TestCompressMiddlewareFlush(t *testing.T) {
+	t.Parallel()
+
+	handler := encoding.CompressMiddleware(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set(headkey.ContentType, headval.MIMEApplicationNDJSON)
+		w.Write([]byte("{}\n"))
+		// Flushing commits the response to being compressed, even below minimum length
+		http.NewResponseController(w).Flush()
+		w.Write([]byte("{}\n"))
+	}))
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set(headkey.AcceptEncoding, headval.EncodingGzip)
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, r)
+
+	if !rr.Flushed {
+		t.Errorf("expected response to be flushed")
+	}
+
+	if rr.Header().Get(headkey.ContentEncoding) != headval.EncodingGzip {
+		t.Fatalf("expected content encoding %q but was %q", headval.EncodingGzip, rr.Header().Get(headkey.ContentEncoding))
+	}
+
+	body := decode(t, headval.EncodingGzip, rr.Body.Bytes())
+	if string(body) != "{}\n{}\n" {
+		t.Errorf("expected body %q but was %q", "{}\n{}\n", body)
+	}
+}
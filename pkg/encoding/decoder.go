@@ -0,0 +1,154 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package encoding
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+	"github.com/kemadev/go-framework/pkg/convenience/headval"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Decoder produces pooled readers decompressing a given content coding.
+type Decoder interface {
+	// Encoding returns the content coding token, as used in Content-Encoding header.
+	Encoding() string
+	// Acquire returns a reader decompressing data read from r. It may read from r, e.g. to parse a header.
+	Acquire(r io.Reader) (io.Reader, error)
+	// Release returns a reader obtained from Acquire to the pool. Reader must not be used afterwards.
+	Release(reader io.Reader)
+}
+
+// resetReader is a decompressing reader that can be reused once reset.
+type resetReader interface {
+	io.Reader
+	Reset(r io.Reader) error
+}
+
+type pooledDecoder struct {
+	encoding string
+	pool     sync.Pool
+}
+
+func newPooledDecoder(encoding string, newReader func() (resetReader, error)) *pooledDecoder {
+	return &pooledDecoder{
+		encoding: encoding,
+		pool: sync.Pool{
+			New: func() any {
+				r, err := newReader()
+				if err != nil {
+					return err
+				}
+
+				return r
+			},
+		},
+	}
+}
+
+// Encoding implements [Decoder].
+func (d *pooledDecoder) Encoding() string {
+	return d.encoding
+}
+
+// Acquire implements [Decoder].
+func (d *pooledDecoder) Acquire(r io.Reader) (io.Reader, error) {
+	pe := d.pool.Get()
+
+	reader, ok := pe.(resetReader)
+	if !ok || reader == nil {
+		if err, ok := pe.(error); ok {
+			return nil, fmt.Errorf("%w: %s reader: %w", ErrFailureGetFromPool, d.encoding, err)
+		}
+
+		return nil, fmt.Errorf("%w: %s reader", ErrFailureGetFromPool, d.encoding)
+	}
+
+	err := reader.Reset(r)
+	if err != nil {
+		d.pool.Put(reader)
+
+		return nil, err
+	}
+
+	return reader, nil
+}
+
+// Release implements [Decoder].
+func (d *pooledDecoder) Release(reader io.Reader) {
+	rr, ok := reader.(resetReader)
+	if !ok {
+		return
+	}
+
+	// Do not keep a reference to the request, error is expected as source is empty
+	_ = rr.Reset(strings.NewReader(""))
+	d.pool.Put(rr)
+}
+
+// NewGzipDecoder returns a [Decoder] for gzip coding.
+func NewGzipDecoder() Decoder {
+	return newPooledDecoder(headval.EncodingGzip, func() (resetReader, error) {
+		return new(gzip.Reader), nil
+	})
+}
+
+// NewDeflateDecoder returns a [Decoder] for deflate coding, that is a zlib-wrapped stream, see RFC 9110.
+func NewDeflateDecoder() Decoder {
+	return newPooledDecoder(headval.EncodingDeflate, func() (resetReader, error) {
+		return &zlibReader{}, nil
+	})
+}
+
+// NewBrotliDecoder returns a [Decoder] for br coding.
+func NewBrotliDecoder() Decoder {
+	return newPooledDecoder(headval.EncodingBr, func() (resetReader, error) {
+		return brotli.NewReader(strings.NewReader("")), nil
+	})
+}
+
+// NewZstdDecoder returns a [Decoder] for zstd coding. Frames requiring a window larger than 8MB are rejected,
+// bounding memory used per request.
+func NewZstdDecoder() Decoder {
+	return newPooledDecoder(headval.EncodingZstd, func() (resetReader, error) {
+		return zstd.NewReader(
+			nil,
+			zstd.WithDecoderConcurrency(1),
+			zstd.WithDecoderLowmem(true),
+			zstd.WithDecoderMaxWindow(zstdWindowSize),
+		)
+	})
+}
+
+// zlibReader adapts [compress/zlib] reader, which can't be created without reading a valid header.
+type zlibReader struct {
+	io.ReadCloser
+}
+
+// Reset implements resetReader.
+func (z *zlibReader) Reset(r io.Reader) error {
+	if z.ReadCloser == nil {
+		reader, err := zlib.NewReader(r)
+		if err != nil {
+			return err
+		}
+
+		z.ReadCloser = reader
+
+		return nil
+	}
+
+	resetter, ok := z.ReadCloser.(zlib.Resetter)
+	if !ok {
+		return fmt.Errorf("%w: zlib reader is not resettable", ErrFailureGetFromPool)
+	}
+
+	return resetter.Reset(r, nil)
+}
@@ -4,94 +4,278 @@
 package encoding
 
 import (
-	"compress/gzip"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
-	"sync"
+	"strings"
 
 	"github.com/kemadev/go-framework/pkg/convenience/headkey"
 	"github.com/kemadev/go-framework/pkg/convenience/headval"
 	"github.com/kemadev/go-framework/pkg/convenience/log"
 )
 
-// DecompressMiddleware returns a middleware that performs automatic decompression of request body when
-// content encoding is gzip. It is inspired from [echo's implementation].
+// DecompressConfig defines the configuration for decompression middleware.
+type DecompressConfig struct {
+	// Maximum decompressed body size, in bytes. Defaults to [DefaultMaxDecompressedSize].
+	MaxSize int64
+	// Maximum ratio between decompressed and compressed sizes, checked once [RatioCheckThreshold] bytes have been
+	// decompressed. Defaults to [DefaultMaxDecompressionRatio].
+	MaxRatio int64
+	// Decoders for supported content codings. Defaults to gzip, deflate, br and zstd.
+	Decoders []Decoder
+}
+
+const (
+	// DefaultMaxDecompressedSize is the default maximum decompressed body size.
+	DefaultMaxDecompressedSize = 32 << 20
+	// DefaultMaxDecompressionRatio is the default maximum decompression ratio, legitimate payloads rarely exceeding
+	// a few tens.
+	DefaultMaxDecompressionRatio = 100
+	// RatioCheckThreshold is the decompressed size from which decompression ratio is checked, so that small and
+	// highly redundant payloads are not rejected.
+	RatioCheckThreshold = 1 << 20
+	// maxStackedEncodings bounds the number of codings applied to a request body.
+	maxStackedEncodings = 4
+)
+
+var (
+	ErrDecompressedTooLarge  = errors.New("decompressed request body too large")
+	ErrDecompressionRatio    = errors.New("request body decompression ratio too high")
+	ErrUnsupportedEncoding   = errors.New("unsupported content encoding")
+	ErrTooManyEncodings      = errors.New("too many content encodings")
+	ErrInvalidCompressedBody = errors.New("invalid compressed request body")
+)
+
+// DecompressMiddleware returns a middleware that performs automatic decompression of request body,
+// supporting gzip, deflate, br and zstd codings, possibly stacked. It is inspired from [echo's implementation].
 //
 // [echo's implementation]: https://github.com/labstack/echo/blob/master/middleware/decompress.go
 func DecompressMiddleware(next http.Handler) http.Handler {
-	decompressPool := gzipDecompressPool()
+	return DecompressMiddlewareWithConfig(DecompressConfig{})(next)
+}
 
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if r.Header.Get(headkey.ContentEncoding) != headval.EncodingGzip {
-			next.ServeHTTP(w, r)
+// DecompressMiddlewareWithConfig returns a middleware with custom decompression configuration. Requests with
+// unsupported content codings are rejected with 415 status. Reading a body whose decompressed size or
+// decompression ratio exceeds configured limits returns an error wrapping [net/http.MaxBytesError], so that
+// handlers respond with 413 status as they would for wire size limits.
+func DecompressMiddlewareWithConfig(conf DecompressConfig) func(http.Handler) http.Handler {
+	if conf.MaxSize == 0 {
+		conf.MaxSize = DefaultMaxDecompressedSize
+	}
 
-			return
+	if conf.MaxRatio == 0 {
+		conf.MaxRatio = DefaultMaxDecompressionRatio
+	}
+
+	if len(conf.Decoders) == 0 {
+		conf.Decoders = []Decoder{
+			NewGzipDecoder(),
+			NewDeflateDecoder(),
+			NewBrotliDecoder(),
+			NewZstdDecoder(),
 		}
+	}
+
+	decoders := make(map[string]Decoder, len(conf.Decoders))
+	supported := make([]string, 0, len(conf.Decoders))
 
-		pe := decompressPool.Get()
-		defer decompressPool.Put(pe)
+	for _, decoder := range conf.Decoders {
+		decoders[decoder.Encoding()] = decoder
+		supported = append(supported, decoder.Encoding())
+	}
 
-		decompressReader, ok := pe.(*gzip.Reader)
-		if !ok || decompressReader == nil {
-			log.ErrLog(packageName, "error getting decompressor", ErrFailureGetFromPool)
-			http.Error(
-				w,
-				http.StatusText(http.StatusServiceUnavailable),
-				http.StatusServiceUnavailable,
-			)
+	acceptEncoding := strings.Join(supported, ", ")
 
-			return
-		}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			codings, err := contentCodings(r.Header)
+			if err != nil {
+				http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
 
-		body := r.Body
-		defer body.Close()
+				return
+			}
 
-		err := decompressReader.Reset(body)
-		if err != nil {
-			// Ignore empty body errors
-			if errors.Is(err, io.EOF) {
+			if len(codings) == 0 {
 				next.ServeHTTP(w, r)
 
 				return
 			}
 
-			var maxBytesErr *http.MaxBytesError
-			if errors.As(err, &maxBytesErr) {
-				http.Error(
-					w,
-					http.StatusText(http.StatusRequestEntityTooLarge),
-					http.StatusRequestEntityTooLarge,
-				)
+			for _, coding := range codings {
+				if _, ok := decoders[coding]; !ok {
+					// Advertise supported codings, see RFC 9110 section 12.5.3
+					w.Header().Set(headkey.AcceptEncoding, acceptEncoding)
+					http.Error(
+						w,
+						http.StatusText(http.StatusUnsupportedMediaType),
+						http.StatusUnsupportedMediaType,
+					)
 
-				return
+					return
+				}
+			}
+
+			body := r.Body
+			defer body.Close()
+
+			wire := &countingReader{reader: body}
+
+			var reader io.Reader = wire
+
+			// Codings are listed in the order they were applied, decode them the other way around
+			for i := len(codings) - 1; i >= 0; i-- {
+				decoder := decoders[codings[i]]
+
+				decoded, err := decoder.Acquire(reader)
+				if err != nil {
+					// Ignore empty body errors
+					if errors.Is(err, io.EOF) && wire.n == 0 {
+						break
+					}
+
+					status := decodeErrorStatus(err)
+					if status == http.StatusServiceUnavailable {
+						log.ErrLog(packageName, "error resetting body decompressor", err)
+					}
+
+					http.Error(w, http.StatusText(status), status)
+
+					return
+				}
+
+				defer decoder.Release(decoded)
+
+				reader = decoded
 			}
 
-			log.ErrLog(packageName, "error resetting body decompressor: %w", err)
-			http.Error(
-				w,
-				http.StatusText(http.StatusServiceUnavailable),
-				http.StatusServiceUnavailable,
-			)
+			r.Body = &decompressedBody{
+				ReadCloser: body,
+				reader: &guardReader{
+					reader:   reader,
+					wire:     wire,
+					maxSize:  conf.MaxSize,
+					maxRatio: conf.MaxRatio,
+				},
+			}
+
+			// Body is no longer encoded, and its length is unknown
+			r.Header.Del(headkey.ContentEncoding)
+			r.Header.Del(headkey.ContentLength)
+			r.ContentLength = -1
 
-			return
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// contentCodings returns content codings applied to body, in order of application, excluding identity.
+func contentCodings(h http.Header) ([]string, error) {
+	values := h.Values(headkey.ContentEncoding)
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	var codings []string
+
+	for _, coding := range strings.Split(strings.Join(values, ","), ",") {
+		coding = strings.ToLower(strings.TrimSpace(coding))
+
+		switch coding {
+		case "", headval.EncodingIdentity:
+			continue
+		// Alias, see RFC 9110 section 8.4.1.3
+		case "x-gzip":
+			coding = headval.EncodingGzip
 		}
 
-		// Only Close gzip reader if it was set to a proper gzip source to prevent panic on closure
-		defer decompressReader.Close()
+		codings = append(codings, coding)
+	}
+
+	if len(codings) > maxStackedEncodings {
+		return nil, ErrTooManyEncodings
+	}
+
+	return codings, nil
+}
+
+// decodeErrorStatus maps an error that occurred while setting up decompression to an HTTP status code.
+func decodeErrorStatus(err error) int {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return http.StatusRequestEntityTooLarge
+	}
+
+	if errors.Is(err, ErrFailureGetFromPool) {
+		return http.StatusServiceUnavailable
+	}
+
+	// Malformed compressed stream header
+	return http.StatusBadRequest
+}
+
+// countingReader counts bytes read from the wire.
+type countingReader struct {
+	reader io.Reader
+	n      int64
+}
+
+// Read implements [io.Reader].
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.reader.Read(p)
+	c.n += int64(n)
 
-		r.Body = decompressReader
+	return n, err
+}
 
-		next.ServeHTTP(w, r)
-	})
+// guardReader enforces decompressed size and ratio limits.
+type guardReader struct {
+	reader   io.Reader
+	wire     *countingReader
+	n        int64
+	maxSize  int64
+	maxRatio int64
+	err      error
 }
 
-func gzipDecompressPool() sync.Pool {
-	return sync.Pool{
-		New: func() any {
-			r := new(gzip.Reader)
+// Read implements [io.Reader].
+func (g *guardReader) Read(p []byte) (int, error) {
+	if g.err != nil {
+		return 0, g.err
+	}
+
+	n, err := g.reader.Read(p)
+	g.n += int64(n)
+
+	switch {
+	case g.maxSize > 0 && g.n > g.maxSize:
+		g.err = fmt.Errorf("%w: %w", ErrDecompressedTooLarge, &http.MaxBytesError{Limit: g.maxSize})
+	case g.maxRatio > 0 && g.n > RatioCheckThreshold && g.n > g.wire.n*g.maxRatio:
+		g.err = fmt.Errorf(
+			"%w: %w",
+			ErrDecompressionRatio,
+			&http.MaxBytesError{Limit: g.wire.n * g.maxRatio},
+		)
+	}
+
+	if g.err != nil {
+		return 0, g.err
+	}
 
-			return r
-		},
+	if err != nil && !errors.Is(err, io.EOF) {
+		return n, fmt.Errorf("%w: %w", ErrInvalidCompressedBody, err)
 	}
+
+	return n, err
+}
+
+// decompressedBody reads decompressed data, and closes original body.
+type decompressedBody struct {
+	io.ReadCloser
+	reader io.Reader
+}
+
+// Read implements [io.Reader].
+func (b *decompressedBody) Read(p []byte) (int, error) {
+	return b.reader.Read(p)
 }
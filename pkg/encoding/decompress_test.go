@@ -0,0 +1,226 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package encoding_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kemadev/go-framework/pkg/convenience/headkey"
+	"github.com/kemadev/go-framework/pkg/convenience/headval"
+	"github.com/kemadev/go-framework/pkg/encoding"
+)
+
+// encode returns [data] encoded with [codings], in order of application.
+func encode(t *testing.T, data []byte, codings ...string) []byte {
+	t.Helper()
+
+	encoders := map[string]encoding.Encoder{
+		headval.EncodingGzip:    encoding.NewGzipEncoder(gzip.BestSpeed),
+		headval.EncodingDeflate: encoding.NewDeflateEncoder(gzip.BestSpeed),
+		headval.EncodingBr:      encoding.NewBrotliEncoder(encoding.DefaultBrotliLevel),
+		headval.EncodingZstd:    encoding.NewZstdEncoder(encoding.DefaultZstdLevel),
+	}
+
+	for _, coding := range codings {
+		var buf bytes.Buffer
+
+		w, err := encoders[coding].Acquire(&buf)
+		if err != nil {
+			t.Fatalf("error acquiring %s writer: %s", coding, err)
+		}
+
+		_, err = w.Write(data)
+		if err == nil {
+			err = w.Close()
+		}
+
+		if err != nil {
+			t.Fatalf("error encoding with %s: %s", coding, err)
+		}
+
+		encoders[coding].Release(w)
+
+		data = buf.Bytes()
+	}
+
+	return data
+}
+
+func TestDecompressMiddleware(t *testing.T) {
+	t.Parallel()
+
+	text := []byte(strings.Repeat("some request payload ", 64))
+	zeros := make([]byte, 2<<20)
+
+	gzipped := encode(t, text, headval.EncodingGzip)
+
+	tests := []struct {
+		Name                   string
+		Conf                   encoding.DecompressConfig
+		ContentEncoding        string
+		Body                   []byte
+		ExpectedStatus         int
+		ExpectedBody           []byte
+		ExpectedErr            error
+		ExpectedAcceptEncoding string
+	}{
+		{
+			Name:           "not encoded",
+			Body:           text,
+			ExpectedStatus: http.StatusOK,
+			ExpectedBody:   text,
+		},
+		{
+			Name:            "identity",
+			ContentEncoding: headval.EncodingIdentity,
+			Body:            text,
+			ExpectedStatus:  http.StatusOK,
+			ExpectedBody:    text,
+		},
+		{
+			Name:            "gzip",
+			ContentEncoding: headval.EncodingGzip,
+			Body:            gzipped,
+			ExpectedStatus:  http.StatusOK,
+			ExpectedBody:    text,
+		},
+		{
+			Name:            "x-gzip alias",
+			ContentEncoding: "X-Gzip",
+			Body:            gzipped,
+			ExpectedStatus:  http.StatusOK,
+			ExpectedBody:    text,
+		},
+		{
+			Name:            "stacked codings",
+			ContentEncoding: "deflate, br, zstd",
+			Body:            encode(t, text, headval.EncodingDeflate, headval.EncodingBr, headval.EncodingZstd),
+			ExpectedStatus:  http.StatusOK,
+			ExpectedBody:    text,
+		},
+		{
+			Name:            "empty body",
+			ContentEncoding: headval.EncodingGzip,
+			ExpectedStatus:  http.StatusOK,
+			ExpectedBody:    []byte{},
+		},
+		{
+			Name:                   "unsupported coding",
+			ContentEncoding:        "gzip, compress",
+			Body:                   text,
+			ExpectedStatus:         http.StatusUnsupportedMediaType,
+			ExpectedAcceptEncoding: "gzip, deflate, br, zstd",
+		},
+		{
+			Name:            "too many codings",
+			ContentEncoding: "gzip, gzip, gzip, gzip, gzip",
+			Body:            text,
+			ExpectedStatus:  http.StatusBadRequest,
+		},
+		{
+			Name:            "malformed header",
+			ContentEncoding: headval.EncodingGzip,
+			Body:            text,
+			ExpectedStatus:  http.StatusBadRequest,
+		},
+		{
+			Name:            "truncated stream",
+			ContentEncoding: headval.EncodingGzip,
+			Body:            gzipped[:len(gzipped)/2],
+			ExpectedStatus:  http.StatusBadRequest,
+			ExpectedErr:     encoding.ErrInvalidCompressedBody,
+		},
+		{
+			Name:            "decompressed size limit",
+			Conf:            encoding.DecompressConfig{MaxSize: 512},
+			ContentEncoding: headval.EncodingGzip,
+			Body:            gzipped,
+			ExpectedStatus:  http.StatusRequestEntityTooLarge,
+			ExpectedErr:     encoding.ErrDecompressedTooLarge,
+		},
+		{
+			Name:            "ratio below threshold",
+			ContentEncoding: headval.EncodingGzip,
+			Body:            encode(t, zeros[:encoding.RatioCheckThreshold/2], headval.EncodingGzip),
+			ExpectedStatus:  http.StatusOK,
+			ExpectedBody:    zeros[:encoding.RatioCheckThreshold/2],
+		},
+		{
+			Name:            "ratio too high",
+			ContentEncoding: headval.EncodingGzip,
+			Body:            encode(t, zeros, headval.EncodingGzip),
+			ExpectedStatus:  http.StatusRequestEntityTooLarge,
+			ExpectedErr:     encoding.ErrDecompressionRatio,
+		},
+		{
+			Name:            "ratio within configured limit",
+			Conf:            encoding.DecompressConfig{MaxRatio: 10000},
+			ContentEncoding: headval.EncodingGzip,
+			Body:            encode(t, zeros, headval.EncodingGzip),
+			ExpectedStatus:  http.StatusOK,
+			ExpectedBody:    zeros,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			t.Parallel()
+
+			handler := encoding.DecompressMiddlewareWithConfig(tt.Conf)(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					if r.Header.Get(headkey.ContentEncoding) != "" && tt.ContentEncoding != headval.EncodingIdentity {
+						t.Errorf("expected Content-Encoding to be removed")
+					}
+
+					body, err := io.ReadAll(r.Body)
+					if !errors.Is(err, tt.ExpectedErr) {
+						t.Errorf("expected error %v but was %v", tt.ExpectedErr, err)
+					}
+
+					var maxBytesErr *http.MaxBytesError
+
+					switch {
+					case errors.As(err, &maxBytesErr):
+						w.WriteHeader(http.StatusRequestEntityTooLarge)
+					case err != nil:
+						w.WriteHeader(http.StatusBadRequest)
+					default:
+						w.Write(body)
+					}
+				}),
+			)
+
+			r := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(tt.Body))
+			if tt.ContentEncoding != "" {
+				r.Header.Set(headkey.ContentEncoding, tt.ContentEncoding)
+			}
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, r)
+
+			if rr.Code != tt.ExpectedStatus {
+				t.Errorf("expected status %d but was %d", tt.ExpectedStatus, rr.Code)
+			}
+
+			if rr.Header().Get(headkey.AcceptEncoding) != tt.ExpectedAcceptEncoding {
+				t.Errorf(
+					"expected Accept-Encoding %q but was %q",
+					tt.ExpectedAcceptEncoding,
+					rr.Header().Get(headkey.AcceptEncoding),
+				)
+			}
+
+			if tt.ExpectedBody != nil && !bytes.Equal(rr.Body.Bytes(), tt.ExpectedBody) {
+				t.Errorf("expected body of %d bytes but was %d bytes", len(tt.ExpectedBody), rr.Body.Len())
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package encoding
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+	"github.com/kemadev/go-framework/pkg/convenience/headval"
+	"github.com/klauspost/compress/zstd"
+)
+
+// zstdWindowSize bounds the memory a client needs to decode responses, keeping it reasonable for browsers.
+const zstdWindowSize = 8 << 20
+
+// Writer is a compressing writer that can be reused once reset.
+type Writer interface {
+	io.Writer
+	// Flush writes any pending compressed data to the underlying writer.
+	Flush() error
+	// Close flushes pending data and writes the stream trailer, without closing the underlying writer.
+	Close() error
+	// Reset discards writer state and makes it write to w.
+	Reset(w io.Writer)
+}
+
+// Encoder produces pooled [Writer] for a given content coding.
+type Encoder interface {
+	// Encoding returns the content coding token, as used in Content-Encoding header.
+	Encoding() string
+	// Acquire returns a [Writer] writing compressed data to w.
+	Acquire(w io.Writer) (Writer, error)
+	// Release returns a [Writer] obtained from Acquire to the pool. Writer must not be used afterwards.
+	Release(writer Writer)
+}
+
+type pooledEncoder struct {
+	encoding string
+	pool     sync.Pool
+}
+
+func newPooledEncoder(encoding string, newWriter func() (Writer, error)) *pooledEncoder {
+	return &pooledEncoder{
+		encoding: encoding,
+		pool: sync.Pool{
+			New: func() any {
+				w, err := newWriter()
+				if err != nil {
+					return err
+				}
+
+				return w
+			},
+		},
+	}
+}
+
+// Encoding implements [Encoder].
+func (e *pooledEncoder) Encoding() string {
+	return e.encoding
+}
+
+// Acquire implements [Encoder].
+func (e *pooledEncoder) Acquire(w io.Writer) (Writer, error) {
+	pe := e.pool.Get()
+
+	writer, ok := pe.(Writer)
+	if !ok || writer == nil {
+		if err, ok := pe.(error); ok {
+			return nil, fmt.Errorf("%w: %s writer: %w", ErrFailureGetFromPool, e.encoding, err)
+		}
+
+		return nil, fmt.Errorf("%w: %s writer", ErrFailureGetFromPool, e.encoding)
+	}
+
+	writer.Reset(w)
+
+	return writer, nil
+}
+
+// Release implements [Encoder].
+func (e *pooledEncoder) Release(writer Writer) {
+	// Do not keep a reference to the response
+	writer.Reset(io.Discard)
+	e.pool.Put(writer)
+}
+
+// NewGzipEncoder returns an [Encoder] for gzip coding, using given compression [level] from [compress/gzip].
+func NewGzipEncoder(level int) Encoder {
+	return newPooledEncoder(headval.EncodingGzip, func() (Writer, error) {
+		return gzip.NewWriterLevel(io.Discard, level)
+	})
+}
+
+// NewDeflateEncoder returns an [Encoder] for deflate coding, using given compression [level] from
+// [compress/zlib]. Deflate coding is a zlib-wrapped stream, see RFC 9110.
+func NewDeflateEncoder(level int) Encoder {
+	return newPooledEncoder(headval.EncodingDeflate, func() (Writer, error) {
+		return zlib.NewWriterLevel(io.Discard, level)
+	})
+}
+
+// NewBrotliEncoder returns an [Encoder] for br coding, using given compression [level] (0 to 11). Levels above
+// 6 are typically too slow for dynamic content.
+func NewBrotliEncoder(level int) Encoder {
+	return newPooledEncoder(headval.EncodingBr, func() (Writer, error) {
+		if level < brotli.BestSpeed || level > brotli.BestCompression {
+			return nil, fmt.Errorf("invalid brotli compression level: %d", level)
+		}
+
+		return brotli.NewWriterLevel(io.Discard, level), nil
+	})
+}
+
+// NewZstdEncoder returns an [Encoder] for zstd coding, using given compression [level]. Window size is capped
+// to 8MB as browsers are not required to decode larger windows.
+func NewZstdEncoder(level zstd.EncoderLevel) Encoder {
+	return newPooledEncoder(headval.EncodingZstd, func() (Writer, error) {
+		return zstd.NewWriter(
+			io.Discard,
+			zstd.WithEncoderLevel(level),
+			zstd.WithEncoderConcurrency(1),
+			zstd.WithWindowSize(zstdWindowSize),
+		)
+	})
+}
@@ -0,0 +1,96 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package iputil
+
+import (
+	"log/slog"
+	"net/http"
+	"net/netip"
+	"sync/atomic"
+
+	"github.com/kemadev/go-framework/pkg/convenience/log"
+	"github.com/kemadev/go-framework/pkg/convenience/resp"
+)
+
+const packageName = "github.com/kemadev/go-framework/pkg/iputil"
+
+// AccessConfig defines client access lists, see [AccessList].
+type AccessConfig struct {
+	// Prefixes of allowed clients, all clients being allowed if empty
+	Allow []netip.Prefix
+	// Prefixes of denied clients, taking precedence over allowed ones
+	Deny []netip.Prefix
+}
+
+// accessRules are compiled access lists.
+type accessRules struct {
+	allow *PrefixSet
+	// allowAll is set when no allowed prefix is configured
+	allowAll bool
+	deny     *PrefixSet
+}
+
+// AccessList enforces client access lists. Lists can be replaced while serving requests, see
+// [AccessList.Reload].
+type AccessList struct {
+	rules atomic.Pointer[accessRules]
+}
+
+// NewAccessList returns an access list enforcing [conf].
+func NewAccessList(conf AccessConfig) *AccessList {
+	l := &AccessList{}
+	l.Reload(conf)
+
+	return l
+}
+
+// Reload atomically replaces access lists with [conf], e.g. when lists are fetched from a dynamic source such as
+// a watched file, without disrupting in-flight requests.
+func (l *AccessList) Reload(conf AccessConfig) {
+	l.rules.Store(&accessRules{
+		allow:    NewPrefixSet(conf.Allow...),
+		allowAll: len(conf.Allow) == 0,
+		deny:     NewPrefixSet(conf.Deny...),
+	})
+}
+
+// Allowed returns whether [addr] is allowed, that is, it is not denied and either allowed or no client is
+// explicitly allowed.
+func (l *AccessList) Allowed(addr netip.Addr) bool {
+	rules := l.rules.Load()
+
+	if rules.deny.Contains(addr) {
+		return false
+	}
+
+	return rules.allowAll || rules.allow.Contains(addr)
+}
+
+// Middleware rejects requests from clients not allowed with 403 status, using problem details, see
+// [resp.ProblemJSON]. Clients are identified by request RemoteAddr, which should be resolved using
+// [github.com/kemadev/go-framework/pkg/realip.NewMiddleware] behind proxies. Requests whose RemoteAddr can't be
+// parsed are rejected.
+func (l *AccessList) Middleware(next http.Handler) http.Handler {
+	logger := log.Logger(packageName)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		addrPort, err := netip.ParseAddrPort(r.RemoteAddr)
+		if err == nil && l.Allowed(addrPort.Addr()) {
+			next.ServeHTTP(w, r)
+
+			return
+		}
+
+		logger.InfoContext(
+			r.Context(),
+			"client denied",
+			slog.String("client.address", r.RemoteAddr),
+		)
+
+		err = resp.ProblemJSON(w, resp.NewProblem(http.StatusForbidden, ""))
+		if err != nil {
+			log.ErrLog(packageName, "error sending problem", err)
+		}
+	})
+}
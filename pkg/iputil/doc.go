@@ -0,0 +1,7 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+// Package iputil provides IP address utilities built on [net/netip]: prefix sets with efficient containment
+// lookup and set operations (see [PrefixSet]), subnet splitting and allocation, and client access lists
+// enforced by a middleware (see [AccessList]).
+package iputil
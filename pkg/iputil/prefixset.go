@@ -0,0 +1,406 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package iputil
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+)
+
+var (
+	// ErrPrefixInvalid is returned when a prefix is invalid.
+	ErrPrefixInvalid = errors.New("prefix is invalid")
+	// ErrSplitInvalid is returned when a prefix can't be split into requested number of subnets.
+	ErrSplitInvalid = errors.New("split is invalid")
+	// ErrNoFreePrefix is returned when no free prefix of requested length is left.
+	ErrNoFreePrefix = errors.New("no free prefix")
+)
+
+// PrefixSet is a set of IP addresses, stored as prefixes in binary radix tries, one per address family.
+// Prefixes are aggregated as they are added, so that the set is always in its minimal form. IPv4-mapped IPv6
+// addresses are handled as IPv4 addresses. The zero value is an empty set. It is not safe for concurrent
+// modification.
+type PrefixSet struct {
+	v4 *trieNode
+	v6 *trieNode
+}
+
+// trieNode is a node of a binary radix trie, whose path from root is a prefix. Terminal nodes cover their
+// whole prefix, and have no children.
+type trieNode struct {
+	children [2]*trieNode
+	terminal bool
+}
+
+// NewPrefixSet returns a set holding [prefixes].
+func NewPrefixSet(prefixes ...netip.Prefix) *PrefixSet {
+	s := &PrefixSet{}
+
+	for _, p := range prefixes {
+		s.Add(p)
+	}
+
+	return s
+}
+
+// normalize returns [p] masked, with IPv4-mapped IPv6 prefixes converted to IPv4, or false if it is invalid.
+func normalize(p netip.Prefix) (netip.Prefix, bool) {
+	if !p.IsValid() {
+		return netip.Prefix{}, false
+	}
+
+	addr := p.Addr()
+	bits := p.Bits()
+
+	if addr.Is4In6() {
+		if bits < 96 {
+			// Prefix spans beyond IPv4-mapped range
+			return p.Masked(), true
+		}
+
+		addr = addr.Unmap()
+		bits -= 96
+	}
+
+	return netip.PrefixFrom(addr.WithZone(""), bits).Masked(), true
+}
+
+// root returns pointer to trie root of family of [addr].
+func (s *PrefixSet) root(addr netip.Addr) **trieNode {
+	if addr.Is4() {
+		return &s.v4
+	}
+
+	return &s.v6
+}
+
+// bit returns bit at [i] of [addr], from most significant one.
+func bit(addr netip.Addr, i int) int {
+	if addr.Is4() {
+		b := addr.As4()
+
+		return int(b[i/8]>>(7-i%8)) & 1
+	}
+
+	b := addr.As16()
+
+	return int(b[i/8]>>(7-i%8)) & 1
+}
+
+// Add adds addresses of [p] to set. Invalid prefixes are ignored.
+func (s *PrefixSet) Add(p netip.Prefix) {
+	p, ok := normalize(p)
+	if !ok {
+		return
+	}
+
+	root := s.root(p.Addr())
+	*root = add(*root, p, 0)
+}
+
+// add adds [p] to trie [n] at [depth], returning updated node.
+func add(n *trieNode, p netip.Prefix, depth int) *trieNode {
+	if n != nil && n.terminal {
+		return n
+	}
+
+	if depth == p.Bits() {
+		return &trieNode{terminal: true}
+	}
+
+	if n == nil {
+		n = &trieNode{}
+	}
+
+	b := bit(p.Addr(), depth)
+	n.children[b] = add(n.children[b], p, depth+1)
+
+	// Aggregate sibling prefixes
+	if n.children[0] != nil && n.children[0].terminal && n.children[1] != nil && n.children[1].terminal {
+		return &trieNode{terminal: true}
+	}
+
+	return n
+}
+
+// Remove removes addresses of [p] from set, splitting prefixes partially covered by [p]. Invalid prefixes are
+// ignored.
+func (s *PrefixSet) Remove(p netip.Prefix) {
+	p, ok := normalize(p)
+	if !ok {
+		return
+	}
+
+	root := s.root(p.Addr())
+	*root = remove(*root, p, 0)
+}
+
+// remove removes [p] from trie [n] at [depth], returning updated node.
+func remove(n *trieNode, p netip.Prefix, depth int) *trieNode {
+	if n == nil || depth == p.Bits() {
+		return nil
+	}
+
+	if n.terminal {
+		n = &trieNode{
+			children: [2]*trieNode{{terminal: true}, {terminal: true}},
+		}
+	}
+
+	b := bit(p.Addr(), depth)
+	n.children[b] = remove(n.children[b], p, depth+1)
+
+	if n.children[0] == nil && n.children[1] == nil {
+		return nil
+	}
+
+	return n
+}
+
+// Contains returns whether [addr] is in set.
+func (s *PrefixSet) Contains(addr netip.Addr) bool {
+	if !addr.IsValid() {
+		return false
+	}
+
+	addr = addr.Unmap().WithZone("")
+	n := *s.root(addr)
+
+	for i := 0; n != nil; i++ {
+		if n.terminal {
+			return true
+		}
+
+		n = n.children[bit(addr, i)]
+	}
+
+	return false
+}
+
+// ContainsPrefix returns whether all addresses of [p] are in set.
+func (s *PrefixSet) ContainsPrefix(p netip.Prefix) bool {
+	p, ok := normalize(p)
+	if !ok {
+		return false
+	}
+
+	n := *s.root(p.Addr())
+
+	for i := 0; n != nil; i++ {
+		if n.terminal {
+			return true
+		}
+
+		if i == p.Bits() {
+			return false
+		}
+
+		n = n.children[bit(p.Addr(), i)]
+	}
+
+	return false
+}
+
+// Overlaps returns whether any address of [p] is in set.
+func (s *PrefixSet) Overlaps(p netip.Prefix) bool {
+	p, ok := normalize(p)
+	if !ok {
+		return false
+	}
+
+	n := *s.root(p.Addr())
+
+	for i := 0; n != nil; i++ {
+		if n.terminal || i == p.Bits() {
+			return true
+		}
+
+		n = n.children[bit(p.Addr(), i)]
+	}
+
+	return false
+}
+
+// Prefixes returns the minimal list of prefixes covering set, IPv4 ones first, in address order.
+func (s *PrefixSet) Prefixes() []netip.Prefix {
+	var prefixes []netip.Prefix
+
+	collect(s.v4, [16]byte{}, 0, 32, &prefixes)
+	collect(s.v6, [16]byte{}, 0, 128, &prefixes)
+
+	return prefixes
+}
+
+// collect appends prefixes of terminal nodes of trie [n] at [depth], whose path is [path], to [prefixes].
+func collect(n *trieNode, path [16]byte, depth int, bitLen int, prefixes *[]netip.Prefix) {
+	if n == nil {
+		return
+	}
+
+	if n.terminal {
+		*prefixes = append(*prefixes, prefixFromPath(path, depth, bitLen))
+
+		return
+	}
+
+	collect(n.children[0], path, depth+1, bitLen, prefixes)
+
+	path[depth/8] |= 1 << (7 - depth%8)
+	collect(n.children[1], path, depth+1, bitLen, prefixes)
+}
+
+// prefixFromPath returns prefix of [bits] length whose address bits are [path], for an address family of
+// [bitLen] bits.
+func prefixFromPath(path [16]byte, bits int, bitLen int) netip.Prefix {
+	if bitLen == 32 {
+		return netip.PrefixFrom(netip.AddrFrom4([4]byte(path[:4])), bits)
+	}
+
+	return netip.PrefixFrom(netip.AddrFrom16(path), bits)
+}
+
+// Clone returns a copy of set.
+func (s *PrefixSet) Clone() *PrefixSet {
+	return NewPrefixSet(s.Prefixes()...)
+}
+
+// Union returns a set holding addresses of both set and [other].
+func (s *PrefixSet) Union(other *PrefixSet) *PrefixSet {
+	union := s.Clone()
+
+	for _, p := range other.Prefixes() {
+		union.Add(p)
+	}
+
+	return union
+}
+
+// Subtract returns a set holding addresses of set that are not in [other].
+func (s *PrefixSet) Subtract(other *PrefixSet) *PrefixSet {
+	diff := s.Clone()
+
+	for _, p := range other.Prefixes() {
+		diff.Remove(p)
+	}
+
+	return diff
+}
+
+// Intersect returns a set holding addresses both in set and [other].
+func (s *PrefixSet) Intersect(other *PrefixSet) *PrefixSet {
+	return s.Subtract(s.Subtract(other))
+}
+
+// Split splits [p] into [n] subnets of equal size, [n] being a power of two.
+func Split(p netip.Prefix, n int) ([]netip.Prefix, error) {
+	if !p.IsValid() {
+		return nil, fmt.Errorf("%w: %s", ErrPrefixInvalid, p)
+	}
+
+	if n <= 0 || n&(n-1) != 0 {
+		return nil, fmt.Errorf("%w: %d is not a power of two", ErrSplitInvalid, n)
+	}
+
+	extraBits := 0
+	for 1<<extraBits < n {
+		extraBits++
+	}
+
+	bits := p.Bits() + extraBits
+	if bits > p.Addr().BitLen() {
+		return nil, fmt.Errorf("%w: %s can't be split into %d subnets", ErrSplitInvalid, p, n)
+	}
+
+	subnets := make([]netip.Prefix, 0, n)
+	addr := p.Masked().Addr()
+
+	for range n {
+		subnet := netip.PrefixFrom(addr, bits)
+		subnets = append(subnets, subnet)
+		addr = nextAddr(subnet)
+	}
+
+	return subnets, nil
+}
+
+// nextAddr returns the first address following [p].
+func nextAddr(p netip.Prefix) netip.Addr {
+	b := p.Masked().Addr().As16()
+	offset := 128 - p.Addr().BitLen()
+
+	// Add one at prefix last bit, carrying over
+	for i := offset + p.Bits() - 1; i >= offset; i-- {
+		mask := byte(1 << (7 - i%8))
+		b[i/8] ^= mask
+
+		if b[i/8]&mask != 0 {
+			break
+		}
+	}
+
+	addr := netip.AddrFrom16(b)
+	if p.Addr().Is4() {
+		return addr.Unmap()
+	}
+
+	return addr
+}
+
+// NextFree returns the first prefix of [bits] length within [within] whose addresses are not in set, e.g. to
+// allocate subnets, set holding allocated ones.
+func (s *PrefixSet) NextFree(within netip.Prefix, bits int) (netip.Prefix, error) {
+	within, ok := normalize(within)
+	if !ok {
+		return netip.Prefix{}, fmt.Errorf("%w: %s", ErrPrefixInvalid, within)
+	}
+
+	if bits < within.Bits() || bits > within.Addr().BitLen() {
+		return netip.Prefix{}, fmt.Errorf("%w: /%d within %s", ErrPrefixInvalid, bits, within)
+	}
+
+	n := *s.root(within.Addr())
+	for i := 0; i < within.Bits() && n != nil; i++ {
+		if n.terminal {
+			return netip.Prefix{}, ErrNoFreePrefix
+		}
+
+		n = n.children[bit(within.Addr(), i)]
+	}
+
+	var path [16]byte
+	if within.Addr().Is4() {
+		a := within.Addr().As4()
+		copy(path[:], a[:])
+	} else {
+		path = within.Addr().As16()
+	}
+
+	found, ok := findFree(n, path, within.Bits(), bits)
+	if !ok {
+		return netip.Prefix{}, fmt.Errorf("%w: /%d within %s", ErrNoFreePrefix, bits, within)
+	}
+
+	return prefixFromPath(found, bits, within.Addr().BitLen()), nil
+}
+
+// findFree returns path of the first prefix of [bits] length under trie [n] at [depth], whose path is [path],
+// that holds no address.
+func findFree(n *trieNode, path [16]byte, depth int, bits int) ([16]byte, bool) {
+	switch {
+	case n == nil:
+		return path, true
+	case n.terminal || depth == bits:
+		return path, false
+	}
+
+	found, ok := findFree(n.children[0], path, depth+1, bits)
+	if ok {
+		return found, true
+	}
+
+	path[depth/8] |= 1 << (7 - depth%8)
+
+	return findFree(n.children[1], path, depth+1, bits)
+}
@@ -0,0 +1,218 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package iputil_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"slices"
+	"testing"
+
+	"github.com/kemadev/go-framework/pkg/iputil"
+)
+
+func prefixes(s ...string) []netip.Prefix {
+	result := make([]netip.Prefix, 0, len(s))
+	for _, p := range s {
+		result = append(result, netip.MustParsePrefix(p))
+	}
+
+	return result
+}
+
+func TestPrefixSet(t *testing.T) {
+	t.Parallel()
+
+	set := iputil.NewPrefixSet(prefixes(
+		"10.0.0.0/25",
+		"10.0.0.128/25",
+		"10.0.1.7/32",
+		"192.168.0.0/16",
+		"192.168.4.0/24",
+		"2001:db8::/32",
+	)...)
+
+	expected := prefixes("10.0.0.0/24", "10.0.1.7/32", "192.168.0.0/16", "2001:db8::/32")
+	if got := set.Prefixes(); !slices.Equal(got, expected) {
+		t.Errorf("expected aggregated prefixes %v; got %v", expected, got)
+	}
+
+	tests := []struct {
+		Addr     string
+		Expected bool
+	}{
+		{Addr: "10.0.0.200", Expected: true},
+		{Addr: "10.0.1.7", Expected: true},
+		{Addr: "10.0.1.8", Expected: false},
+		{Addr: "::ffff:192.168.3.4", Expected: true},
+		{Addr: "2001:db8:1::1", Expected: true},
+		{Addr: "2001:db9::1", Expected: false},
+	}
+
+	for _, test := range tests {
+		if got := set.Contains(netip.MustParseAddr(test.Addr)); got != test.Expected {
+			t.Errorf("%s: expected contained %t", test.Addr, test.Expected)
+		}
+	}
+
+	if !set.ContainsPrefix(netip.MustParsePrefix("192.168.128.0/17")) ||
+		set.ContainsPrefix(netip.MustParsePrefix("10.0.0.0/23")) {
+		t.Errorf("unexpected prefix containment")
+	}
+
+	if !set.Overlaps(netip.MustParsePrefix("10.0.0.0/23")) || set.Overlaps(netip.MustParsePrefix("172.16.0.0/12")) {
+		t.Errorf("unexpected prefix overlap")
+	}
+}
+
+func TestSetOperations(t *testing.T) {
+	t.Parallel()
+
+	a := iputil.NewPrefixSet(prefixes("10.0.0.0/24")...)
+	b := iputil.NewPrefixSet(prefixes("10.0.0.64/26", "10.0.1.0/24")...)
+
+	tests := []struct {
+		Name     string
+		Set      *iputil.PrefixSet
+		Expected []netip.Prefix
+	}{
+		{
+			Name:     "union",
+			Set:      a.Union(b),
+			Expected: prefixes("10.0.0.0/23"),
+		},
+		{
+			Name:     "subtract",
+			Set:      a.Subtract(b),
+			Expected: prefixes("10.0.0.0/26", "10.0.0.128/25"),
+		},
+		{
+			Name:     "intersect",
+			Set:      a.Intersect(b),
+			Expected: prefixes("10.0.0.64/26"),
+		},
+	}
+
+	for _, test := range tests {
+		if got := test.Set.Prefixes(); !slices.Equal(got, test.Expected) {
+			t.Errorf("%s: expected %v; got %v", test.Name, test.Expected, got)
+		}
+	}
+
+	// Operands are left untouched
+	if got := a.Prefixes(); !slices.Equal(got, prefixes("10.0.0.0/24")) {
+		t.Errorf("expected operand to be unchanged; got %v", got)
+	}
+}
+
+func TestSplit(t *testing.T) {
+	t.Parallel()
+
+	subnets, err := iputil.Split(netip.MustParsePrefix("10.0.0.0/22"), 4)
+	if err != nil {
+		t.Fatalf("Split: %s", err)
+	}
+
+	expected := prefixes("10.0.0.0/24", "10.0.1.0/24", "10.0.2.0/24", "10.0.3.0/24")
+	if !slices.Equal(subnets, expected) {
+		t.Errorf("expected %v; got %v", expected, subnets)
+	}
+
+	subnets, err = iputil.Split(netip.MustParsePrefix("2001:db8::/47"), 2)
+	if err != nil {
+		t.Fatalf("Split: %s", err)
+	}
+
+	expected = prefixes("2001:db8::/48", "2001:db8:1::/48")
+	if !slices.Equal(subnets, expected) {
+		t.Errorf("expected %v; got %v", expected, subnets)
+	}
+
+	_, err = iputil.Split(netip.MustParsePrefix("10.0.0.0/24"), 3)
+	if !errors.Is(err, iputil.ErrSplitInvalid) {
+		t.Errorf("expected error %v; got %v", iputil.ErrSplitInvalid, err)
+	}
+
+	_, err = iputil.Split(netip.MustParsePrefix("10.0.0.1/32"), 2)
+	if !errors.Is(err, iputil.ErrSplitInvalid) {
+		t.Errorf("expected error %v; got %v", iputil.ErrSplitInvalid, err)
+	}
+}
+
+func TestNextFree(t *testing.T) {
+	t.Parallel()
+
+	within := netip.MustParsePrefix("10.0.0.0/22")
+	allocated := iputil.NewPrefixSet(prefixes("10.0.0.0/24", "10.0.1.0/26")...)
+
+	tests := []struct {
+		Bits          int
+		Expected      netip.Prefix
+		ExpectedError error
+	}{
+		{Bits: 24, Expected: netip.MustParsePrefix("10.0.2.0/24")},
+		{Bits: 26, Expected: netip.MustParsePrefix("10.0.1.64/26")},
+		{Bits: 23, Expected: netip.MustParsePrefix("10.0.2.0/23")},
+		{Bits: 22, ExpectedError: iputil.ErrNoFreePrefix},
+		{Bits: 21, ExpectedError: iputil.ErrPrefixInvalid},
+	}
+
+	for _, test := range tests {
+		got, err := allocated.NextFree(within, test.Bits)
+		if !errors.Is(err, test.ExpectedError) {
+			t.Errorf("/%d: expected error %v; got %v", test.Bits, test.ExpectedError, err)
+		}
+
+		if got != test.Expected {
+			t.Errorf("/%d: expected %s; got %s", test.Bits, test.Expected, got)
+		}
+	}
+}
+
+func TestAccessList(t *testing.T) {
+	t.Parallel()
+
+	list := iputil.NewAccessList(iputil.AccessConfig{
+		Allow: prefixes("10.0.0.0/8"),
+		Deny:  prefixes("10.0.0.0/24"),
+	})
+
+	app := list.Middleware(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	do := func(remoteAddr string) int {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = remoteAddr
+
+		rr := httptest.NewRecorder()
+		app.ServeHTTP(rr, req)
+
+		return rr.Code
+	}
+
+	tests := []struct {
+		RemoteAddr     string
+		ExpectedStatus int
+	}{
+		{RemoteAddr: "10.1.0.1:1234", ExpectedStatus: http.StatusOK},
+		{RemoteAddr: "10.0.0.1:1234", ExpectedStatus: http.StatusForbidden},
+		{RemoteAddr: "192.0.2.1:1234", ExpectedStatus: http.StatusForbidden},
+		{RemoteAddr: "invalid", ExpectedStatus: http.StatusForbidden},
+	}
+
+	for _, test := range tests {
+		if got := do(test.RemoteAddr); got != test.ExpectedStatus {
+			t.Errorf("%s: expected status %d but was %d", test.RemoteAddr, test.ExpectedStatus, got)
+		}
+	}
+
+	list.Reload(iputil.AccessConfig{})
+
+	if got := do("192.0.2.1:1234"); got != http.StatusOK {
+		t.Errorf("expected all clients to be allowed after reload, status was %d", got)
+	}
+}
@@ -0,0 +1,8 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+// Package loadshed protects servers from overload by limiting inbound request concurrency with an adaptive,
+// latency-gradient limiter, shedding lower priority requests first when it is full. Request priority derives
+// from route [Class] annotations and the Priority header, see https://www.rfc-editor.org/rfc/rfc9218. Outbound
+// calls are rather limited using [github.com/kemadev/go-framework/pkg/otelfailsafe] adaptive limiters.
+package loadshed
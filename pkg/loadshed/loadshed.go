@@ -0,0 +1,213 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package loadshed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/failsafe-go/failsafe-go/adaptivelimiter"
+	"github.com/failsafe-go/failsafe-go/priority"
+	"github.com/kemadev/go-framework/pkg/convenience/headkey"
+	"github.com/kemadev/go-framework/pkg/convenience/log"
+	"github.com/kemadev/go-framework/pkg/convenience/resp"
+	"github.com/kemadev/go-framework/pkg/monitoring"
+	"github.com/kemadev/go-framework/pkg/router"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+)
+
+const packageName = "github.com/kemadev/go-framework/pkg/loadshed"
+
+const (
+	// DefaultMaxWait is the default maximum time requests wait for a permit.
+	DefaultMaxWait = time.Second
+	// DefaultRetryAfter is the default delay after which clients of shed requests should retry.
+	DefaultRetryAfter = time.Second
+	// DefaultDegradedFor is the default duration readiness is reported as degraded after shedding a request.
+	DefaultDegradedFor = 30 * time.Second
+	// calibrationInterval is the interval at which priority rejection threshold is adjusted.
+	calibrationInterval = time.Second
+)
+
+var ErrInvalidDuration = errors.New("duration must not be negative")
+
+// Config defines the configuration for load shedding middleware.
+type Config struct {
+	// Builder of adaptive limiter, defaults to [adaptivelimiter.NewBuilder]. Use
+	// [github.com/kemadev/go-framework/pkg/otelfailsafe.PolicyEngine.NewAdaptiveLimiterBuilder] to record limit
+	// changes
+	Builder adaptivelimiter.Builder[any]
+	// Router used to find [Class] of requests routes, all requests being of [DefaultClass] if nil
+	Router *router.Router
+	// Maximum time requests are queued for when limiter is full, defaults to [DefaultMaxWait]
+	MaxWait time.Duration
+	// Delay after which clients of shed requests should retry, defaults to [DefaultRetryAfter]
+	RetryAfter time.Duration
+	// Duration readiness is reported as degraded after shedding a request, defaults to [DefaultDegradedFor]
+	DegradedFor time.Duration
+	// Skip returns whether limiting should be bypassed for given request. As clients could use it to bypass
+	// shedding, it must not rely on request headers, prefer [Exempt] annotations
+	Skip func(r *http.Request) bool
+}
+
+// Shedder limits inbound request concurrency, shedding requests when server is overloaded.
+type Shedder struct {
+	conf    Config
+	limiter adaptivelimiter.PriorityLimiter[any]
+	// stopCalibrations stops prioritizer calibrations
+	stopCalibrations context.CancelFunc
+	// lastShed is the time last request was shed, in nanoseconds since Unix epoch
+	lastShed   atomic.Int64
+	rejections metric.Int64Counter
+}
+
+// NewShedder returns a shedder enforcing [conf]. Its prioritizer is calibrated in background until
+// [Shedder.Close] is called.
+func NewShedder(conf Config) (*Shedder, error) {
+	if conf.MaxWait < 0 || conf.RetryAfter < 0 || conf.DegradedFor < 0 {
+		return nil, fmt.Errorf("%w: %+v", ErrInvalidDuration, conf)
+	}
+
+	if conf.Builder == nil {
+		conf.Builder = adaptivelimiter.NewBuilder[any]()
+	}
+
+	if conf.MaxWait == 0 {
+		conf.MaxWait = DefaultMaxWait
+	}
+
+	if conf.RetryAfter == 0 {
+		conf.RetryAfter = DefaultRetryAfter
+	}
+
+	if conf.DegradedFor == 0 {
+		conf.DegradedFor = DefaultDegradedFor
+	}
+
+	rejections, err := otel.GetMeterProvider().Meter(packageName).Int64Counter(
+		"http.server.load_shed.rejections",
+		metric.WithDescription("Number of requests rejected as server is overloaded"),
+		metric.WithUnit("{request}"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error creating rejections counter: %w", err)
+	}
+
+	prioritizer := adaptivelimiter.NewPrioritizer()
+
+	return &Shedder{
+		conf:             conf,
+		limiter:          conf.Builder.BuildPrioritized(prioritizer),
+		stopCalibrations: prioritizer.ScheduleCalibrations(context.Background(), calibrationInterval),
+		rejections:       rejections,
+	}, nil
+}
+
+// Close stops background prioritizer calibrations.
+func (s *Shedder) Close() {
+	s.stopCalibrations()
+}
+
+// Middleware limits concurrency of requests, queuing them for up to configured maximum wait time when limiter
+// is full. Lower priority requests are shed first, with 503 status along with Retry-After header, using problem
+// details, see [resp.ProblemJSON], and counted in http.server.load_shed.rejections metric. Request priority is
+// added to its context, see [priority.FromContext], so that handlers can propagate it to outbound calls.
+func (s *Shedder) Middleware(next http.Handler) http.Handler {
+	retryAfter := strconv.FormatFloat(math.Ceil(s.conf.RetryAfter.Seconds()), 'f', 0, 64)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.conf.Skip != nil && s.conf.Skip(r) {
+			next.ServeHTTP(w, r)
+
+			return
+		}
+
+		class := DefaultClass
+
+		var pattern string
+
+		if s.conf.Router != nil {
+			route, found := s.conf.Router.Lookup(r)
+			if found {
+				if RouteExempt(route) {
+					next.ServeHTTP(w, r)
+
+					return
+				}
+
+				class = RouteClass(route)
+				pattern = route.Pattern
+			}
+		}
+
+		prio := requestPriority(class, r)
+		ctx := priority.ContextWithPriority(r.Context(), prio)
+
+		permit, err := s.limiter.AcquirePermitWithMaxWait(
+			priority.ContextWithLevel(ctx, prio.RandomLevel()),
+			s.conf.MaxWait,
+		)
+		if err == nil {
+			defer permit.Record()
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+
+			return
+		}
+
+		// Client went away while waiting for a permit
+		if !errors.Is(err, adaptivelimiter.ErrExceeded) {
+			return
+		}
+
+		s.lastShed.Store(time.Now().UnixNano())
+		s.rejections.Add(
+			r.Context(),
+			1,
+			metric.WithAttributes(
+				attribute.Int("loadshed.priority", int(prio)),
+				semconv.HTTPRoute(pattern),
+			),
+		)
+
+		w.Header().Set(headkey.RetryAfter, retryAfter)
+
+		err = resp.ProblemJSON(w, resp.NewProblem(http.StatusServiceUnavailable, ""))
+		if err != nil {
+			log.ErrLog(packageName, "error sending problem", err)
+		}
+	})
+}
+
+// Check returns [monitoring.StatusDegraded] while shedding requests, that is, when a request was shed within
+// configured degraded duration, and [monitoring.StatusOK] otherwise. It is meant to be used as a readiness
+// check, see [monitoring.ReadinessHandler].
+func (s *Shedder) Check() monitoring.StatusCheck {
+	lastShed := s.lastShed.Load()
+	if lastShed != 0 && time.Since(time.Unix(0, lastShed)) < s.conf.DegradedFor {
+		return monitoring.StatusCheck{
+			Status: monitoring.StatusDegraded,
+			Message: fmt.Sprintf(
+				"shedding load, limit %d, inflight %d, queued %d",
+				s.limiter.Limit(),
+				s.limiter.Inflight(),
+				s.limiter.Queued(),
+			),
+		}
+	}
+
+	return monitoring.StatusCheck{
+		Status:  monitoring.StatusOK,
+		Message: monitoring.StatusOK.String(),
+	}
+}
@@ -0,0 +1,210 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package loadshed_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/failsafe-go/failsafe-go/adaptivelimiter"
+	"github.com/failsafe-go/failsafe-go/priority"
+	"github.com/kemadev/go-framework/pkg/convenience/headkey"
+	"github.com/kemadev/go-framework/pkg/loadshed"
+	"github.com/kemadev/go-framework/pkg/monitoring"
+	"github.com/kemadev/go-framework/pkg/router"
+)
+
+func newShedder(t *testing.T, conf loadshed.Config) *loadshed.Shedder {
+	t.Helper()
+
+	s, err := loadshed.NewShedder(conf)
+	if err != nil {
+		t.Fatalf("NewShedder: %s", err)
+	}
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+func TestMiddleware(t *testing.T) {
+	t.Parallel()
+
+	s := newShedder(t, loadshed.Config{
+		Builder: adaptivelimiter.NewBuilder[any]().WithLimits(1, 1, 1),
+		MaxWait: 10 * time.Millisecond,
+	})
+
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	app := s.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			entered <- struct{}{}
+			<-release
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		app.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/slow", nil))
+	}()
+	<-entered
+
+	if s.Check().Status != monitoring.StatusOK {
+		t.Errorf("expected status %s before shedding but was %s", monitoring.StatusOK, s.Check().Status)
+	}
+
+	rr := httptest.NewRecorder()
+	app.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected status %d but was %d", http.StatusServiceUnavailable, rr.Code)
+	}
+
+	if rr.Header().Get(headkey.RetryAfter) != "1" {
+		t.Errorf("expected Retry-After %q but was %q", "1", rr.Header().Get(headkey.RetryAfter))
+	}
+
+	if s.Check().Status != monitoring.StatusDegraded {
+		t.Errorf("expected status %s while shedding but was %s", monitoring.StatusDegraded, s.Check().Status)
+	}
+
+	close(release)
+	<-done
+
+	rr = httptest.NewRecorder()
+	app.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if rr.Code != http.StatusOK {
+		t.Errorf("expected status %d once permit is released but was %d", http.StatusOK, rr.Code)
+	}
+}
+
+func TestPriority(t *testing.T) {
+	t.Parallel()
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Priority", strconv.Itoa(int(priority.FromContext(r.Context()))))
+		w.WriteHeader(http.StatusOK)
+	}
+
+	app := router.New()
+	app.Use(newShedder(t, loadshed.Config{Router: app}).Middleware)
+
+	app.HandleFunc("GET /default", handler)
+	app.Group(func(r *router.Router) {
+		r.Annotate(loadshed.Class(priority.High))
+		r.HandleFunc("GET /high", handler)
+		r.HandleFunc("GET /batch", handler, loadshed.Class(priority.VeryLow))
+	})
+
+	tests := []struct {
+		RequestPath      string
+		PriorityHeader   string
+		ExpectedPriority priority.Priority
+	}{
+		{RequestPath: "/default", ExpectedPriority: priority.Medium},
+		{RequestPath: "/high", ExpectedPriority: priority.High},
+		{RequestPath: "/batch", ExpectedPriority: priority.VeryLow},
+		{RequestPath: "/high", PriorityHeader: "u=0", ExpectedPriority: priority.High},
+		{RequestPath: "/high", PriorityHeader: "u=5, i", ExpectedPriority: priority.Low},
+		{RequestPath: "/default", PriorityHeader: "u=4;x", ExpectedPriority: priority.Low},
+		{RequestPath: "/default", PriorityHeader: "u=9", ExpectedPriority: priority.Medium},
+		{RequestPath: "/default", PriorityHeader: "i", ExpectedPriority: priority.Medium},
+		{RequestPath: "/batch", PriorityHeader: "u=7", ExpectedPriority: priority.VeryLow},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.RequestPath+" "+tt.PriorityHeader, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(http.MethodGet, tt.RequestPath, nil)
+			if tt.PriorityHeader != "" {
+				req.Header.Set(headkey.Priority, tt.PriorityHeader)
+			}
+
+			rr := httptest.NewRecorder()
+			app.ServeHTTP(rr, req)
+
+			if rr.Header().Get("X-Priority") != strconv.Itoa(int(tt.ExpectedPriority)) {
+				t.Errorf(
+					"expected priority %d but was %s",
+					tt.ExpectedPriority,
+					rr.Header().Get("X-Priority"),
+				)
+			}
+		})
+	}
+}
+
+func TestExempt(t *testing.T) {
+	t.Parallel()
+
+	entered := make(chan struct{})
+	release := make(chan struct{})
+
+	app := router.New()
+	app.Use(newShedder(t, loadshed.Config{
+		Builder: adaptivelimiter.NewBuilder[any]().WithLimits(1, 1, 1),
+		Router:  app,
+		MaxWait: 10 * time.Millisecond,
+	}).Middleware)
+
+	app.HandleFunc("GET /slow", func(w http.ResponseWriter, _ *http.Request) {
+		entered <- struct{}{}
+		<-release
+		w.WriteHeader(http.StatusOK)
+	})
+	app.HandleFunc("GET /limited", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	app.HandleFunc("GET /events", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}, loadshed.Exempt())
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		app.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/slow", nil))
+	}()
+	<-entered
+
+	defer func() {
+		close(release)
+		<-done
+	}()
+
+	tests := []struct {
+		RequestPath    string
+		Header         map[string]string
+		ExpectedStatus int
+	}{
+		{RequestPath: "/events", ExpectedStatus: http.StatusOK},
+		{RequestPath: "/limited", ExpectedStatus: http.StatusServiceUnavailable},
+		{
+			RequestPath:    "/limited",
+			Header:         map[string]string{headkey.Accept: "text/event-stream", headkey.Upgrade: "websocket"},
+			ExpectedStatus: http.StatusServiceUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, tt.RequestPath, nil)
+		for k, v := range tt.Header {
+			req.Header.Set(k, v)
+		}
+
+		rr := httptest.NewRecorder()
+		app.ServeHTTP(rr, req)
+
+		if rr.Code != tt.ExpectedStatus {
+			t.Errorf("%s: expected status %d but was %d", tt.RequestPath, tt.ExpectedStatus, rr.Code)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package loadshed
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/failsafe-go/failsafe-go/priority"
+	"github.com/kemadev/go-framework/pkg/convenience/headkey"
+	"github.com/kemadev/go-framework/pkg/router"
+)
+
+// DefaultClass is the class of routes lacking [Class] annotation.
+const DefaultClass = Class(priority.Medium)
+
+// defaultUrgency is the urgency of requests lacking Priority header, see
+// https://www.rfc-editor.org/rfc/rfc9218#section-4.1.
+const defaultUrgency = 3
+
+// maxUrgency is the lowest urgency, see https://www.rfc-editor.org/rfc/rfc9218#section-4.1.
+const maxUrgency = 7
+
+// Class is a route class, to be attached to routes and groups using [router.Router.Annotate] or route
+// annotations, see [router.Router.Handle]. It sets the priority of requests to route, lower priority requests
+// being shed first. When several classes are attached to a route, the last one applies.
+type Class priority.Priority
+
+// Exemption exempts a route from load shedding, see [Exempt].
+type Exemption struct{}
+
+// Exempt returns an annotation exempting a route from load shedding, to be attached to routes and groups using
+// [router.Router.Annotate] or route annotations, see [router.Router.Handle]. It suits long-lived streams, whose
+// latency doesn't reflect load, and monitoring endpoints, which must remain reachable.
+func Exempt() Exemption {
+	return Exemption{}
+}
+
+// RouteExempt returns whether [route] is exempted from load shedding.
+func RouteExempt(route router.Route) bool {
+	for _, annotation := range route.Annotations {
+		_, ok := annotation.(Exemption)
+		if ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// RouteClass returns class attached to [route], or [DefaultClass].
+func RouteClass(route router.Route) Class {
+	class := DefaultClass
+
+	for _, annotation := range route.Annotations {
+		c, ok := annotation.(Class)
+		if ok {
+			class = c
+		}
+	}
+
+	return class
+}
+
+// requestPriority returns priority of [r], given its route [class]. As Priority header is set by clients, it
+// can only lower priority: each urgency level below default one lowers priority by one level.
+func requestPriority(class Class, r *http.Request) priority.Priority {
+	p := priority.Priority(class) - priority.Priority(max(0, urgency(r.Header)-defaultUrgency))
+
+	return max(priority.VeryLow, min(p, priority.VeryHigh))
+}
+
+// urgency returns urgency parameter of Priority header in [h], or default urgency when it is missing or invalid.
+// As in any structured field dictionary, last member wins.
+func urgency(h http.Header) int {
+	u := defaultUrgency
+
+	for _, value := range h.Values(headkey.Priority) {
+		for member := range strings.SplitSeq(value, ",") {
+			key, val, found := strings.Cut(strings.TrimSpace(member), "=")
+			if !found || key != "u" {
+				continue
+			}
+
+			// Parameters are not part of urgency, e.g. "u=5;foo"
+			val, _, _ = strings.Cut(val, ";")
+
+			parsed, err := strconv.Atoi(val)
+			if err != nil || parsed < 0 || parsed > maxUrgency {
+				u = defaultUrgency
+
+				continue
+			}
+
+			u = parsed
+		}
+	}
+
+	return u
+}
@@ -0,0 +1,8 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+// Package ratelimit limits inbound request rates per client, identified by a [KeyFunc] such as client IP or
+// authenticated principal. Limits are enforced using token bucket or sliding window algorithms, in memory (see
+// [NewLocalStore]) or in valkey so that they are shared between instances (see [NewValkeyStore]). Outbound
+// calls are rather limited using [github.com/kemadev/go-framework/pkg/otelfailsafe] rate limiters.
+package ratelimit
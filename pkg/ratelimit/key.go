@@ -0,0 +1,95 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package ratelimit
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/netip"
+	"slices"
+
+	"github.com/kemadev/go-framework/pkg/auth"
+	"github.com/kemadev/go-framework/pkg/convenience/req"
+)
+
+// DefaultIPv6PrefixLength is the default length of IPv6 prefixes clients are keyed by, as ISPs commonly assign
+// a /64 to each customer.
+const DefaultIPv6PrefixLength = 64
+
+// ErrNoClientAddress is returned when request client address can't be determined.
+var ErrNoClientAddress = errors.New("no client address")
+
+// KeyFunc returns key identifying client of [r], whose requests are counted together.
+type KeyFunc func(r *http.Request) (string, error)
+
+// IPKeyConfig defines the configuration for client IP keys. Behind proxies, clients are best resolved using
+// [github.com/kemadev/go-framework/pkg/realip.NewMiddleware], leaving configuration empty.
+type IPKeyConfig struct {
+	// Header carrying client IP, in Forwarded format, see [req.IP] and
+	// [github.com/kemadev/go-framework/pkg/config.Server] ProxyHeader. It is only read for requests from
+	// trusted proxies, which must overwrite it.
+	ProxyHeader string
+	// Prefixes of proxies whose header is trusted, other requests being keyed by connection peer address
+	TrustedProxies []netip.Prefix
+	// Length of IPv6 prefixes clients are keyed by, defaults to [DefaultIPv6PrefixLength]
+	IPv6PrefixLength int
+}
+
+// KeyByIP returns a function keying requests by client IP.
+func KeyByIP(conf IPKeyConfig) KeyFunc {
+	if conf.IPv6PrefixLength == 0 {
+		conf.IPv6PrefixLength = DefaultIPv6PrefixLength
+	}
+
+	return func(r *http.Request) (string, error) {
+		addrPort, err := netip.ParseAddrPort(r.RemoteAddr)
+		if err != nil {
+			return "", fmt.Errorf("%w: %w", ErrNoClientAddress, err)
+		}
+
+		addr := addrPort.Addr().Unmap()
+
+		trusted := slices.ContainsFunc(conf.TrustedProxies, func(prefix netip.Prefix) bool {
+			return prefix.Contains(addr)
+		})
+		if trusted && conf.ProxyHeader != "" {
+			ip, err := req.IP(r, conf.ProxyHeader)
+			if err != nil {
+				return "", fmt.Errorf("%w: %w", ErrNoClientAddress, err)
+			}
+
+			forwarded, ok := netip.AddrFromSlice(ip)
+			if !ok {
+				return "", ErrNoClientAddress
+			}
+
+			addr = forwarded.Unmap()
+		}
+
+		if addr.Is6() {
+			prefix, err := addr.Prefix(conf.IPv6PrefixLength)
+			if err != nil {
+				return "", fmt.Errorf("%w: %w", ErrNoClientAddress, err)
+			}
+
+			return "ip:" + prefix.String(), nil
+		}
+
+		return "ip:" + addr.String(), nil
+	}
+}
+
+// KeyByPrincipal returns a function keying requests by authenticated principal, see [auth.FromContext], and
+// unauthenticated ones using [fallback].
+func KeyByPrincipal(fallback KeyFunc) KeyFunc {
+	return func(r *http.Request) (string, error) {
+		principal, ok := auth.FromContext(r.Context())
+		if ok {
+			return "principal:" + principal.ID, nil
+		}
+
+		return fallback(r)
+	}
+}
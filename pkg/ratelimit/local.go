@@ -0,0 +1,81 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepInterval is the interval between removals of expired local entries.
+const sweepInterval = time.Minute
+
+// LocalStore counts requests in memory. As counts are not shared between instances, limits apply per instance.
+type LocalStore struct {
+	mu        sync.Mutex
+	entries   map[string]*localEntry
+	nextSweep time.Time
+}
+
+// localEntry holds state of a key, for a single algorithm.
+type localEntry struct {
+	tokenBucket   tokenBucketState
+	slidingWindow slidingWindowState
+	expires       time.Time
+}
+
+// NewLocalStore returns a store counting requests in memory.
+func NewLocalStore() *LocalStore {
+	return &LocalStore{
+		entries: make(map[string]*localEntry),
+	}
+}
+
+// Take implements [Store].
+func (s *LocalStore) Take(_ context.Context, key string, policy Policy) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.sweep(now)
+
+	key = policy.Name + ":" + key
+
+	entry, exists := s.entries[key]
+	if !exists {
+		entry = &localEntry{}
+		s.entries[key] = entry
+	}
+
+	var res Result
+
+	switch policy.Algorithm {
+	case TokenBucket:
+		res = entry.tokenBucket.take(now, policy)
+		// Bucket is full once reset, which is the same as having no state
+		entry.expires = now.Add(res.Reset)
+	case SlidingWindow:
+		res = entry.slidingWindow.take(now, policy)
+		// Current window count weighs on next window
+		entry.expires = entry.slidingWindow.start.Add(2 * policy.Window)
+	}
+
+	return res, nil
+}
+
+// sweep removes expired entries, at most once per [sweepInterval].
+func (s *LocalStore) sweep(now time.Time) {
+	if now.Before(s.nextSweep) {
+		return
+	}
+
+	for key, entry := range s.entries {
+		if now.After(entry.expires) {
+			delete(s.entries, key)
+		}
+	}
+
+	s.nextSweep = now.Add(sweepInterval)
+}
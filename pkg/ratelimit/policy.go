@@ -0,0 +1,162 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+)
+
+// Algorithm is a rate limiting algorithm.
+type Algorithm int
+
+const (
+	// TokenBucket allows bursts of Limit requests, tokens being refilled at a rate of Limit per Window.
+	TokenBucket Algorithm = iota
+	// SlidingWindow allows Limit requests in any Window, approximated by weighting previous fixed window count.
+	SlidingWindow
+)
+
+var (
+	// ErrInvalidPolicy is returned when a policy has no limit or window.
+	ErrInvalidPolicy = errors.New("invalid rate limit policy")
+	// ErrStore is returned by stores when limits can't be checked, e.g. as backend is unreachable.
+	ErrStore = errors.New("rate limit store error")
+)
+
+// Policy is a rate limit.
+type Policy struct {
+	// Policy name, used in metrics and keys, so that policies sharing a store don't share counters
+	Name string
+	// Algorithm enforcing limit
+	Algorithm Algorithm
+	// Number of requests allowed per window
+	Limit int
+	// Window duration
+	Window time.Duration
+}
+
+// validate returns an error if policy can't be enforced.
+func (p Policy) validate() error {
+	if p.Limit <= 0 || p.Window <= 0 {
+		return ErrInvalidPolicy
+	}
+
+	if p.Algorithm != TokenBucket && p.Algorithm != SlidingWindow {
+		return ErrInvalidPolicy
+	}
+
+	return nil
+}
+
+// Result is the outcome of a rate limit check.
+type Result struct {
+	// Whether request is allowed
+	Allowed bool
+	// Number of requests still allowed
+	Remaining int
+	// Duration until quota is fully restored
+	Reset time.Duration
+	// Duration until a request is allowed, for rejected requests
+	RetryAfter time.Duration
+}
+
+// Store enforces policies, counting requests of each key.
+type Store interface {
+	// Take counts a request of [key] against [policy], returning whether it is allowed. It returns an error
+	// wrapping [ErrStore] when request can't be counted.
+	Take(ctx context.Context, key string, policy Policy) (Result, error)
+}
+
+// tokenBucketState is the state of a token bucket.
+type tokenBucketState struct {
+	tokens  float64
+	updated time.Time
+}
+
+// take counts a request at [now] against [policy], updating state.
+func (s *tokenBucketState) take(now time.Time, policy Policy) Result {
+	capacity := float64(policy.Limit)
+	// Tokens per nanosecond
+	rate := capacity / float64(policy.Window)
+
+	if s.updated.IsZero() {
+		s.tokens = capacity
+	} else {
+		s.tokens = math.Min(capacity, s.tokens+float64(now.Sub(s.updated))*rate)
+	}
+
+	s.updated = now
+
+	res := Result{
+		Allowed: s.tokens >= 1,
+	}
+
+	if res.Allowed {
+		s.tokens--
+	} else {
+		res.RetryAfter = time.Duration(math.Ceil((1 - s.tokens) / rate))
+	}
+
+	res.Remaining = int(s.tokens)
+	res.Reset = time.Duration(math.Ceil((capacity - s.tokens) / rate))
+
+	return res
+}
+
+// slidingWindowState is the state of a sliding window.
+type slidingWindowState struct {
+	start    time.Time
+	previous int
+	current  int
+}
+
+// take counts a request at [now] against [policy], updating state.
+func (s *slidingWindowState) take(now time.Time, policy Policy) Result {
+	elapsed := now.Sub(s.start)
+
+	switch {
+	case s.start.IsZero() || elapsed >= 2*policy.Window:
+		s.start = now.Truncate(policy.Window)
+		s.previous, s.current = 0, 0
+	case elapsed >= policy.Window:
+		s.start = s.start.Add(policy.Window)
+		s.previous, s.current = s.current, 0
+	}
+
+	elapsed = now.Sub(s.start)
+	weight := 1 - float64(elapsed)/float64(policy.Window)
+	estimated := float64(s.previous)*weight + float64(s.current)
+
+	res := Result{
+		Allowed: estimated+1 <= float64(policy.Limit),
+		Reset:   policy.Window - elapsed,
+	}
+
+	if res.Allowed {
+		s.current++
+		estimated++
+	} else {
+		res.RetryAfter = slidingWindowRetryAfter(s.previous, s.current, elapsed, policy)
+	}
+
+	res.Remaining = max(0, policy.Limit-int(math.Ceil(estimated)))
+
+	return res
+}
+
+// slidingWindowRetryAfter returns duration after which previous window weight has decreased enough for a
+// request to be allowed, or current window ends if its count alone exceeds limit.
+func slidingWindowRetryAfter(previous int, current int, elapsed time.Duration, policy Policy) time.Duration {
+	if current+1 > policy.Limit || previous == 0 {
+		return policy.Window - elapsed
+	}
+
+	// Solve previous * (1 - (elapsed + t) / window) + current + 1 <= limit
+	fraction := 1 - float64(policy.Limit-current-1)/float64(previous)
+
+	return max(0, time.Duration(math.Ceil(fraction*float64(policy.Window)))-elapsed)
+}
@@ -0,0 +1,141 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/kemadev/go-framework/pkg/convenience/headkey"
+	"github.com/kemadev/go-framework/pkg/convenience/log"
+	"github.com/kemadev/go-framework/pkg/convenience/resp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+)
+
+const packageName = "github.com/kemadev/go-framework/pkg/ratelimit"
+
+// DefaultPolicyName is the default name of policies.
+const DefaultPolicyName = "default"
+
+// Config defines the configuration for rate limiting middleware.
+type Config struct {
+	// Policy enforced for each client
+	Policy Policy
+	// Store counting requests, defaults to [NewLocalStore]
+	Store Store
+	// Key identifying clients, defaults to [KeyByIP] with connection peer address
+	Key KeyFunc
+	// Skip returns whether rate limiting should be bypassed for given request
+	Skip func(r *http.Request) bool
+	// Reject requests when rate limit can't be checked, with 503 status, instead of letting them through
+	FailClosed bool
+}
+
+// NewMiddleware returns a middleware limiting request rate of each client according to configured policy.
+// Responses carry RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset and RateLimit-Policy headers, see
+// https://datatracker.ietf.org/doc/html/draft-ietf-httpapi-ratelimit-headers-07. Requests exceeding limit are
+// rejected with 429 status along with Retry-After header, using problem details, see [resp.ProblemJSON], and
+// counted in http.server.rate_limit.rejections metric.
+func NewMiddleware(conf Config) (func(http.Handler) http.Handler, error) {
+	if conf.Policy.Name == "" {
+		conf.Policy.Name = DefaultPolicyName
+	}
+
+	err := conf.Policy.validate()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %+v", err, conf.Policy)
+	}
+
+	if conf.Store == nil {
+		conf.Store = NewLocalStore()
+	}
+
+	if conf.Key == nil {
+		conf.Key = KeyByIP(IPKeyConfig{})
+	}
+
+	rejections, err := otel.GetMeterProvider().Meter(packageName).Int64Counter(
+		"http.server.rate_limit.rejections",
+		metric.WithDescription("Number of requests rejected as they exceed rate limit"),
+		metric.WithUnit("{request}"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error creating rejections counter: %w", err)
+	}
+
+	policyHeader := strconv.Itoa(conf.Policy.Limit) + ";w=" + seconds(conf.Policy.Window)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if conf.Skip != nil && conf.Skip(r) {
+				next.ServeHTTP(w, r)
+
+				return
+			}
+
+			key, err := conf.Key(r)
+
+			var res Result
+			if err == nil {
+				res, err = conf.Store.Take(r.Context(), key, conf.Policy)
+			}
+
+			if err != nil {
+				log.ErrLog(packageName, "error checking rate limit", err)
+
+				if conf.FailClosed {
+					http.Error(
+						w,
+						http.StatusText(http.StatusServiceUnavailable),
+						http.StatusServiceUnavailable,
+					)
+
+					return
+				}
+
+				next.ServeHTTP(w, r)
+
+				return
+			}
+
+			w.Header().Set(headkey.RateLimitLimit, strconv.Itoa(conf.Policy.Limit))
+			w.Header().Set(headkey.RateLimitRemaining, strconv.Itoa(res.Remaining))
+			w.Header().Set(headkey.RateLimitReset, seconds(res.Reset))
+			w.Header().Set(headkey.RateLimitPolicy, policyHeader)
+
+			if res.Allowed {
+				next.ServeHTTP(w, r)
+
+				return
+			}
+
+			rejections.Add(
+				r.Context(),
+				1,
+				metric.WithAttributes(
+					attribute.String("ratelimit.policy", conf.Policy.Name),
+					semconv.HTTPRoute(r.Pattern),
+				),
+			)
+
+			w.Header().Set(headkey.RetryAfter, seconds(res.RetryAfter))
+
+			err = resp.ProblemJSON(w, resp.NewProblem(http.StatusTooManyRequests, ""))
+			if err != nil {
+				log.ErrLog(packageName, "error sending problem", err)
+			}
+		})
+	}, nil
+}
+
+// seconds returns [d] in seconds, rounded up so that clients don't retry too early.
+func seconds(d time.Duration) string {
+	return strconv.FormatFloat(math.Ceil(d.Seconds()), 'f', 0, 64)
+}
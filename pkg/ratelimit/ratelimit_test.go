@@ -0,0 +1,186 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package ratelimit_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/kemadev/go-framework/pkg/auth"
+	"github.com/kemadev/go-framework/pkg/ratelimit"
+)
+
+func newApp(t *testing.T, conf ratelimit.Config) http.Handler {
+	t.Helper()
+
+	mw, err := ratelimit.NewMiddleware(conf)
+	if err != nil {
+		t.Fatalf("NewMiddleware: %s", err)
+	}
+
+	return mw(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+}
+
+func do(app http.Handler, remoteAddr string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = remoteAddr
+
+	rr := httptest.NewRecorder()
+	app.ServeHTTP(rr, req)
+
+	return rr
+}
+
+func TestTokenBucket(t *testing.T) {
+	t.Parallel()
+
+	app := newApp(t, ratelimit.Config{
+		Policy: ratelimit.Policy{
+			Algorithm: ratelimit.TokenBucket,
+			Limit:     3,
+			Window:    time.Minute,
+		},
+	})
+
+	for i := range 3 {
+		rr := do(app, "192.0.2.1:1234")
+		if rr.Code != http.StatusOK {
+			t.Fatalf("request %d: expected status %d but was %d", i, http.StatusOK, rr.Code)
+		}
+	}
+
+	rr := do(app, "192.0.2.1:4321")
+	if rr.Code != http.StatusTooManyRequests {
+		t.Errorf("expected status %d but was %d", http.StatusTooManyRequests, rr.Code)
+	}
+
+	if retryAfter := rr.Header().Get("Retry-After"); retryAfter != "20" {
+		t.Errorf("expected Retry-After %q; got %q", "20", retryAfter)
+	}
+
+	if remaining := rr.Header().Get("RateLimit-Remaining"); remaining != "0" {
+		t.Errorf("expected RateLimit-Remaining %q; got %q", "0", remaining)
+	}
+
+	if policy := rr.Header().Get("RateLimit-Policy"); policy != "3;w=60" {
+		t.Errorf("expected RateLimit-Policy %q; got %q", "3;w=60", policy)
+	}
+
+	if rr := do(app, "192.0.2.2:1234"); rr.Code != http.StatusOK {
+		t.Errorf("expected other client to be allowed, status was %d", rr.Code)
+	}
+}
+
+func TestSlidingWindow(t *testing.T) {
+	t.Parallel()
+
+	app := newApp(t, ratelimit.Config{
+		Policy: ratelimit.Policy{
+			Algorithm: ratelimit.SlidingWindow,
+			Limit:     2,
+			Window:    100 * time.Millisecond,
+		},
+	})
+
+	// Align on a fresh window, so that all requests are counted in it
+	time.Sleep(time.Until(time.Now().Truncate(100 * time.Millisecond).Add(100 * time.Millisecond)))
+
+	for i := range 2 {
+		rr := do(app, "[2001:db8::1]:1234")
+		if rr.Code != http.StatusOK {
+			t.Fatalf("request %d: expected status %d but was %d", i, http.StatusOK, rr.Code)
+		}
+	}
+
+	// Clients are keyed by IPv6 /64
+	if rr := do(app, "[2001:db8::2]:1234"); rr.Code != http.StatusTooManyRequests {
+		t.Errorf("expected status %d but was %d", http.StatusTooManyRequests, rr.Code)
+	}
+
+	time.Sleep(250 * time.Millisecond)
+
+	if rr := do(app, "[2001:db8::1]:1234"); rr.Code != http.StatusOK {
+		t.Errorf("expected request to be allowed once window slid, status was %d", rr.Code)
+	}
+}
+
+func TestKeys(t *testing.T) {
+	t.Parallel()
+
+	byIP := ratelimit.KeyByIP(ratelimit.IPKeyConfig{
+		ProxyHeader:    "Forwarded",
+		TrustedProxies: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")},
+	})
+
+	tests := []struct {
+		Name          string
+		RemoteAddr    string
+		Forwarded     string
+		Principal     string
+		ExpectedKey   string
+		ExpectedError bool
+	}{
+		{
+			Name:        "peer",
+			RemoteAddr:  "192.0.2.1:1234",
+			ExpectedKey: "ip:192.0.2.1",
+		},
+		{
+			Name:        "untrusted proxy",
+			RemoteAddr:  "192.0.2.1:1234",
+			Forwarded:   "for=198.51.100.1",
+			ExpectedKey: "ip:192.0.2.1",
+		},
+		{
+			Name:        "trusted proxy",
+			RemoteAddr:  "10.0.0.1:1234",
+			Forwarded:   "for=198.51.100.1",
+			ExpectedKey: "ip:198.51.100.1",
+		},
+		{
+			Name:        "trusted proxy IPv6",
+			RemoteAddr:  "10.0.0.1:1234",
+			Forwarded:   `for="[2001:db8:1:2:3::1]"`,
+			ExpectedKey: "ip:2001:db8:1:2::/64",
+		},
+		{
+			Name:          "trusted proxy without header",
+			RemoteAddr:    "10.0.0.1:1234",
+			ExpectedError: true,
+		},
+		{
+			Name:        "principal",
+			RemoteAddr:  "192.0.2.1:1234",
+			Principal:   "alice",
+			ExpectedKey: "principal:alice",
+		},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = test.RemoteAddr
+
+		if test.Forwarded != "" {
+			req.Header.Set("Forwarded", test.Forwarded)
+		}
+
+		if test.Principal != "" {
+			req = req.WithContext(auth.NewContext(req.Context(), &auth.Principal{ID: test.Principal}))
+		}
+
+		key, err := ratelimit.KeyByPrincipal(byIP)(req)
+		if (err != nil) != test.ExpectedError {
+			t.Errorf("%s: unexpected error %v", test.Name, err)
+		}
+
+		if key != test.ExpectedKey {
+			t.Errorf("%s: expected key %q; got %q", test.Name, test.ExpectedKey, key)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/valkey-io/valkey-go"
+)
+
+// DefaultValkeyPrefix is the default prefix of valkey keys holding counters.
+const DefaultValkeyPrefix = "ratelimit:"
+
+// Scripts mirror local algorithms, see [tokenBucketState.take] and [slidingWindowState.take]. They use server
+// time so that instances clocks don't matter, and durations are in microseconds. They return whether request
+// is allowed, remaining requests, reset and retry after durations.
+var (
+	tokenBucketScript = valkey.NewLuaScript(`
+local limit = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000000 + tonumber(time[2])
+local rate = limit / window
+
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'updated')
+local tokens = tonumber(state[1])
+if tokens == nil then
+  tokens = limit
+else
+  tokens = math.min(limit, tokens + (now - tonumber(state[2])) * rate)
+end
+
+local allowed = 0
+local retry = 0
+if tokens >= 1 then
+  allowed = 1
+  tokens = tokens - 1
+else
+  retry = math.ceil((1 - tokens) / rate)
+end
+
+local reset = math.ceil((limit - tokens) / rate)
+redis.call('HSET', KEYS[1], 'tokens', tokens, 'updated', now)
+redis.call('PEXPIRE', KEYS[1], math.max(1, math.ceil(reset / 1000)))
+
+return {allowed, math.floor(tokens), reset, retry}
+`)
+	slidingWindowScript = valkey.NewLuaScript(`
+local limit = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000000 + tonumber(time[2])
+
+local state = redis.call('HMGET', KEYS[1], 'start', 'previous', 'current')
+local start = tonumber(state[1])
+local previous = tonumber(state[2]) or 0
+local current = tonumber(state[3]) or 0
+if start == nil or now - start >= 2 * window then
+  start = now - (now % window)
+  previous = 0
+  current = 0
+elseif now - start >= window then
+  start = start + window
+  previous = current
+  current = 0
+end
+
+local elapsed = now - start
+local estimated = previous * (1 - elapsed / window) + current
+
+local allowed = 0
+local retry = 0
+if estimated + 1 <= limit then
+  allowed = 1
+  current = current + 1
+  estimated = estimated + 1
+elseif current + 1 > limit or previous == 0 then
+  retry = window - elapsed
+else
+  retry = math.max(0, math.ceil((1 - (limit - current - 1) / previous) * window) - elapsed)
+end
+
+redis.call('HSET', KEYS[1], 'start', start, 'previous', previous, 'current', current)
+redis.call('PEXPIRE', KEYS[1], math.max(1, math.ceil((start + 2 * window - now) / 1000)))
+
+return {allowed, math.max(0, limit - math.ceil(estimated)), window - elapsed, retry}
+`)
+)
+
+// ValkeyStore counts requests in valkey, see [github.com/kemadev/go-framework/pkg/client/cache.NewClient], so
+// that limits apply across instances. Requests are counted atomically using Lua scripts.
+type ValkeyStore struct {
+	client valkey.Client
+	prefix string
+}
+
+// NewValkeyStore returns a store counting requests using [client], under keys prefixed with [prefix],
+// defaulting to [DefaultValkeyPrefix].
+func NewValkeyStore(client valkey.Client, prefix string) *ValkeyStore {
+	if prefix == "" {
+		prefix = DefaultValkeyPrefix
+	}
+
+	return &ValkeyStore{
+		client: client,
+		prefix: prefix,
+	}
+}
+
+// Take implements [Store].
+func (s *ValkeyStore) Take(ctx context.Context, key string, policy Policy) (Result, error) {
+	script := tokenBucketScript
+	if policy.Algorithm == SlidingWindow {
+		script = slidingWindowScript
+	}
+
+	values, err := script.Exec(
+		ctx,
+		s.client,
+		[]string{s.prefix + policy.Name + ":" + key},
+		[]string{
+			strconv.Itoa(policy.Limit),
+			strconv.FormatInt(policy.Window.Microseconds(), 10),
+		},
+	).AsIntSlice()
+	if err != nil {
+		return Result{}, fmt.Errorf("%w: error executing script: %w", ErrStore, err)
+	}
+
+	if len(values) != 4 {
+		return Result{}, fmt.Errorf("%w: unexpected script result %v", ErrStore, values)
+	}
+
+	return Result{
+		Allowed:    values[0] == 1,
+		Remaining:  int(values[1]),
+		Reset:      time.Duration(values[2]) * time.Microsecond,
+		RetryAfter: time.Duration(values[3]) * time.Microsecond,
+	}, nil
+}
@@ -0,0 +1,7 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+// Package realip resolves the address of clients whose requests are forwarded by trusted proxies, so that
+// handlers and middlewares can rely on [net/http.Request] RemoteAddr, Host and URL scheme. Headers set by
+// untrusted peers are ignored, as clients can forge them.
+package realip
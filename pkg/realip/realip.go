@@ -0,0 +1,276 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package realip
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/netip"
+	"slices"
+	"strings"
+
+	"github.com/kemadev/go-framework/pkg/convenience/headkey"
+	"github.com/kemadev/go-framework/pkg/convenience/headutil"
+	"github.com/kemadev/go-framework/pkg/convenience/log"
+	"github.com/kemadev/go-framework/pkg/convenience/resp"
+	"github.com/kemadev/go-framework/pkg/convenience/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+)
+
+const packageName = "github.com/kemadev/go-framework/pkg/realip"
+
+// xForwardedProto and xForwardedHost are de-facto standard headers accompanying X-Forwarded-For.
+const (
+	xForwardedProto = "X-Forwarded-Proto"
+	xForwardedHost  = "X-Forwarded-Host"
+)
+
+// ErrUnsupportedHeader is returned when configured header is not supported.
+var ErrUnsupportedHeader = errors.New("unsupported proxy header")
+
+// Config defines the configuration for real client IP middleware.
+type Config struct {
+	// Header carrying forwarded clients, one of Forwarded (default), X-Forwarded-For and
+	// X-Envoy-External-Address, see [github.com/kemadev/go-framework/pkg/config.Server] ProxyHeader
+	Header string
+	// Prefixes of proxies whose header is trusted, see [github.com/kemadev/go-framework/pkg/config.Server]
+	// TrustedProxies
+	TrustedProxies []netip.Prefix
+}
+
+// client is a client resolved from proxy header, along with scheme and host it used.
+type client struct {
+	addrPort netip.AddrPort
+	proto    string
+	host     string
+}
+
+// NewMiddleware returns a middleware resolving client of requests forwarded by trusted proxies. Forwarded and
+// X-Forwarded-For hops are walked from right to left, that is, from the closest proxy, until the first hop not
+// in trusted proxies, which is the client. X-Envoy-External-Address holds the client address determined by
+// Envoy, which must be configured to trust the same proxies.
+//
+// Requests RemoteAddr is rewritten to client address, and Host and URL scheme to those client used, as
+// reported by Forwarded host and proto parameters or X-Forwarded-Host and X-Forwarded-Proto headers. Client
+// address is recorded on current span as client.address. Clients hidden behind obfuscated or unknown
+// identifiers leave requests untouched. Hops preceding the client are never parsed, as clients may forge them,
+// whereas malformed hops added by trusted proxies are answered with 400 status, using problem details, see
+// [resp.ProblemJSON], so that requests are never handled as coming from a trusted proxy.
+func NewMiddleware(conf Config) (func(http.Handler) http.Handler, error) {
+	if conf.Header == "" {
+		conf.Header = headkey.Forwarded
+	}
+
+	var resolve func(r *http.Request, trusted func(netip.Addr) bool) (client, bool, error)
+
+	switch http.CanonicalHeaderKey(conf.Header) {
+	case headkey.Forwarded:
+		resolve = resolveForwarded
+	case headkey.XForwardedFor:
+		resolve = resolveXForwardedFor
+	case headkey.XEnvoyExternalAddress:
+		resolve = resolveEnvoy
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedHeader, conf.Header)
+	}
+
+	trusted := func(addr netip.Addr) bool {
+		addr = addr.Unmap()
+
+		return slices.ContainsFunc(conf.TrustedProxies, func(prefix netip.Prefix) bool {
+			return prefix.Contains(addr)
+		})
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			peer, err := netip.ParseAddrPort(r.RemoteAddr)
+			if err != nil || !trusted(peer.Addr()) {
+				if err == nil {
+					setSpanClient(r, peer)
+				}
+
+				next.ServeHTTP(w, r)
+
+				return
+			}
+
+			c, found, err := resolve(r, trusted)
+			if err != nil {
+				log.ErrLog(packageName, "error resolving client from proxy header", err)
+
+				err = resp.ProblemJSON(w, resp.NewProblem(http.StatusBadRequest, ""))
+				if err != nil {
+					log.ErrLog(packageName, "error sending problem", err)
+				}
+
+				return
+			}
+
+			if !found {
+				next.ServeHTTP(w, r)
+
+				return
+			}
+
+			next.ServeHTTP(w, rewrite(r, c))
+		})
+	}, nil
+}
+
+// rewrite returns a shallow copy of [r] with client address, scheme and host of [c], and records client
+// address on current span.
+func rewrite(r *http.Request, c client) *http.Request {
+	rewritten := r.WithContext(r.Context())
+	url := *r.URL
+	rewritten.URL = &url
+
+	rewritten.RemoteAddr = c.addrPort.String()
+
+	proto := strings.ToLower(c.proto)
+	if proto == "http" || proto == "https" {
+		rewritten.URL.Scheme = proto
+	}
+
+	if c.host != "" && validHost(c.host) {
+		rewritten.Host = c.host
+	}
+
+	setSpanClient(rewritten, c.addrPort)
+
+	return rewritten
+}
+
+// setSpanClient records [addrPort] as client of current span.
+func setSpanClient(r *http.Request, addrPort netip.AddrPort) {
+	span := trace.Span(r.Context())
+	span.SetAttributes(semconv.ClientAddress(addrPort.Addr().Unmap().String()))
+
+	if addrPort.Port() != 0 {
+		span.SetAttributes(semconv.ClientPort(int(addrPort.Port())))
+	}
+}
+
+// validHost returns whether [host] is a valid Host header value, see RFC 9110 section 7.2.
+func validHost(host string) bool {
+	return !strings.ContainsAny(host, " \t\r\n/\\?#@")
+}
+
+// resolveForwarded resolves client from Forwarded header, see RFC 7239.
+func resolveForwarded(r *http.Request, trusted func(netip.Addr) bool) (client, bool, error) {
+	var (
+		first client
+		found bool
+	)
+
+	for element, err := range headutil.ForwardedBackward(r.Header) {
+		if err != nil {
+			return client{}, false, fmt.Errorf("error parsing Forwarded header: %w", err)
+		}
+
+		node, err := headutil.ParseForwardedNode(element.For)
+		if err != nil {
+			return client{}, false, fmt.Errorf("error parsing Forwarded for parameter: %w", err)
+		}
+
+		addrPort, ok := node.AddrPort()
+		if !ok {
+			// Client identity is hidden
+			return client{}, false, nil
+		}
+
+		c := client{
+			addrPort: addrPort,
+			proto:    element.Proto,
+			host:     element.Host,
+		}
+
+		if !trusted(addrPort.Addr()) {
+			return c, true, nil
+		}
+
+		// Element added by proxy closest to client holds proto and host client used
+		first, found = c, true
+	}
+
+	return first, found, nil
+}
+
+// resolveXForwardedFor resolves client from X-Forwarded-For header.
+func resolveXForwardedFor(r *http.Request, trusted func(netip.Addr) bool) (client, bool, error) {
+	var hops []string
+	for _, value := range r.Header.Values(headkey.XForwardedFor) {
+		for hop := range strings.SplitSeq(value, ",") {
+			hops = append(hops, strings.TrimSpace(hop))
+		}
+	}
+
+	for i, hop := range slices.Backward(hops) {
+		addrPort, err := parseHop(hop)
+		if err != nil {
+			return client{}, false, err
+		}
+
+		if !trusted(addrPort.Addr()) || i == 0 {
+			return client{
+				addrPort: addrPort,
+				proto:    lastValue(r.Header, xForwardedProto),
+				host:     lastValue(r.Header, xForwardedHost),
+			}, true, nil
+		}
+	}
+
+	return client{}, false, nil
+}
+
+// resolveEnvoy resolves client from X-Envoy-External-Address header, see
+// https://www.envoyproxy.io/docs/envoy/latest/configuration/http/http_conn_man/headers#x-envoy-external-address
+func resolveEnvoy(r *http.Request, _ func(netip.Addr) bool) (client, bool, error) {
+	value := r.Header.Get(headkey.XEnvoyExternalAddress)
+	if value == "" {
+		return client{}, false, nil
+	}
+
+	addrPort, err := parseHop(value)
+	if err != nil {
+		return client{}, false, err
+	}
+
+	return client{
+		addrPort: addrPort,
+		proto:    lastValue(r.Header, xForwardedProto),
+		host:     lastValue(r.Header, xForwardedHost),
+	}, true, nil
+}
+
+// parseHop parses an address, with optional port, as found in X-Forwarded-For header.
+func parseHop(hop string) (netip.AddrPort, error) {
+	addr, err := netip.ParseAddr(strings.Trim(hop, "[]"))
+	if err == nil {
+		return netip.AddrPortFrom(addr, 0), nil
+	}
+
+	addrPort, err := netip.ParseAddrPort(hop)
+	if err != nil {
+		return netip.AddrPort{}, fmt.Errorf("error parsing hop %q: %w", hop, err)
+	}
+
+	return addrPort, nil
+}
+
+// lastValue returns last comma-separated value of header [key], that is, the one set by closest proxy.
+func lastValue(h http.Header, key string) string {
+	values := h.Values(key)
+	if len(values) == 0 {
+		return ""
+	}
+
+	last := values[len(values)-1]
+	if i := strings.LastIndexByte(last, ','); i >= 0 {
+		last = last[i+1:]
+	}
+
+	return strings.TrimSpace(last)
+}
@@ -0,0 +1,203 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package realip_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/kemadev/go-framework/pkg/realip"
+)
+
+func TestMiddleware(t *testing.T) {
+	t.Parallel()
+
+	trusted := []netip.Prefix{
+		netip.MustParsePrefix("10.0.0.0/8"),
+		netip.MustParsePrefix("2001:db8:ffff::/48"),
+	}
+
+	tests := []struct {
+		Name               string
+		Header             string
+		RemoteAddr         string
+		Headers            map[string][]string
+		ExpectedStatus     int
+		ExpectedRemoteAddr string
+		ExpectedScheme     string
+		ExpectedHost       string
+	}{
+		{
+			Name:               "untrusted peer",
+			Header:             "Forwarded",
+			RemoteAddr:         "192.0.2.1:1234",
+			Headers:            map[string][]string{"Forwarded": {"for=198.51.100.1"}},
+			ExpectedRemoteAddr: "192.0.2.1:1234",
+			ExpectedHost:       "example.com",
+		},
+		{
+			Name:       "forwarded chain",
+			Header:     "Forwarded",
+			RemoteAddr: "10.0.0.2:1234",
+			Headers: map[string][]string{"Forwarded": {
+				`for=198.51.100.7, for="198.51.100.1:4711";proto=https;host=app.example.com`,
+				`for=10.0.0.1`,
+			}},
+			ExpectedRemoteAddr: "198.51.100.1:4711",
+			ExpectedScheme:     "https",
+			ExpectedHost:       "app.example.com",
+		},
+		{
+			Name:       "forwarded all trusted",
+			Header:     "Forwarded",
+			RemoteAddr: "10.0.0.2:1234",
+			Headers: map[string][]string{"Forwarded": {
+				`for="[2001:db8:ffff::1]";proto=http, for=10.0.0.1`,
+			}},
+			ExpectedRemoteAddr: "[2001:db8:ffff::1]:0",
+			ExpectedScheme:     "http",
+			ExpectedHost:       "example.com",
+		},
+		{
+			Name:       "forwarded obfuscated client",
+			Header:     "Forwarded",
+			RemoteAddr: "10.0.0.2:1234",
+			Headers: map[string][]string{"Forwarded": {
+				`for=_hidden;proto=https, for=10.0.0.1`,
+			}},
+			ExpectedRemoteAddr: "10.0.0.2:1234",
+			ExpectedHost:       "example.com",
+		},
+		{
+			Name:           "forwarded malformed",
+			Header:         "Forwarded",
+			RemoteAddr:     "10.0.0.2:1234",
+			Headers:        map[string][]string{"Forwarded": {`for="198.51.100.1`}},
+			ExpectedStatus: http.StatusBadRequest,
+		},
+		{
+			Name:           "forwarded malformed trusted hop",
+			Header:         "Forwarded",
+			RemoteAddr:     "10.0.0.2:1234",
+			Headers:        map[string][]string{"Forwarded": {`for=198.51.100.1, for=10.0.0.1:http`}},
+			ExpectedStatus: http.StatusBadRequest,
+		},
+		{
+			Name:       "forwarded malformed client prefix",
+			Header:     "Forwarded",
+			RemoteAddr: "10.0.0.1:1234",
+			Headers: map[string][]string{"Forwarded": {
+				`for="bad`,
+				`for=203.0.113.7`,
+			}},
+			ExpectedRemoteAddr: "203.0.113.7:0",
+			ExpectedHost:       "example.com",
+		},
+		{
+			Name:               "forwarded malformed client prefix in same header",
+			Header:             "Forwarded",
+			RemoteAddr:         "10.0.0.1:1234",
+			Headers:            map[string][]string{"Forwarded": {`for="bad, for=203.0.113.7`}},
+			ExpectedRemoteAddr: "203.0.113.7:0",
+			ExpectedHost:       "example.com",
+		},
+		{
+			Name:       "x-forwarded-for spoofed leftmost",
+			Header:     "X-Forwarded-For",
+			RemoteAddr: "10.0.0.2:1234",
+			Headers: map[string][]string{
+				"X-Forwarded-For":   {"203.0.113.9, 198.51.100.1", "10.0.0.1"},
+				"X-Forwarded-Proto": {"https"},
+				"X-Forwarded-Host":  {"app.example.com"},
+			},
+			ExpectedRemoteAddr: "198.51.100.1:0",
+			ExpectedScheme:     "https",
+			ExpectedHost:       "app.example.com",
+		},
+		{
+			Name:       "x-forwarded-for malformed trusted hop",
+			Header:     "X-Forwarded-For",
+			RemoteAddr: "10.0.0.2:1234",
+			Headers: map[string][]string{
+				"X-Forwarded-For": {"198.51.100.1, 10.0.0.1:http"},
+			},
+			ExpectedStatus: http.StatusBadRequest,
+		},
+		{
+			Name:               "envoy",
+			Header:             "X-Envoy-External-Address",
+			RemoteAddr:         "10.0.0.2:1234",
+			Headers:            map[string][]string{"X-Envoy-External-Address": {"198.51.100.1"}},
+			ExpectedRemoteAddr: "198.51.100.1:0",
+			ExpectedHost:       "example.com",
+		},
+	}
+
+	for _, test := range tests {
+		mw, err := realip.NewMiddleware(realip.Config{
+			Header:         test.Header,
+			TrustedProxies: trusted,
+		})
+		if err != nil {
+			t.Fatalf("%s: NewMiddleware: %s", test.Name, err)
+		}
+
+		var got *http.Request
+
+		app := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got = r
+
+			w.WriteHeader(http.StatusOK)
+		}))
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = test.RemoteAddr
+		req.Host = "example.com"
+		req.URL.Scheme = ""
+
+		for key, values := range test.Headers {
+			for _, value := range values {
+				req.Header.Add(key, value)
+			}
+		}
+
+		rr := httptest.NewRecorder()
+		app.ServeHTTP(rr, req)
+
+		if test.ExpectedStatus == 0 {
+			test.ExpectedStatus = http.StatusOK
+		}
+
+		if rr.Code != test.ExpectedStatus {
+			t.Errorf("%s: expected status %d; got %d", test.Name, test.ExpectedStatus, rr.Code)
+		}
+
+		if test.ExpectedStatus != http.StatusOK {
+			if got != nil {
+				t.Errorf("%s: expected request not to be handled", test.Name)
+			}
+
+			continue
+		}
+
+		if got.RemoteAddr != test.ExpectedRemoteAddr {
+			t.Errorf("%s: expected RemoteAddr %q; got %q", test.Name, test.ExpectedRemoteAddr, got.RemoteAddr)
+		}
+
+		if got.URL.Scheme != test.ExpectedScheme {
+			t.Errorf("%s: expected scheme %q; got %q", test.Name, test.ExpectedScheme, got.URL.Scheme)
+		}
+
+		if got.Host != test.ExpectedHost {
+			t.Errorf("%s: expected host %q; got %q", test.Name, test.ExpectedHost, got.Host)
+		}
+	}
+
+	_, err := realip.NewMiddleware(realip.Config{Header: "X-Real-IP"})
+	if err == nil {
+		t.Errorf("expected unsupported header error")
+	}
+}
@@ -0,0 +1,208 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package router
+
+import (
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/kemadev/go-framework/pkg/convenience/headkey"
+)
+
+// wildcardRegexp matches path wildcards, whose names do not matter when comparing patterns. The {$} end
+// anchor is not a wildcard, and is left as is.
+var wildcardRegexp = regexp.MustCompile(`\{[^}$.][^}.]*(\.\.\.)?\}`)
+
+// registry records routes registered by a router and all of its groups.
+type registry struct {
+	mu     sync.Mutex
+	routes []Route
+	// byPattern indexes routes by pattern, as matched by mux, automatically registered OPTIONS routes being
+	// indexed by -1
+	byPattern map[string]int
+	paths     map[string]*pathRoutes
+}
+
+// pathRoutes holds methods registered for a given host and path, along with its OPTIONS handler.
+type pathRoutes struct {
+	mu      sync.RWMutex
+	methods []string
+	// pattern OPTIONS handler is registered with in mux
+	pattern string
+	// handler serves OPTIONS requests, either explicitly registered or answering with allowed methods
+	handler  atomic.Pointer[http.Handler]
+	explicit bool
+}
+
+func newRegistry() *registry {
+	return &registry{
+		byPattern: make(map[string]int),
+		paths:     make(map[string]*pathRoutes),
+	}
+}
+
+// splitPattern returns method and host-path parts of [pattern], see [net/http.ServeMux].
+func splitPattern(pattern string) (string, string) {
+	method, rest, found := strings.Cut(strings.TrimLeft(pattern, " \t"), " ")
+	if !found {
+		return "", pattern
+	}
+
+	return method, strings.TrimLeft(rest, " \t")
+}
+
+// add records [route], whose handler is [h] once wrapped with [chain]. It returns the OPTIONS pattern and
+// handler to register in mux for a new path, if any, and whether [pattern] is served by that OPTIONS handler
+// rather than registered in mux.
+func (reg *registry) add(
+	route Route,
+	h http.Handler,
+	chain func(http.Handler) http.Handler,
+) (string, http.Handler, bool) {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+
+	reg.routes = append(reg.routes, route)
+	if i, exists := reg.byPattern[route.Pattern]; !exists || i < 0 {
+		reg.byPattern[route.Pattern] = len(reg.routes) - 1
+	}
+
+	method, hostPath := splitPattern(route.Pattern)
+	if method == "" {
+		// Pattern matches all methods, including OPTIONS
+		return "", nil, false
+	}
+
+	if strings.HasSuffix(hostPath, "/") || strings.HasSuffix(hostPath, "...}") {
+		// Subtree patterns would answer OPTIONS requests for any path below them, including unknown ones
+		return "", nil, false
+	}
+
+	key := wildcardRegexp.ReplaceAllStringFunc(hostPath, func(wildcard string) string {
+		if strings.HasSuffix(wildcard, "...}") {
+			return "{...}"
+		}
+
+		return "{}"
+	})
+
+	routes, exists := reg.paths[key]
+	if !exists {
+		routes = &pathRoutes{pattern: http.MethodOptions + " " + hostPath}
+		reg.paths[key] = routes
+
+		if _, registered := reg.byPattern[routes.pattern]; !registered {
+			reg.byPattern[routes.pattern] = -1
+		}
+	}
+
+	served := false
+
+	switch {
+	case method == http.MethodOptions && routes.explicit:
+		// Duplicate route, registered in mux so that it reports the conflict
+	case method == http.MethodOptions:
+		routes.explicit = true
+		served = true
+		wrapped := chain(h)
+
+		if routes.pattern != route.Pattern {
+			// Mux routes requests using pattern of first route of path, whose wildcard names may differ
+			reg.byPattern[routes.pattern] = len(reg.routes) - 1
+			wrapped = route.rebind(wrapped)
+		}
+
+		routes.handler.Store(&wrapped)
+	default:
+		routes.mu.Lock()
+		routes.methods = append(routes.methods, method)
+		routes.mu.Unlock()
+
+		if !exists {
+			wrapped := chain(http.HandlerFunc(routes.allow))
+			routes.handler.Store(&wrapped)
+		}
+	}
+
+	if exists {
+		return "", nil, served
+	}
+
+	return routes.pattern, routes, served
+}
+
+// rebind returns a handler serving requests routed by mux using another pattern as if they were routed using
+// route pattern, so that path values are named after route wildcards.
+func (route Route) rebind(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		unrouted := r.WithContext(r.Context())
+		unrouted.Pattern = ""
+
+		next.ServeHTTP(w, route.Bind(unrouted))
+	})
+}
+
+// ServeHTTP implements [net/http.Handler], serving OPTIONS requests.
+func (routes *pathRoutes) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	(*routes.handler.Load()).ServeHTTP(w, r)
+}
+
+// allow answers OPTIONS requests with methods allowed for path, see RFC 9110 section 9.3.7.
+func (routes *pathRoutes) allow(w http.ResponseWriter, _ *http.Request) {
+	routes.mu.RLock()
+	methods := slices.Clone(routes.methods)
+	routes.mu.RUnlock()
+
+	// Mux serves HEAD requests using GET handlers
+	if slices.Contains(methods, http.MethodGet) {
+		methods = append(methods, http.MethodHead)
+	}
+
+	methods = append(methods, http.MethodOptions)
+	slices.Sort(methods)
+
+	w.Header().Set(headkey.Allow, strings.Join(slices.Compact(methods), ", "))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// list returns registered routes, in registration order.
+func (reg *registry) list() []Route {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+
+	routes := make([]Route, 0, len(reg.routes))
+	for _, route := range reg.routes {
+		routes = append(routes, route.clone())
+	}
+
+	return routes
+}
+
+// get returns route registered with [pattern], if any.
+func (reg *registry) get(pattern string) (Route, bool) {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+
+	i, exists := reg.byPattern[pattern]
+	if !exists || i < 0 {
+		return Route{}, false
+	}
+
+	return reg.routes[i].clone(), true
+}
+
+// known reports whether [pattern] was registered in mux by router, be it for a route or an automatically
+// registered OPTIONS route.
+func (reg *registry) known(pattern string) bool {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+
+	_, exists := reg.byPattern[pattern]
+
+	return exists
+}
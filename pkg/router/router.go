@@ -5,7 +5,10 @@ package router
 
 import (
 	"net/http"
+	"net/url"
+	"path"
 	"slices"
+	"strings"
 )
 
 const ServerRootSpanName = "server"
@@ -19,13 +22,34 @@ type PatternKey struct{}
 type Router struct {
 	globalChain []func(http.Handler) http.Handler
 	routeChain  []func(http.Handler) http.Handler
+	annotations []any
 	isSubRouter bool
+	routes      *registry
 	*http.ServeMux
 }
 
+// Route is a registered route.
+type Route struct {
+	// Pattern route was registered with, see [net/http.ServeMux]
+	Pattern string
+	// Annotations of route, inherited from its groups and set at registration, see [Router.Annotate]
+	Annotations []any
+}
+
+// clone returns a copy of route, whose annotations can be modified.
+func (route Route) clone() Route {
+	return Route{
+		Pattern:     route.Pattern,
+		Annotations: slices.Clone(route.Annotations),
+	}
+}
+
 // New returns a new HTTP router.
 func New() *Router {
-	return &Router{ServeMux: http.NewServeMux()}
+	return &Router{
+		routes:   newRegistry(),
+		ServeMux: http.NewServeMux(),
+	}
 }
 
 // Use appends [mw] to the routers chain.
@@ -37,29 +61,138 @@ func (r *Router) Use(mw ...func(http.Handler) http.Handler) {
 	}
 }
 
+// Annotate appends [annotations] to routers annotations, which are attached to routes registered afterwards
+// by router and its groups. Annotations are opaque to router, and are meant to be read by middlewares, e.g.
+// authorization requirements, see [Router.Lookup].
+func (r *Router) Annotate(annotations ...any) {
+	r.annotations = append(r.annotations, annotations...)
+}
+
 // Group adds all routers down the chain to a group. All members of a group inherits from
 // their parent's routers chain.
 func (r *Router) Group(group func(r *Router)) {
 	subRouter := &Router{
 		routeChain:  slices.Clone(r.routeChain),
+		annotations: slices.Clone(r.annotations),
 		isSubRouter: true,
+		routes:      r.routes,
 		ServeMux:    r.ServeMux,
 	}
 	group(subRouter)
 }
 
-// HandleFunc registers a handler function for a pattern.
-func (r *Router) HandleFunc(pattern string, h http.HandlerFunc) {
-	r.Handle(pattern, h)
+// HandleFunc registers a handler function for a pattern, see [Router.Handle].
+func (r *Router) HandleFunc(pattern string, h http.HandlerFunc, annotations ...any) {
+	r.Handle(pattern, h, annotations...)
 }
 
-// Handle registers a handler for a pattern.
-func (r *Router) Handle(pattern string, h http.Handler) {
+// Handle registers a handler for a pattern, along with route-specific [annotations] that are appended to
+// routers ones, see [Router.Annotate].
+//
+// Unless explicitly registered, an OPTIONS route is registered along with the first method-specific route of
+// each path, answering with allowed methods. It is wrapped by routers chain of that route, so that group
+// middlewares such as CORS can handle preflight requests. Subtree patterns, ending with a slash or a
+// {name...} wildcard, get none, as it would answer OPTIONS requests for any path below them.
+func (r *Router) Handle(pattern string, h http.Handler, annotations ...any) {
+	route := Route{
+		Pattern:     pattern,
+		Annotations: slices.Concat(r.annotations, annotations),
+	}
+
+	optionsPattern, optionsHandler, served := r.routes.add(route, h, r.chain)
+
+	if optionsHandler != nil {
+		r.ServeMux.Handle(optionsPattern, optionsHandler)
+	}
+
+	if served {
+		return
+	}
+
+	r.ServeMux.Handle(pattern, r.chain(h))
+}
+
+// chain wraps [h] with routers chain.
+func (r *Router) chain(h http.Handler) http.Handler {
 	for _, mw := range slices.Backward(r.routeChain) {
 		h = mw(h)
 	}
 
-	r.ServeMux.Handle(pattern, h)
+	return h
+}
+
+// Routes returns routes registered by router and all of its groups, in registration order. Automatically
+// registered OPTIONS routes are not listed.
+func (r *Router) Routes() []Route {
+	return r.routes.list()
+}
+
+// Bind returns a shallow copy of [req] with its pattern and path values set from route, as mux would do, so
+// that [net/http.Request.PathValue] can be used before request is routed, e.g. in routers global chain.
+// Requests already routed are returned as is.
+func (route Route) Bind(req *http.Request) *http.Request {
+	if req.Pattern != "" {
+		return req
+	}
+
+	bound := req.Clone(req.Context())
+	bound.Pattern = route.Pattern
+
+	_, hostPath := splitPattern(route.Pattern)
+
+	path := hostPath[strings.Index(hostPath, "/"):]
+	segments := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	values := strings.Split(strings.TrimPrefix(req.URL.EscapedPath(), "/"), "/")
+
+	for i, segment := range segments {
+		if i >= len(values) || !strings.HasPrefix(segment, "{") || segment == "{$}" {
+			continue
+		}
+
+		name, multi := strings.CutSuffix(strings.Trim(segment, "{}"), "...")
+
+		value := values[i]
+		if multi {
+			value = strings.Join(values[i:], "/")
+		}
+
+		unescaped, err := url.PathUnescape(value)
+		if err == nil {
+			value = unescaped
+		}
+
+		bound.SetPathValue(name, value)
+	}
+
+	return bound
+}
+
+// Lookup returns route matching [req], if any. Requests already routed by mux, e.g. in routers chain, are
+// resolved using their pattern. Requests served by automatically registered OPTIONS routes match no route.
+// Requests matching a pattern that was not registered by router, e.g. using embedded mux directly, match no
+// route either, returned route only holding that pattern.
+func (r *Router) Lookup(req *http.Request) (Route, bool) {
+	pattern := req.Pattern
+	if pattern == "" {
+		_, pattern = r.ServeMux.Handler(req)
+
+		// Depending on Go version, mux reports redirect path as pattern when redirecting to a subtree root, e.g.
+		// from /dir to /dir/
+		if pattern == path.Clean(req.URL.EscapedPath())+"/" {
+			return Route{}, false
+		}
+	}
+
+	if pattern == "" {
+		return Route{}, false
+	}
+
+	route, found := r.routes.get(pattern)
+	if !found && !r.routes.known(pattern) {
+		return Route{Pattern: pattern}, false
+	}
+
+	return route, found
 }
 
 // ServeHTTP implements http.Handler, applying global middleware.
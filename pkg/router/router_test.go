@@ -7,6 +7,7 @@ import (
 	"context"
 	"net/http"
 	"net/http/httptest"
+	"slices"
 	"testing"
 
 	"github.com/kemadev/go-framework/pkg/router"
@@ -199,3 +200,225 @@ func TestChain(t *testing.T) {
 		}
 	}
 }
+
+func TestOptions(t *testing.T) {
+	t.Parallel()
+
+	used := ""
+
+	mw := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			used += "m"
+
+			next.ServeHTTP(w, r)
+		})
+	}
+
+	handler := func(_ http.ResponseWriter, _ *http.Request) {
+		used += "h"
+	}
+
+	app := router.New()
+	app.Group(func(r *router.Router) {
+		r.Use(mw)
+		r.HandleFunc("GET /items/{id}", handler)
+		r.HandleFunc("DELETE /items/{name}", handler)
+	})
+	app.HandleFunc("OPTIONS /explicit", handler)
+	app.HandleFunc("POST /explicit", handler)
+	// End anchor is not a wildcard, paths being distinct
+	app.HandleFunc("GET /anchored/{$}", handler)
+	app.HandleFunc("PUT /anchored/{x}", handler)
+	app.HandleFunc("GET /", handler)
+	app.HandleFunc("GET /files/{path...}", handler)
+
+	tests := []struct {
+		RequestPath    string
+		ExpectedUsed   string
+		ExpectedStatus int
+		ExpectedAllow  string
+	}{
+		{
+			RequestPath:    "/items/1",
+			ExpectedUsed:   "m",
+			ExpectedStatus: http.StatusNoContent,
+			ExpectedAllow:  "DELETE, GET, HEAD, OPTIONS",
+		},
+		{
+			RequestPath:    "/explicit",
+			ExpectedUsed:   "h",
+			ExpectedStatus: http.StatusOK,
+		},
+		{
+			RequestPath:    "/anchored/",
+			ExpectedStatus: http.StatusNoContent,
+			ExpectedAllow:  "GET, HEAD, OPTIONS",
+		},
+		{
+			RequestPath:    "/anchored/1",
+			ExpectedStatus: http.StatusNoContent,
+			ExpectedAllow:  "OPTIONS, PUT",
+		},
+		{
+			// Subtree patterns get no OPTIONS route, mux answering instead
+			RequestPath:    "/unknown",
+			ExpectedStatus: http.StatusMethodNotAllowed,
+			ExpectedAllow:  "GET, HEAD",
+		},
+		{
+			RequestPath:    "/files/a/b",
+			ExpectedStatus: http.StatusMethodNotAllowed,
+			ExpectedAllow:  "GET, HEAD",
+		},
+	}
+
+	for _, test := range tests {
+		used = ""
+
+		req, err := http.NewRequestWithContext(
+			context.Background(),
+			http.MethodOptions,
+			test.RequestPath,
+			nil,
+		)
+		if err != nil {
+			t.Errorf("NewRequestWithContext: %s", err)
+		}
+
+		rr := httptest.NewRecorder()
+		app.ServeHTTP(rr, req)
+
+		if rr.Code != test.ExpectedStatus {
+			t.Errorf("%s: expected status %d but was %d", test.RequestPath, test.ExpectedStatus, rr.Code)
+		}
+
+		if used != test.ExpectedUsed {
+			t.Errorf("%s: expected %q; got %q", test.RequestPath, test.ExpectedUsed, used)
+		}
+
+		if allow := rr.Header().Get("Allow"); allow != test.ExpectedAllow {
+			t.Errorf("%s: expected Allow %q; got %q", test.RequestPath, test.ExpectedAllow, allow)
+		}
+	}
+
+	if routes := app.Routes(); len(routes) != 8 {
+		t.Errorf("expected 8 routes; got %v", routes)
+	}
+}
+
+func TestLookup(t *testing.T) {
+	t.Parallel()
+
+	handler := func(_ http.ResponseWriter, _ *http.Request) {}
+
+	app := router.New()
+	app.Annotate("root")
+	app.Group(func(r *router.Router) {
+		r.Annotate("group")
+		r.HandleFunc("GET /items/{id}", handler, "route")
+		// Served by mux using pattern of route above
+		r.HandleFunc("OPTIONS /items/{name}", handler, "options")
+	})
+	app.HandleFunc("GET /health", handler)
+	app.ServeMux.HandleFunc("GET /direct", handler)
+
+	tests := []struct {
+		Method              string
+		RequestPath         string
+		ExpectedFound       bool
+		ExpectedPattern     string
+		ExpectedAnnotations []any
+	}{
+		{
+			Method:              http.MethodGet,
+			RequestPath:         "/items/1",
+			ExpectedFound:       true,
+			ExpectedPattern:     "GET /items/{id}",
+			ExpectedAnnotations: []any{"root", "group", "route"},
+		},
+		{
+			Method:              http.MethodHead,
+			RequestPath:         "/items/1",
+			ExpectedFound:       true,
+			ExpectedPattern:     "GET /items/{id}",
+			ExpectedAnnotations: []any{"root", "group", "route"},
+		},
+		{
+			Method:              http.MethodGet,
+			RequestPath:         "/health",
+			ExpectedFound:       true,
+			ExpectedPattern:     "GET /health",
+			ExpectedAnnotations: []any{"root"},
+		},
+		{
+			Method:              http.MethodOptions,
+			RequestPath:         "/items/1",
+			ExpectedFound:       true,
+			ExpectedPattern:     "OPTIONS /items/{name}",
+			ExpectedAnnotations: []any{"root", "group", "options"},
+		},
+		{
+			Method:        http.MethodOptions,
+			RequestPath:   "/health",
+			ExpectedFound: false,
+		},
+		{
+			Method:          http.MethodGet,
+			RequestPath:     "/direct",
+			ExpectedFound:   false,
+			ExpectedPattern: "GET /direct",
+		},
+		{
+			Method:        http.MethodGet,
+			RequestPath:   "/unknown",
+			ExpectedFound: false,
+		},
+	}
+
+	for _, test := range tests {
+		req, err := http.NewRequestWithContext(
+			context.Background(),
+			test.Method,
+			test.RequestPath,
+			nil,
+		)
+		if err != nil {
+			t.Errorf("NewRequestWithContext: %s", err)
+		}
+
+		route, found := app.Lookup(req)
+		if found != test.ExpectedFound {
+			t.Errorf("%s %s: expected found %t", test.Method, test.RequestPath, test.ExpectedFound)
+
+			continue
+		}
+
+		if route.Pattern != test.ExpectedPattern {
+			t.Errorf("%s %s: expected pattern %q; got %q", test.Method, test.RequestPath, test.ExpectedPattern, route.Pattern)
+		}
+
+		if !slices.Equal(route.Annotations, test.ExpectedAnnotations) {
+			t.Errorf("%s %s: expected annotations %v; got %v", test.Method, test.RequestPath, test.ExpectedAnnotations, route.Annotations)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/items/a%2Fb", nil)
+
+	route, _ := app.Lookup(req)
+	if id := route.Bind(req).PathValue("id"); id != "a/b" {
+		t.Errorf("expected path value %q; got %q", "a/b", id)
+	}
+
+	// Explicit OPTIONS route reads path values using its own wildcard names
+	var name string
+
+	app.HandleFunc("GET /users/{id}", handler)
+	app.HandleFunc("OPTIONS /users/{name}", func(_ http.ResponseWriter, r *http.Request) {
+		name = r.PathValue("name")
+	})
+	app.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodOptions, "/users/alice", nil))
+
+	if name != "alice" {
+		t.Errorf("expected path value %q; got %q", "alice", name)
+	}
+}
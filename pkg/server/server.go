@@ -19,6 +19,8 @@ import (
 	"github.com/kemadev/go-framework/pkg/config"
 	"github.com/kemadev/go-framework/pkg/log"
 	"github.com/kemadev/go-framework/pkg/otel"
+	"github.com/kemadev/go-framework/pkg/sse"
+	"github.com/kemadev/go-framework/pkg/websocket"
 	"go.opentelemetry.io/contrib/bridges/otelslog"
 )
 
@@ -99,6 +101,10 @@ func Run(handler http.Handler, conf config.Global) {
 		Protocols: &protocols,
 	}
 
+	// Close long-lived streams and connections so that they do not hold graceful shutdown
+	srv.RegisterOnShutdown(sse.Drain)
+	srv.RegisterOnShutdown(websocket.Drain)
+
 	srvErr := make(chan error, 1)
 
 	go func() {
@@ -0,0 +1,123 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package session
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// CookieKeySize is the size of cookie store keys, used for AES-256-GCM.
+const CookieKeySize = 32
+
+// maxCookieSize is the maximum size of cookie values, browsers limiting whole cookie to 4096 bytes.
+const maxCookieSize = 3800
+
+var (
+	// ErrInvalidKey is returned when a cookie store key has an invalid size.
+	ErrInvalidKey = errors.New("invalid cookie store key")
+	// ErrNoKey is returned when a cookie store is created without keys.
+	ErrNoKey = errors.New("no cookie store key")
+	// ErrCookieTooLarge is returned when an encrypted session does not fit in a cookie.
+	ErrCookieTooLarge = errors.New("session too large for cookie")
+)
+
+// CookieStore stores sessions in cookies themselves, encrypted and authenticated using AES-256-GCM. Tokens are
+// encrypted sessions. As nothing is stored server-side, sessions can't be revoked before they expire, and
+// their size is limited.
+type CookieStore struct {
+	aeads []cipher.AEAD
+}
+
+// NewCookieStore returns a store encrypting sessions using [keys] of [CookieKeySize] bytes, which should be
+// generated using a cryptographically secure random generator. The first key encrypts sessions, and all keys
+// decrypt them, so that keys can be rotated by prepending new ones.
+func NewCookieStore(keys ...[]byte) (*CookieStore, error) {
+	if len(keys) == 0 {
+		return nil, ErrNoKey
+	}
+
+	aeads := make([]cipher.AEAD, 0, len(keys))
+
+	for _, key := range keys {
+		if len(key) != CookieKeySize {
+			return nil, fmt.Errorf("%w: expected %d bytes, got %d", ErrInvalidKey, CookieKeySize, len(key))
+		}
+
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, fmt.Errorf("error creating cipher: %w", err)
+		}
+
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, fmt.Errorf("error creating AEAD: %w", err)
+		}
+
+		aeads = append(aeads, aead)
+	}
+
+	return &CookieStore{
+		aeads: aeads,
+	}, nil
+}
+
+// Load implements [Store].
+func (s *CookieStore) Load(_ context.Context, token string) (Record, error) {
+	sealed, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return Record{}, ErrNotFound
+	}
+
+	for _, aead := range s.aeads {
+		if len(sealed) < aead.NonceSize() {
+			break
+		}
+
+		data, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], nil)
+		if err != nil {
+			continue
+		}
+
+		rec, err := decode(data)
+		if err != nil {
+			return Record{}, fmt.Errorf("%w: %w", ErrStore, err)
+		}
+
+		return rec, nil
+	}
+
+	// Tampered cookies and cookies encrypted with retired keys are ignored
+	return Record{}, ErrNotFound
+}
+
+// Save implements [Store]. Record expiration is enforced by session middleware, so that [ttl] is ignored.
+func (s *CookieStore) Save(_ context.Context, rec Record, _ time.Duration) (string, error) {
+	data, err := encode(rec)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrStore, err)
+	}
+
+	aead := s.aeads[0]
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(data)+aead.Overhead())
+	rand.Read(nonce)
+
+	token := base64.RawURLEncoding.EncodeToString(aead.Seal(nonce, nonce, data, nil))
+	if len(token) > maxCookieSize {
+		return "", fmt.Errorf("%w: %d bytes", ErrCookieTooLarge, len(token))
+	}
+
+	return token, nil
+}
+
+// Delete implements [Store]. Cookie being expired by session middleware, it is a no-op.
+func (s *CookieStore) Delete(_ context.Context, _ string) error {
+	return nil
+}
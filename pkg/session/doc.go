@@ -0,0 +1,11 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+// Package session manages HTTP sessions, identified by a secure cookie and stored in a [Store], such as valkey
+// (see [NewValkeyStore]), an in-memory ristretto cache (see [NewLocalStore]) or the cookie itself, encrypted
+// (see [NewCookieStore]). Sessions expire after a period of inactivity and after an absolute lifetime, and their
+// ID should be renewed on privilege changes such as login, see [Session.RenewID].
+//
+// Session values are encoded using [encoding/gob], so that custom types must be registered using
+// [encoding/gob.Register].
+package session
@@ -0,0 +1,66 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package session
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/dgraph-io/ristretto/v2"
+)
+
+// LocalStore stores sessions in an in-memory ristretto cache, see
+// [github.com/kemadev/go-framework/pkg/client/cache.NewLocal]. Tokens are session IDs. As sessions are neither
+// shared between instances nor persisted, it is best suited to single-instance deployments and development.
+// Item cost is encoded session size, so that cache MaxCost bounds memory used by sessions.
+type LocalStore struct {
+	cache *ristretto.Cache[string, []byte]
+}
+
+// NewLocalStore returns a store saving sessions in [cache].
+func NewLocalStore(cache *ristretto.Cache[string, []byte]) *LocalStore {
+	return &LocalStore{
+		cache: cache,
+	}
+}
+
+// Load implements [Store].
+func (s *LocalStore) Load(_ context.Context, token string) (Record, error) {
+	data, found := s.cache.Get(token)
+	if !found {
+		return Record{}, ErrNotFound
+	}
+
+	rec, err := decode(data)
+	if err != nil {
+		return Record{}, fmt.Errorf("%w: %w", ErrStore, err)
+	}
+
+	return rec, nil
+}
+
+// Save implements [Store].
+func (s *LocalStore) Save(_ context.Context, rec Record, ttl time.Duration) (string, error) {
+	data, err := encode(rec)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrStore, err)
+	}
+
+	if !s.cache.SetWithTTL(rec.ID, data, int64(len(data)), ttl) {
+		return "", fmt.Errorf("%w: session rejected by cache", ErrStore)
+	}
+
+	// Sets are buffered, make session available to next request
+	s.cache.Wait()
+
+	return rec.ID, nil
+}
+
+// Delete implements [Store].
+func (s *LocalStore) Delete(_ context.Context, token string) error {
+	s.cache.Del(token)
+
+	return nil
+}
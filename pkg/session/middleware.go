@@ -0,0 +1,283 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package session
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kemadev/go-framework/pkg/convenience/log"
+)
+
+const packageName = "github.com/kemadev/go-framework/pkg/session"
+
+const (
+	// DefaultCookieName is the default name of session cookie. The __Host- prefix makes browsers only accept it
+	// when set with Secure attribute, from a secure origin, without Domain attribute and with / path.
+	DefaultCookieName = "__Host-session"
+	// DefaultInsecureCookieName is the default name of session cookie when [Config] Insecure is set, as prefixed
+	// cookies can't be set over plain HTTP.
+	DefaultInsecureCookieName = "session"
+	// DefaultIdleTimeout is the default duration of inactivity after which sessions expire.
+	DefaultIdleTimeout = 30 * time.Minute
+	// DefaultAbsoluteTimeout is the default lifetime of sessions, regardless of activity.
+	DefaultAbsoluteTimeout = 24 * time.Hour
+)
+
+// maxTouchInterval is the maximum interval between saves of unmodified sessions, recording their activity.
+const maxTouchInterval = time.Minute
+
+var (
+	// ErrNoStore is returned when session middleware is configured without store.
+	ErrNoStore = errors.New("no session store")
+	// ErrInvalidTimeout is returned when idle timeout exceeds absolute timeout.
+	ErrInvalidTimeout = errors.New("idle timeout exceeds absolute timeout")
+	// ErrInsecureCookiePrefix is returned when a __Host- or __Secure- prefixed cookie name is used along with
+	// [Config] Insecure.
+	ErrInsecureCookiePrefix = errors.New("prefixed cookie can't be insecure")
+)
+
+// Config defines the configuration for session middleware.
+type Config struct {
+	// Store holding sessions
+	Store Store
+	// Session cookie name, defaults to [DefaultCookieName], or [DefaultInsecureCookieName] when Insecure is set
+	CookieName string
+	// Duration of inactivity after which sessions expire, defaults to [DefaultIdleTimeout]. Activity of
+	// unmodified sessions is recorded at most once a minute.
+	IdleTimeout time.Duration
+	// Lifetime of sessions, regardless of activity, defaults to [DefaultAbsoluteTimeout]
+	AbsoluteTimeout time.Duration
+	// SameSite attribute of session cookie, defaults to [net/http.SameSiteLaxMode]
+	SameSite http.SameSite
+	// Omit Secure attribute of session cookie, so that sessions work over plain HTTP, e.g. in local environment
+	Insecure bool
+}
+
+// NewMiddleware returns a middleware loading session of requests from configured store, see [FromContext].
+// Requests without valid session get a new one, which is only saved once it holds values. Session changes are
+// saved when response headers are written, so that session cookie can be set.
+//
+// Requests whose session can't be loaded are rejected with 503 status, and errors saving sessions are logged.
+func NewMiddleware(conf Config) (func(http.Handler) http.Handler, error) {
+	if conf.Store == nil {
+		return nil, ErrNoStore
+	}
+
+	if conf.CookieName == "" {
+		conf.CookieName = DefaultCookieName
+		if conf.Insecure {
+			conf.CookieName = DefaultInsecureCookieName
+		}
+	}
+
+	if conf.Insecure &&
+		(strings.HasPrefix(conf.CookieName, "__Host-") || strings.HasPrefix(conf.CookieName, "__Secure-")) {
+		return nil, fmt.Errorf("%w: %s", ErrInsecureCookiePrefix, conf.CookieName)
+	}
+
+	if conf.IdleTimeout == 0 {
+		conf.IdleTimeout = DefaultIdleTimeout
+	}
+
+	if conf.AbsoluteTimeout == 0 {
+		conf.AbsoluteTimeout = DefaultAbsoluteTimeout
+	}
+
+	if conf.IdleTimeout > conf.AbsoluteTimeout {
+		return nil, fmt.Errorf(
+			"%w: %s > %s",
+			ErrInvalidTimeout,
+			conf.IdleTimeout,
+			conf.AbsoluteTimeout,
+		)
+	}
+
+	if conf.SameSite == 0 {
+		conf.SameSite = http.SameSiteLaxMode
+	}
+
+	m := &manager{
+		conf:          conf,
+		touchInterval: min(maxTouchInterval, conf.IdleTimeout/2),
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			s, err := m.load(r)
+			if err != nil {
+				log.ErrLog(packageName, "error loading session", err)
+				http.Error(
+					w,
+					http.StatusText(http.StatusServiceUnavailable),
+					http.StatusServiceUnavailable,
+				)
+
+				return
+			}
+
+			sw := &sessionResponseWriter{
+				ResponseWriter: w,
+				save: func() {
+					m.save(w, r, s)
+				},
+			}
+
+			next.ServeHTTP(sw, r.WithContext(NewContext(r.Context(), s)))
+
+			// Handler may have written nothing
+			sw.commit()
+		})
+	}, nil
+}
+
+// manager loads and saves sessions.
+type manager struct {
+	conf          Config
+	touchInterval time.Duration
+}
+
+// load returns session of [r], or a new session if it has none or it expired.
+func (m *manager) load(r *http.Request) (*Session, error) {
+	now := time.Now()
+
+	cookie, err := r.Cookie(m.conf.CookieName)
+	if err != nil {
+		return newSession(now), nil
+	}
+
+	rec, err := m.conf.Store.Load(r.Context(), cookie.Value)
+
+	switch {
+	case errors.Is(err, ErrNotFound):
+	case err != nil:
+		return nil, fmt.Errorf("error loading session: %w", err)
+	case now.Sub(rec.LastActivityAt) > m.conf.IdleTimeout ||
+		now.Sub(rec.CreatedAt) > m.conf.AbsoluteTimeout:
+		// Cookie store sessions outlive their TTL
+	default:
+		if rec.Values == nil {
+			rec.Values = make(map[string]any)
+		}
+
+		return &Session{
+			rec:   rec,
+			token: cookie.Value,
+		}, nil
+	}
+
+	// Stale session is deleted, and its cookie cleared unless replaced by new session
+	s := newSession(now)
+	s.token = cookie.Value
+	s.renewed = true
+
+	return s, nil
+}
+
+// save saves changes of [s] and sets its cookie.
+func (m *manager) save(w http.ResponseWriter, r *http.Request, s *Session) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	oldToken := s.token
+
+	if oldToken != "" && (s.destroyed || s.renewed) {
+		err := m.conf.Store.Delete(r.Context(), oldToken)
+		if err != nil {
+			log.ErrLog(packageName, "error deleting session", err)
+		}
+
+		s.token = ""
+	}
+
+	if s.destroyed || (s.token == "" && len(s.rec.Values) == 0) {
+		if oldToken != "" {
+			m.setCookie(w, "", -1)
+		}
+
+		return
+	}
+
+	if s.token != "" && !s.modified && now.Sub(s.rec.LastActivityAt) < m.touchInterval {
+		return
+	}
+
+	s.rec.LastActivityAt = now
+	lifetime := s.rec.CreatedAt.Add(m.conf.AbsoluteTimeout).Sub(now)
+
+	token, err := m.conf.Store.Save(r.Context(), s.record(), min(m.conf.IdleTimeout, lifetime))
+	if err != nil {
+		log.ErrLog(packageName, "error saving session", err)
+
+		return
+	}
+
+	s.token = token
+	s.modified = false
+	s.renewed = false
+
+	m.setCookie(w, token, int(lifetime.Seconds()))
+}
+
+// setCookie sets session cookie to [value], expiring after [maxAge] seconds, see [net/http.Cookie].
+func (m *manager) setCookie(w http.ResponseWriter, value string, maxAge int) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     m.conf.CookieName,
+		Value:    value,
+		Path:     "/",
+		MaxAge:   maxAge,
+		Secure:   !m.conf.Insecure,
+		HttpOnly: true,
+		SameSite: m.conf.SameSite,
+	})
+}
+
+// sessionResponseWriter saves session before response headers are written.
+type sessionResponseWriter struct {
+	http.ResponseWriter
+	save func()
+	once sync.Once
+}
+
+// commit saves session, once.
+func (w *sessionResponseWriter) commit() {
+	w.once.Do(w.save)
+}
+
+// WriteHeader implements [net/http.ResponseWriter].
+func (w *sessionResponseWriter) WriteHeader(code int) {
+	// Informational responses are followed by final one
+	if code >= http.StatusOK {
+		w.commit()
+	}
+
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Write implements [net/http.ResponseWriter].
+func (w *sessionResponseWriter) Write(b []byte) (int, error) {
+	w.commit()
+
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush implements [net/http.Flusher].
+func (w *sessionResponseWriter) Flush() {
+	w.commit()
+
+	err := http.NewResponseController(w.ResponseWriter).Flush()
+	if err != nil {
+		log.ErrLog(packageName, "error flushing response", err)
+	}
+}
+
+// Unwrap returns the underlying [net/http.ResponseWriter].
+func (w *sessionResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,131 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package session
+
+import (
+	"context"
+	"crypto/rand"
+	"maps"
+	"sync"
+	"time"
+)
+
+// Session is an HTTP session, safe for concurrent use. Changes are saved when response headers are written.
+type Session struct {
+	mu  sync.Mutex
+	rec Record
+	// token referencing session in store, empty for new sessions
+	token     string
+	isNew     bool
+	modified  bool
+	renewed   bool
+	destroyed bool
+}
+
+// newSession returns an empty session created at [now].
+func newSession(now time.Time) *Session {
+	return &Session{
+		rec: Record{
+			ID:             rand.Text(),
+			Values:         make(map[string]any),
+			CreatedAt:      now,
+			LastActivityAt: now,
+		},
+		isNew: true,
+	}
+}
+
+// ID returns session ID, which changes when it is renewed, see [Session.RenewID].
+func (s *Session) ID() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.rec.ID
+}
+
+// IsNew returns whether session was created by current request.
+func (s *Session) IsNew() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.isNew
+}
+
+// CreatedAt returns session creation time.
+func (s *Session) CreatedAt() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.rec.CreatedAt
+}
+
+// Get returns value stored under [key], if any.
+func (s *Session) Get(key string) (any, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	v, ok := s.rec.Values[key]
+
+	return v, ok
+}
+
+// Set stores [v] under [key].
+func (s *Session) Set(key string, v any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.rec.Values[key] = v
+	s.modified = true
+}
+
+// Delete removes value stored under [key].
+func (s *Session) Delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.rec.Values, key)
+	s.modified = true
+}
+
+// RenewID changes session ID while keeping its values, to prevent session fixation. It must be called on
+// privilege changes, such as login.
+func (s *Session) RenewID() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.rec.ID = rand.Text()
+	s.renewed = true
+}
+
+// Destroy removes session from store and expires its cookie, e.g. on logout. A new session is created by next
+// request.
+func (s *Session) Destroy() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.rec.Values = make(map[string]any)
+	s.destroyed = true
+}
+
+// record returns a copy of session record.
+func (s *Session) record() Record {
+	rec := s.rec
+	rec.Values = maps.Clone(s.rec.Values)
+
+	return rec
+}
+
+type sessionKey struct{}
+
+// FromContext returns session loaded by [NewMiddleware], if any.
+func FromContext(c context.Context) (*Session, bool) {
+	s, ok := c.Value(sessionKey{}).(*Session)
+
+	return s, ok && s != nil
+}
+
+// NewContext returns a context carrying [s].
+func NewContext(c context.Context, s *Session) context.Context {
+	return context.WithValue(c, sessionKey{}, s)
+}
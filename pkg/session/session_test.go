@@ -0,0 +1,246 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package session_test
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dgraph-io/ristretto/v2"
+	"github.com/kemadev/go-framework/pkg/client/cache"
+	"github.com/kemadev/go-framework/pkg/session"
+)
+
+// newApp returns a handler exercising sessions, along with session middleware configured with [store].
+func newApp(t *testing.T, store session.Store, idle time.Duration) http.Handler {
+	t.Helper()
+
+	mw, err := session.NewMiddleware(session.Config{
+		Store:       store,
+		IdleTimeout: idle,
+	})
+	if err != nil {
+		t.Fatalf("NewMiddleware: %s", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /login", func(w http.ResponseWriter, r *http.Request) {
+		s, _ := session.FromContext(r.Context())
+		s.RenewID()
+		s.Set("user", r.FormValue("user"))
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("GET /me", func(w http.ResponseWriter, r *http.Request) {
+		s, _ := session.FromContext(r.Context())
+
+		user, ok := s.Get("user")
+		if !ok {
+			w.WriteHeader(http.StatusUnauthorized)
+
+			return
+		}
+
+		w.Write([]byte(user.(string)))
+	})
+	mux.HandleFunc("POST /logout", func(_ http.ResponseWriter, r *http.Request) {
+		s, _ := session.FromContext(r.Context())
+		s.Destroy()
+	})
+
+	return mw(mux)
+}
+
+// do sends a request to [app] with [cookie], returning response and session cookie it sets, if any.
+func do(app http.Handler, method string, target string, cookie *http.Cookie) (*http.Response, *http.Cookie) {
+	req := httptest.NewRequest(method, target, nil)
+	if cookie != nil {
+		req.AddCookie(cookie)
+	}
+
+	rr := httptest.NewRecorder()
+	app.ServeHTTP(rr, req)
+
+	res := rr.Result()
+	for _, c := range res.Cookies() {
+		if c.Name == session.DefaultCookieName {
+			return res, c
+		}
+	}
+
+	return res, nil
+}
+
+func TestMiddleware(t *testing.T) {
+	t.Parallel()
+
+	local, err := cache.NewLocal(ristretto.Config[string, []byte]{
+		NumCounters: 1000,
+		MaxCost:     1 << 20,
+		BufferItems: 64,
+	})
+	if err != nil {
+		t.Fatalf("NewLocal: %s", err)
+	}
+
+	cookieStore, err := session.NewCookieStore(bytes.Repeat([]byte{1}, session.CookieKeySize))
+	if err != nil {
+		t.Fatalf("NewCookieStore: %s", err)
+	}
+
+	stores := map[string]session.Store{
+		"local":  session.NewLocalStore(local),
+		"cookie": cookieStore,
+	}
+
+	for name, store := range stores {
+		app := newApp(t, store, time.Hour)
+
+		res, anonymous := do(app, http.MethodGet, "/me", nil)
+		if res.StatusCode != http.StatusUnauthorized || anonymous != nil {
+			t.Errorf("%s: expected unauthorized without session cookie", name)
+		}
+
+		_, preLogin := do(app, http.MethodPost, "/login?user=alice", nil)
+		if preLogin == nil {
+			t.Fatalf("%s: expected session cookie", name)
+		}
+
+		if !preLogin.Secure || !preLogin.HttpOnly || preLogin.SameSite != http.SameSiteLaxMode || preLogin.Path != "/" {
+			t.Errorf("%s: insecure session cookie %v", name, preLogin)
+		}
+
+		_, loggedIn := do(app, http.MethodPost, "/login?user=bob", preLogin)
+		if loggedIn == nil || loggedIn.Value == preLogin.Value {
+			t.Errorf("%s: expected session ID renewal", name)
+		}
+
+		res, _ = do(app, http.MethodGet, "/me", loggedIn)
+		if body := readBody(t, res); body != "bob" {
+			t.Errorf("%s: expected user %q; got %q", name, "bob", body)
+		}
+
+		tampered := *loggedIn
+		tampered.Value = tampered.Value[:len(tampered.Value)-2]
+
+		res, _ = do(app, http.MethodGet, "/me", &tampered)
+		if res.StatusCode != http.StatusUnauthorized {
+			t.Errorf("%s: expected tampered session to be ignored", name)
+		}
+
+		_, loggedOut := do(app, http.MethodPost, "/logout", loggedIn)
+		if loggedOut == nil || loggedOut.MaxAge >= 0 {
+			t.Errorf("%s: expected session cookie to be cleared", name)
+		}
+	}
+
+	// Renewed and destroyed sessions are revoked server-side
+	app := newApp(t, stores["local"], time.Hour)
+
+	_, preLogin := do(app, http.MethodPost, "/login?user=alice", nil)
+	do(app, http.MethodPost, "/login?user=bob", preLogin)
+
+	res, _ := do(app, http.MethodGet, "/me", preLogin)
+	if res.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected renewed session to be revoked")
+	}
+}
+
+func TestIdleTimeout(t *testing.T) {
+	t.Parallel()
+
+	store, err := session.NewCookieStore(bytes.Repeat([]byte{2}, session.CookieKeySize))
+	if err != nil {
+		t.Fatalf("NewCookieStore: %s", err)
+	}
+
+	app := newApp(t, store, 50*time.Millisecond)
+
+	_, cookie := do(app, http.MethodPost, "/login?user=alice", nil)
+
+	time.Sleep(100 * time.Millisecond)
+
+	res, cleared := do(app, http.MethodGet, "/me", cookie)
+	if res.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected idle session to expire")
+	}
+
+	if cleared == nil || cleared.MaxAge >= 0 {
+		t.Errorf("expected expired session cookie to be cleared")
+	}
+}
+
+func TestConfig(t *testing.T) {
+	t.Parallel()
+
+	store, err := session.NewCookieStore(bytes.Repeat([]byte{3}, session.CookieKeySize))
+	if err != nil {
+		t.Fatalf("NewCookieStore: %s", err)
+	}
+
+	tests := []struct {
+		Name          string
+		Config        session.Config
+		ExpectedError error
+	}{
+		{
+			Name:          "no store",
+			Config:        session.Config{},
+			ExpectedError: session.ErrNoStore,
+		},
+		{
+			Name: "insecure prefixed cookie",
+			Config: session.Config{
+				Store:      store,
+				CookieName: session.DefaultCookieName,
+				Insecure:   true,
+			},
+			ExpectedError: session.ErrInsecureCookiePrefix,
+		},
+		{
+			Name: "idle timeout exceeding absolute timeout",
+			Config: session.Config{
+				Store:           store,
+				IdleTimeout:     time.Hour,
+				AbsoluteTimeout: time.Minute,
+			},
+			ExpectedError: session.ErrInvalidTimeout,
+		},
+		{
+			Name: "insecure",
+			Config: session.Config{
+				Store:    store,
+				Insecure: true,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		_, err := session.NewMiddleware(test.Config)
+		if !errors.Is(err, test.ExpectedError) {
+			t.Errorf("%s: expected error %v; got %v", test.Name, test.ExpectedError, err)
+		}
+	}
+
+	_, err = session.NewCookieStore([]byte("short"))
+	if !errors.Is(err, session.ErrInvalidKey) {
+		t.Errorf("expected error %v; got %v", session.ErrInvalidKey, err)
+	}
+}
+
+func readBody(t *testing.T, res *http.Response) string {
+	t.Helper()
+
+	var buf bytes.Buffer
+
+	_, err := buf.ReadFrom(res.Body)
+	if err != nil {
+		t.Errorf("ReadFrom: %s", err)
+	}
+
+	return buf.String()
+}
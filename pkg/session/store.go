@@ -0,0 +1,67 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package session
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	// ErrNotFound is returned by stores when session does not exist, e.g. as it expired.
+	ErrNotFound = errors.New("session not found")
+	// ErrStore is returned by stores when session can't be loaded or saved, e.g. as backend is unreachable.
+	ErrStore = errors.New("session store error")
+)
+
+// Record is the stored state of a session.
+type Record struct {
+	// Session ID
+	ID string
+	// Session values
+	Values map[string]any
+	// Session creation time, from which absolute lifetime is computed
+	CreatedAt time.Time
+	// Last time session was saved, from which idle lifetime is computed
+	LastActivityAt time.Time
+}
+
+// Store stores sessions. Sessions are referenced by a token, which is the session cookie value.
+type Store interface {
+	// Load returns session referenced by [token]. It returns an error wrapping [ErrNotFound] when session does
+	// not exist, and [ErrStore] when it can't be loaded.
+	Load(ctx context.Context, token string) (Record, error)
+	// Save stores [rec] for [ttl] and returns token referencing it.
+	Save(ctx context.Context, rec Record, ttl time.Duration) (string, error)
+	// Delete removes session referenced by [token].
+	Delete(ctx context.Context, token string) error
+}
+
+// encode returns [rec] encoded using gob.
+func encode(rec Record) ([]byte, error) {
+	var buf bytes.Buffer
+
+	err := gob.NewEncoder(&buf).Encode(rec)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding session: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// decode returns record encoded in [data] using gob.
+func decode(data []byte) (Record, error) {
+	var rec Record
+
+	err := gob.NewDecoder(bytes.NewReader(data)).Decode(&rec)
+	if err != nil {
+		return Record{}, fmt.Errorf("error decoding session: %w", err)
+	}
+
+	return rec, nil
+}
@@ -0,0 +1,82 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package session
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/valkey-io/valkey-go"
+)
+
+// DefaultValkeyPrefix is the default prefix of valkey keys holding sessions.
+const DefaultValkeyPrefix = "session:"
+
+// ValkeyStore stores sessions in valkey, see [github.com/kemadev/go-framework/pkg/client/cache.NewClient].
+// Tokens are session IDs.
+type ValkeyStore struct {
+	client valkey.Client
+	prefix string
+}
+
+// NewValkeyStore returns a store saving sessions using [client], under keys prefixed with [prefix], defaulting
+// to [DefaultValkeyPrefix].
+func NewValkeyStore(client valkey.Client, prefix string) *ValkeyStore {
+	if prefix == "" {
+		prefix = DefaultValkeyPrefix
+	}
+
+	return &ValkeyStore{
+		client: client,
+		prefix: prefix,
+	}
+}
+
+// Load implements [Store].
+func (s *ValkeyStore) Load(ctx context.Context, token string) (Record, error) {
+	data, err := s.client.Do(ctx, s.client.B().Get().Key(s.prefix+token).Build()).AsBytes()
+	if valkey.IsValkeyNil(err) {
+		return Record{}, ErrNotFound
+	}
+
+	if err != nil {
+		return Record{}, fmt.Errorf("%w: error getting session: %w", ErrStore, err)
+	}
+
+	rec, err := decode(data)
+	if err != nil {
+		return Record{}, fmt.Errorf("%w: %w", ErrStore, err)
+	}
+
+	return rec, nil
+}
+
+// Save implements [Store].
+func (s *ValkeyStore) Save(ctx context.Context, rec Record, ttl time.Duration) (string, error) {
+	data, err := encode(rec)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrStore, err)
+	}
+
+	err = s.client.Do(
+		ctx,
+		s.client.B().Set().Key(s.prefix+rec.ID).Value(valkey.BinaryString(data)).Px(ttl).Build(),
+	).Error()
+	if err != nil {
+		return "", fmt.Errorf("%w: error setting session: %w", ErrStore, err)
+	}
+
+	return rec.ID, nil
+}
+
+// Delete implements [Store].
+func (s *ValkeyStore) Delete(ctx context.Context, token string) error {
+	err := s.client.Do(ctx, s.client.B().Del().Key(s.prefix+token).Build()).Error()
+	if err != nil {
+		return fmt.Errorf("%w: error deleting session: %w", ErrStore, err)
+	}
+
+	return nil
+}
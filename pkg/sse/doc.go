@@ -0,0 +1,8 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+// Package sse implements [Server-Sent Events] streams, with reconnection support, keep-alive heartbeats and
+// graceful closing upon server shutdown.
+//
+// [Server-Sent Events]: https://html.spec.whatwg.org/multipage/server-sent-events.html
+package sse
@@ -0,0 +1,326 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package sse
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kemadev/go-framework/pkg/convenience/headkey"
+	"github.com/kemadev/go-framework/pkg/convenience/headval"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const packageName = "github.com/kemadev/go-framework/pkg/sse"
+
+var (
+	ErrStreamingUnsupported = errors.New("response writer does not support streaming")
+	ErrStreamClosed         = errors.New("stream is closed")
+	ErrEventInvalid         = errors.New("event is invalid")
+)
+
+// DefaultHeartbeatInterval is the default interval between heartbeat comments, which is short enough
+// to prevent most proxies from closing idle connections.
+const DefaultHeartbeatInterval = 15 * time.Second
+
+// DefaultWriteTimeout is the default maximum duration of each write to a stream.
+const DefaultWriteTimeout = 10 * time.Second
+
+// Event is a single Server-Sent Event.
+type Event struct {
+	// ID is the event ID, sent back by clients as Last-Event-ID header upon reconnection
+	ID string
+	// Type is the event type, defaults to "message" on client side when empty
+	Type string
+	// Data is the event payload, multiline data is split across multiple data fields
+	Data string
+	// Retry is the reconnection delay to advertise to client, not sent when 0
+	Retry time.Duration
+}
+
+// Config defines the configuration for a [Stream].
+type Config struct {
+	// HeartbeatInterval is the interval between heartbeat comments, heartbeats are disabled when 0
+	HeartbeatInterval time.Duration
+	// Retry is the reconnection delay to advertise to client upon stream opening, not sent when 0
+	Retry time.Duration
+	// WriteTimeout is the maximum duration of each write, so that clients that stop reading do not block
+	// stream forever, defaults to [DefaultWriteTimeout] when 0
+	WriteTimeout time.Duration
+}
+
+// Stream is a Server-Sent Events stream. It is safe for concurrent use.
+type Stream struct {
+	w           http.ResponseWriter
+	rc          *http.ResponseController
+	c           context.Context
+	cancel      context.CancelFunc
+	span        trace.Span
+	lastEventID string
+	// writeTimeout bounds each write
+	writeTimeout time.Duration
+	// writeMu serializes writes, which are network I/O and must not block [Stream.Close]
+	writeMu sync.Mutex
+	// mu guards fields below
+	mu     sync.Mutex
+	closed bool
+	sent   int64
+}
+
+var (
+	streams   = make(map[*Stream]struct{})
+	streamsMu sync.Mutex
+)
+
+// New returns a new [Stream] for given request, with default configuration.
+func New(w http.ResponseWriter, r *http.Request) (*Stream, error) {
+	return NewWithConfig(w, r, Config{
+		HeartbeatInterval: DefaultHeartbeatInterval,
+		WriteTimeout:      DefaultWriteTimeout,
+	})
+}
+
+// NewWithConfig returns a new [Stream] for given request, with custom configuration. It sends
+// response headers right away, replaces server's write timeout for the connection with a deadline for each
+// write, and starts a dedicated span linked to the request's one, ended upon stream closure.
+// Callers must call [Stream.Close] before returning from their handler.
+func NewWithConfig(w http.ResponseWriter, r *http.Request, conf Config) (*Stream, error) {
+	rc := http.NewResponseController(w)
+
+	if conf.WriteTimeout == 0 {
+		conf.WriteTimeout = DefaultWriteTimeout
+	}
+
+	c, span := otel.Tracer(packageName).Start(
+		context.WithoutCancel(r.Context()),
+		"sse.stream",
+		trace.WithNewRoot(),
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithLinks(trace.LinkFromContext(r.Context())),
+	)
+
+	// Stream is done as soon as client goes away
+	c, cancel := context.WithCancel(c)
+	stop := context.AfterFunc(r.Context(), cancel)
+
+	s := &Stream{
+		w:            w,
+		rc:           rc,
+		c:            c,
+		cancel:       cancel,
+		span:         span,
+		lastEventID:  r.Header.Get(headkey.LastEventID),
+		writeTimeout: conf.WriteTimeout,
+	}
+
+	if s.lastEventID != "" {
+		span.SetAttributes(attribute.String("sse.last_event_id", s.lastEventID))
+	}
+
+	w.Header().Set(headkey.ContentType, headval.MIMETextEventStream)
+	w.Header().Set(headkey.CacheControl, "no-cache")
+	w.Header().Del(headkey.ContentLength)
+	w.WriteHeader(http.StatusOK)
+
+	var b strings.Builder
+	if conf.Retry > 0 {
+		b.WriteString("retry: " + strconv.FormatInt(conf.Retry.Milliseconds(), 10) + "\n\n")
+	}
+
+	err := s.write(b.String())
+	if err != nil {
+		stop()
+		s.Close()
+
+		return nil, err
+	}
+
+	streamsMu.Lock()
+	streams[s] = struct{}{}
+	streamsMu.Unlock()
+
+	go func() {
+		<-c.Done()
+		stop()
+		s.Close()
+	}()
+
+	if conf.HeartbeatInterval > 0 {
+		go s.heartbeat(conf.HeartbeatInterval)
+	}
+
+	return s, nil
+}
+
+// LastEventID returns the ID of the last event received by the client, as sent upon reconnection, so that
+// stream can be resumed. It is empty for new streams.
+func (s *Stream) LastEventID() string {
+	return s.lastEventID
+}
+
+// Context returns a context that is done when the stream is closed, either because client went away,
+// because [Stream.Close] was called, or because the server is shutting down (see [Drain]).
+func (s *Stream) Context() context.Context {
+	return s.c
+}
+
+// Send sends ev to the client and flushes it.
+func (s *Stream) Send(ev Event) error {
+	if strings.ContainsAny(ev.ID, "\r\n\x00") || strings.ContainsAny(ev.Type, "\r\n") {
+		return fmt.Errorf("event id or type contains forbidden characters: %w", ErrEventInvalid)
+	}
+
+	var b strings.Builder
+
+	if ev.ID != "" {
+		b.WriteString("id: " + ev.ID + "\n")
+	}
+
+	if ev.Type != "" {
+		b.WriteString("event: " + ev.Type + "\n")
+	}
+
+	if ev.Retry > 0 {
+		b.WriteString("retry: " + strconv.FormatInt(ev.Retry.Milliseconds(), 10) + "\n")
+	}
+
+	// Any of CRLF, CR and LF ends a line, which would otherwise let data inject fields
+	data := strings.NewReplacer("\r\n", "\n", "\r", "\n").Replace(ev.Data)
+	for line := range strings.SplitSeq(data, "\n") {
+		b.WriteString("data: " + line + "\n")
+	}
+
+	b.WriteString("\n")
+
+	err := s.write(b.String())
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.sent++
+	s.mu.Unlock()
+
+	return nil
+}
+
+// Close closes the stream, which makes further calls to [Stream.Send] fail. It is safe to call it multiple times.
+func (s *Stream) Close() {
+	s.mu.Lock()
+
+	if s.closed {
+		s.mu.Unlock()
+
+		return
+	}
+
+	s.closed = true
+	s.mu.Unlock()
+
+	s.cancel()
+
+	streamsMu.Lock()
+	delete(streams, s)
+	streamsMu.Unlock()
+
+	// Abort pending write, e.g. to a client that stopped reading, and wait for it to return so that response
+	// is no longer used once closed
+	if !s.writeMu.TryLock() {
+		_ = s.rc.SetWriteDeadline(time.Now())
+
+		s.writeMu.Lock()
+	}
+
+	// Let server finish response without stream deadline
+	_ = s.rc.SetWriteDeadline(time.Time{})
+	s.writeMu.Unlock()
+
+	s.mu.Lock()
+	sent := s.sent
+	s.mu.Unlock()
+
+	s.span.SetAttributes(attribute.Int64("sse.events.sent", sent))
+	s.span.End()
+}
+
+// Drain closes all open streams. It is meant to be registered using [net/http.Server.RegisterOnShutdown], so that
+// long-lived streams do not prevent graceful shutdown.
+func Drain() {
+	streamsMu.Lock()
+	open := make([]*Stream, 0, len(streams))
+
+	for s := range streams {
+		open = append(open, s)
+	}
+	streamsMu.Unlock()
+
+	for _, s := range open {
+		s.Close()
+	}
+}
+
+func (s *Stream) heartbeat(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.c.Done():
+			return
+		case <-ticker.C:
+			err := s.write(": heartbeat\n\n")
+			if err != nil {
+				s.Close()
+
+				return
+			}
+		}
+	}
+}
+
+func (s *Stream) write(data string) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	// Streams are long-lived, and thus can't be bound by server write timeout. Deadline is set before checking
+	// closure, so that it doesn't override the one set by [Stream.Close] to abort write.
+	err := s.rc.SetWriteDeadline(time.Now().Add(s.writeTimeout))
+	if err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return fmt.Errorf("error setting write deadline: %w", err)
+	}
+
+	s.mu.Lock()
+	closed := s.closed
+	s.mu.Unlock()
+
+	if closed {
+		return ErrStreamClosed
+	}
+
+	if data != "" {
+		_, err := s.w.Write([]byte(data))
+		if err != nil {
+			return fmt.Errorf("error writing to stream: %w", err)
+		}
+	}
+
+	err = s.rc.Flush()
+	if err != nil {
+		if errors.Is(err, http.ErrNotSupported) {
+			return ErrStreamingUnsupported
+		}
+
+		return fmt.Errorf("error flushing stream: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,225 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package sse_test
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kemadev/go-framework/pkg/convenience/headkey"
+	"github.com/kemadev/go-framework/pkg/convenience/headval"
+	"github.com/kemadev/go-framework/pkg/sse"
+)
+
+func TestSend(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Name         string
+		Event        sse.Event
+		ExpectedBody string
+		ExpectedErr  error
+	}{
+		{
+			Name:         "data only",
+			Event:        sse.Event{Data: "hello"},
+			ExpectedBody: "data: hello\n\n",
+		},
+		{
+			Name:         "all fields",
+			Event:        sse.Event{ID: "42", Type: "tick", Data: "hello", Retry: 3 * time.Second},
+			ExpectedBody: "id: 42\nevent: tick\nretry: 3000\ndata: hello\n\n",
+		},
+		{
+			Name:         "multiline data",
+			Event:        sse.Event{Data: "a\nb\r\nc"},
+			ExpectedBody: "data: a\ndata: b\ndata: c\n\n",
+		},
+		{
+			Name:         "carriage return can't inject fields",
+			Event:        sse.Event{Data: "a\revent: admin\rid: 1"},
+			ExpectedBody: "data: a\ndata: event: admin\ndata: id: 1\n\n",
+		},
+		{
+			Name:        "line break in id",
+			Event:       sse.Event{ID: "1\rdata: x", Data: "hello"},
+			ExpectedErr: sse.ErrEventInvalid,
+		},
+		{
+			Name:        "null in id",
+			Event:       sse.Event{ID: "1\x00", Data: "hello"},
+			ExpectedErr: sse.ErrEventInvalid,
+		},
+		{
+			Name:        "line break in type",
+			Event:       sse.Event{Type: "tick\nid: 1", Data: "hello"},
+			ExpectedErr: sse.ErrEventInvalid,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			t.Parallel()
+
+			rr := httptest.NewRecorder()
+
+			stream, err := sse.NewWithConfig(rr, httptest.NewRequest(http.MethodGet, "/", nil), sse.Config{})
+			if err != nil {
+				t.Fatalf("NewWithConfig: %s", err)
+			}
+
+			err = stream.Send(tt.Event)
+			stream.Close()
+
+			if !errors.Is(err, tt.ExpectedErr) {
+				t.Fatalf("expected error %v but was %v", tt.ExpectedErr, err)
+			}
+
+			if tt.ExpectedErr != nil {
+				tt.ExpectedBody = ""
+			}
+
+			if rr.Body.String() != tt.ExpectedBody {
+				t.Errorf("expected body %q but was %q", tt.ExpectedBody, rr.Body.String())
+			}
+		})
+	}
+}
+
+// TestStream is not parallel, as draining closes streams of other tests.
+func TestStream(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(headkey.LastEventID, "41")
+
+	rr := httptest.NewRecorder()
+
+	stream, err := sse.NewWithConfig(rr, req, sse.Config{Retry: time.Second})
+	if err != nil {
+		t.Fatalf("NewWithConfig: %s", err)
+	}
+
+	if stream.LastEventID() != "41" {
+		t.Errorf("expected last event ID %q but was %q", "41", stream.LastEventID())
+	}
+
+	if rr.Header().Get(headkey.ContentType) != headval.MIMETextEventStream {
+		t.Errorf(
+			"expected content type %q but was %q",
+			headval.MIMETextEventStream,
+			rr.Header().Get(headkey.ContentType),
+		)
+	}
+
+	if rr.Body.String() != "retry: 1000\n\n" {
+		t.Errorf("expected retry to be advertised but body was %q", rr.Body.String())
+	}
+
+	sse.Drain()
+
+	select {
+	case <-stream.Context().Done():
+	case <-time.After(time.Second):
+		t.Fatal("expected stream context to be done once drained")
+	}
+
+	err = stream.Send(sse.Event{Data: "hello"})
+	if !errors.Is(err, sse.ErrStreamClosed) {
+		t.Errorf("expected error %v but was %v", sse.ErrStreamClosed, err)
+	}
+}
+
+func TestStalledClient(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Name         string
+		WriteTimeout time.Duration
+		Close        bool
+	}{
+		{
+			Name:         "write timeout",
+			WriteTimeout: 50 * time.Millisecond,
+		},
+		{
+			Name:         "closed while writing",
+			WriteTimeout: time.Hour,
+			Close:        true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			t.Parallel()
+
+			streams := make(chan *sse.Stream, 1)
+			errs := make(chan error, 1)
+
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				stream, err := sse.NewWithConfig(w, r, sse.Config{WriteTimeout: tt.WriteTimeout})
+				if err != nil {
+					errs <- err
+
+					return
+				}
+
+				streams <- stream
+
+				// Fill connection buffers until writes block
+				data := strings.Repeat("a", 64<<10)
+				for err == nil {
+					err = stream.Send(sse.Event{Data: data})
+				}
+
+				stream.Close()
+				errs <- err
+			}))
+			defer srv.Close()
+
+			// Client never reads response
+			conn, err := net.Dial("tcp", srv.Listener.Addr().String())
+			if err != nil {
+				t.Fatalf("Dial: %s", err)
+			}
+			defer conn.Close()
+
+			_, err = conn.Write([]byte("GET / HTTP/1.1\r\nHost: example.com\r\n\r\n"))
+			if err != nil {
+				t.Fatalf("error writing request: %s", err)
+			}
+
+			stream := <-streams
+
+			if tt.Close {
+				time.Sleep(100 * time.Millisecond)
+
+				closed := make(chan struct{})
+
+				go func() {
+					stream.Close()
+					close(closed)
+				}()
+
+				select {
+				case <-closed:
+				case <-time.After(5 * time.Second):
+					t.Fatal("expected close not to be blocked by pending write")
+				}
+			}
+
+			select {
+			case err := <-errs:
+				if err == nil {
+					t.Error("expected send to fail")
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("expected pending write to be aborted")
+			}
+		})
+	}
+}
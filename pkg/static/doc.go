@@ -0,0 +1,7 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+// Package static serves static assets from an [io/fs.FS], precompressed once at startup. Each request is
+// served the best encoded variant its Accept-Encoding allows, with strong ETags and per-path cache policies,
+// so that conditional and range requests are handled without recompressing assets.
+package static
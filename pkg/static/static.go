@@ -0,0 +1,393 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package static
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/andybalholm/brotli"
+	"github.com/kemadev/go-framework/pkg/convenience/headkey"
+	"github.com/kemadev/go-framework/pkg/convenience/headutil"
+	"github.com/kemadev/go-framework/pkg/convenience/headval"
+	"github.com/kemadev/go-framework/pkg/encoding"
+	"github.com/klauspost/compress/zstd"
+)
+
+const packageName = "github.com/kemadev/go-framework/pkg/static"
+
+// DefaultMinSize is the default size under which assets are not precompressed.
+const DefaultMinSize = 1024
+
+// fingerprintLength is the number of hexadecimal characters of content hash in fingerprinted paths.
+const fingerprintLength = 8
+
+var (
+	ErrInvalidPattern = errors.New("invalid cache policy pattern")
+	ErrAssetNotFound  = errors.New("asset not found")
+)
+
+var (
+	// CacheRevalidate lets clients cache assets, revalidating them using ETag on each use.
+	CacheRevalidate = headutil.CacheHeader{
+		Public:  true,
+		NoCache: true,
+	}
+	// CacheImmutable lets clients cache assets for a year without revalidation, for assets whose path changes
+	// with their content (e.g. fingerprinted assets).
+	CacheImmutable = headutil.CacheHeader{
+		Public:    true,
+		MaxAge:    365 * 24 * time.Hour,
+		Immutable: true,
+	}
+)
+
+// CachePolicy associates a cache policy to assets whose path matches Pattern.
+type CachePolicy struct {
+	// Pattern matched against asset path in file system, see [path.Match]
+	Pattern string
+	// Cache-Control header to send
+	Header headutil.CacheHeader
+}
+
+// Config defines the configuration for static handler.
+type Config struct {
+	// Encoders used to precompress assets, by order of server preference. Defaults to zstd, br and gzip at
+	// their best compression levels, as compression only happens once.
+	Encoders []encoding.Encoder
+	// Size under which assets are not precompressed. Defaults to [DefaultMinSize].
+	MinSize int
+	// Media types that are not precompressed. Defaults to [encoding.DefaultSkipContentTypes].
+	SkipContentTypes []string
+	// Cache policies, the first one whose pattern matches asset path applies
+	CachePolicies []CachePolicy
+	// Cache policy of assets matching none of CachePolicies. Defaults to [CacheRevalidate].
+	DefaultCachePolicy *headutil.CacheHeader
+	// URL path prefix handler is mounted at, stripped from request path to get asset path. Defaults to "/".
+	Prefix string
+}
+
+// Handler serves static assets, see [New].
+type Handler struct {
+	assets        map[string]*asset
+	fingerprinted map[string]*asset
+	policies      []CachePolicy
+	fallback      headutil.CacheHeader
+	prefix        string
+}
+
+type asset struct {
+	contentType string
+	hash        string
+	// fingerprinted asset path, see [Handler.Asset]
+	fingerprint string
+	// Subresource Integrity metadata, see [Handler.Integrity]
+	integrity string
+	// variants by order of server preference, identity last
+	variants []variant
+	offers   []string
+}
+
+type variant struct {
+	encoding string
+	etag     string
+	data     []byte
+}
+
+// New returns a [Handler] serving assets of [fsys], keyed by their path, with default configuration.
+func New(fsys fs.FS) (*Handler, error) {
+	return NewWithConfig(fsys, Config{})
+}
+
+// NewWithConfig returns a [Handler] serving assets of [fsys], keyed by their path. All assets are read and
+// precompressed, which should only be used with file systems of reasonable size such as [embed.FS]. Each asset
+// is also served at a fingerprinted path embedding its content hash (e.g. app.css at app.3f9a1c2b.css), with
+// [CacheImmutable] policy, see [Handler.Asset].
+func NewWithConfig(fsys fs.FS, conf Config) (*Handler, error) {
+	if len(conf.Encoders) == 0 {
+		conf.Encoders = []encoding.Encoder{
+			encoding.NewZstdEncoder(zstd.SpeedBestCompression),
+			encoding.NewBrotliEncoder(brotli.BestCompression),
+			encoding.NewGzipEncoder(gzip.BestCompression),
+		}
+	}
+
+	if conf.MinSize == 0 {
+		conf.MinSize = DefaultMinSize
+	}
+
+	if conf.SkipContentTypes == nil {
+		conf.SkipContentTypes = encoding.DefaultSkipContentTypes
+	}
+
+	if conf.DefaultCachePolicy == nil {
+		conf.DefaultCachePolicy = &CacheRevalidate
+	}
+
+	if conf.Prefix == "" {
+		conf.Prefix = "/"
+	}
+
+	for _, policy := range conf.CachePolicies {
+		_, err := path.Match(policy.Pattern, "")
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidPattern, policy.Pattern)
+		}
+	}
+
+	h := &Handler{
+		assets:        make(map[string]*asset),
+		fingerprinted: make(map[string]*asset),
+		policies:      conf.CachePolicies,
+		fallback:      *conf.DefaultCachePolicy,
+		prefix:        "/" + strings.Trim(conf.Prefix, "/"),
+	}
+
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			return nil
+		}
+
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return fmt.Errorf("error reading asset %s: %w", name, err)
+		}
+
+		a, err := newAsset(name, data, conf)
+		if err != nil {
+			return err
+		}
+
+		h.assets[name] = a
+		h.fingerprinted[a.fingerprint] = a
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error loading assets: %w", err)
+	}
+
+	return h, nil
+}
+
+func newAsset(name string, data []byte, conf Config) (*asset, error) {
+	contentType := mime.TypeByExtension(path.Ext(name))
+	if contentType == "" {
+		contentType = http.DetectContentType(data)
+	}
+
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:16])
+
+	// sha384 is the most widely supported algorithm, see https://www.w3.org/TR/sri/
+	integrity := sha512.Sum384(data)
+
+	a := &asset{
+		contentType: contentType,
+		hash:        hash,
+		fingerprint: fingerprint(name, hash[:fingerprintLength]),
+		integrity:   "sha384-" + base64.StdEncoding.EncodeToString(integrity[:]),
+	}
+
+	if len(data) >= conf.MinSize && !encoding.MatchContentType(contentType, conf.SkipContentTypes) {
+		for _, encoder := range conf.Encoders {
+			compressed, err := compress(encoder, data)
+			if err != nil {
+				return nil, fmt.Errorf("error compressing asset %s: %w", name, err)
+			}
+
+			// Only keep worthwhile variants
+			if len(compressed) >= len(data) {
+				continue
+			}
+
+			a.variants = append(a.variants, variant{
+				encoding: encoder.Encoding(),
+				// ETags are representation specific, see RFC 9110 section 8.8.3
+				etag: `"` + hash + "-" + encoder.Encoding() + `"`,
+				data: compressed,
+			})
+			a.offers = append(a.offers, encoder.Encoding())
+		}
+	}
+
+	a.variants = append(a.variants, variant{
+		encoding: headval.EncodingIdentity,
+		etag:     `"` + hash + `"`,
+		data:     data,
+	})
+	a.offers = append(a.offers, headval.EncodingIdentity)
+
+	return a, nil
+}
+
+// fingerprint inserts [hash] before [name] extension, if any.
+func fingerprint(name string, hash string) string {
+	ext := path.Ext(name)
+
+	return strings.TrimSuffix(name, ext) + "." + hash + ext
+}
+
+func compress(encoder encoding.Encoder, data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+
+	writer, err := encoder.Acquire(&buf)
+	if err != nil {
+		return nil, fmt.Errorf("error acquiring %s writer: %w", encoder.Encoding(), err)
+	}
+	defer encoder.Release(writer)
+
+	_, err = writer.Write(data)
+	if err != nil {
+		return nil, fmt.Errorf("error writing %s data: %w", encoder.Encoding(), err)
+	}
+
+	err = writer.Close()
+	if err != nil {
+		return nil, fmt.Errorf("error closing %s writer: %w", encoder.Encoding(), err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// ServeHTTP implements [net/http.Handler]. Request path, stripped from configured prefix, is used as asset
+// path, and paths ending with a slash are served their index.html asset.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	a, policy, ok := h.lookup(r.URL.Path)
+	if !ok {
+		http.NotFound(w, r)
+
+		return
+	}
+
+	v := a.variants[len(a.variants)-1]
+
+	if len(a.variants) > 1 {
+		headutil.AddVary(w.Header(), headkey.AcceptEncoding)
+
+		// Ranges are served from identity, as few clients expect ranges of encoded representations
+		if r.Header.Get(headkey.Range) == "" {
+			coding, _ := headutil.NegotiateEncoding(r.Header, a.offers)
+			for _, candidate := range a.variants {
+				if candidate.encoding == coding {
+					v = candidate
+
+					break
+				}
+			}
+		}
+	}
+
+	head := w.Header()
+	head.Set(headkey.ContentType, a.contentType)
+	head.Set(headkey.ETag, v.etag)
+	head.Set(headkey.CacheControl, policy.Build())
+
+	if v.encoding != headval.EncodingIdentity {
+		head.Set(headkey.ContentEncoding, v.encoding)
+	}
+
+	// Handles conditional requests, ranges and HEAD requests
+	http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(v.data))
+}
+
+// lookup returns asset served at [urlPath], along with its cache policy.
+func (h *Handler) lookup(urlPath string) (*asset, *headutil.CacheHeader, bool) {
+	cleaned := path.Clean("/" + urlPath)
+	if h.prefix != "/" {
+		if cleaned != h.prefix && !strings.HasPrefix(cleaned, h.prefix+"/") {
+			return nil, nil, false
+		}
+
+		cleaned = strings.TrimPrefix(cleaned, h.prefix)
+	}
+
+	name := strings.TrimPrefix(cleaned, "/")
+	if strings.HasSuffix(urlPath, "/") {
+		name = path.Join(name, "index.html")
+	}
+
+	if a, ok := h.assets[name]; ok {
+		return a, h.cachePolicy(name), true
+	}
+
+	if a, ok := h.fingerprinted[name]; ok {
+		return a, &CacheImmutable, true
+	}
+
+	return nil, nil, false
+}
+
+// Asset returns the URL path of fingerprinted asset at [name], which can be cached forever as it changes along
+// with asset content.
+func (h *Handler) Asset(name string) (string, error) {
+	a, ok := h.assets[strings.TrimPrefix(name, "/")]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrAssetNotFound, name)
+	}
+
+	return path.Join(h.prefix, a.fingerprint), nil
+}
+
+// Integrity returns [Subresource Integrity] metadata of asset at [name], for use in integrity attributes.
+//
+// [Subresource Integrity]: https://developer.mozilla.org/en-US/docs/Web/Security/Subresource_Integrity
+func (h *Handler) Integrity(name string) (string, error) {
+	a, ok := h.assets[strings.TrimPrefix(name, "/")]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrAssetNotFound, name)
+	}
+
+	return a.integrity, nil
+}
+
+// Manifest returns fingerprinted URL paths by asset path, e.g. to be consumed by frontend tooling.
+func (h *Handler) Manifest() map[string]string {
+	manifest := make(map[string]string, len(h.assets))
+
+	for name, a := range h.assets {
+		manifest[name] = path.Join(h.prefix, a.fingerprint)
+	}
+
+	return manifest
+}
+
+// FuncMap returns template functions "asset" and "integrity", wrapping [Handler.Asset] and
+// [Handler.Integrity], so that templates reference assets as in:
+//
+//	<script src="{{ asset "app.js" }}" integrity="{{ integrity "app.js" }}"></script>
+func (h *Handler) FuncMap() template.FuncMap {
+	return template.FuncMap{
+		"asset":     h.Asset,
+		"integrity": h.Integrity,
+	}
+}
+
+func (h *Handler) cachePolicy(name string) *headutil.CacheHeader {
+	for _, policy := range h.policies {
+		// Patterns are validated upon creation
+		if ok, _ := path.Match(policy.Pattern, name); ok {
+			return &policy.Header
+		}
+	}
+
+	return &h.fallback
+}
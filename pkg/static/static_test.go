@@ -0,0 +1,343 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package static_test
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/kemadev/go-framework/pkg/convenience/headkey"
+	"github.com/kemadev/go-framework/pkg/convenience/headutil"
+	"github.com/kemadev/go-framework/pkg/static"
+)
+
+var (
+	css  = []byte(strings.Repeat("body { color: black; }\n", 128))
+	png  = []byte("\x89PNG\x0D\x0A\x1A\x0A" + strings.Repeat("\x00", 2048))
+	html = []byte("<!DOCTYPE html><title>docs</title>")
+)
+
+var assets = fstest.MapFS{
+	"app.css":         {Data: css},
+	"logo.png":        {Data: png},
+	"docs/index.html": {Data: html},
+}
+
+var noStore = headutil.CacheHeader{NoStore: true}
+
+// etag returns the strong ETag of [data], as encoded with [coding], if any.
+func etag(data []byte, coding string) string {
+	sum := sha256.Sum256(data)
+
+	tag := hex.EncodeToString(sum[:16])
+	if coding != "" {
+		tag += "-" + coding
+	}
+
+	return `"` + tag + `"`
+}
+
+func TestHandler(t *testing.T) {
+	t.Parallel()
+
+	h, err := static.NewWithConfig(assets, static.Config{
+		Prefix: "/static/",
+		CachePolicies: []static.CachePolicy{
+			{Pattern: "docs/*", Header: noStore},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewWithConfig: %s", err)
+	}
+
+	tests := []struct {
+		Name                 string
+		Path                 string
+		AcceptEncoding       string
+		IfNoneMatch          string
+		Range                string
+		ExpectedStatus       int
+		ExpectedEncoding     string
+		ExpectedETag         string
+		ExpectedVary         string
+		ExpectedCacheControl string
+		ExpectedContentType  string
+	}{
+		{
+			Name:                 "server preference",
+			Path:                 "/static/app.css",
+			AcceptEncoding:       "gzip, br, zstd",
+			ExpectedStatus:       http.StatusOK,
+			ExpectedEncoding:     "zstd",
+			ExpectedETag:         etag(css, "zstd"),
+			ExpectedVary:         headkey.AcceptEncoding,
+			ExpectedCacheControl: static.CacheRevalidate.Build(),
+			ExpectedContentType:  "text/css; charset=utf-8",
+		},
+		{
+			Name:                 "client preference",
+			Path:                 "/static/app.css",
+			AcceptEncoding:       "gzip, br;q=0.5",
+			ExpectedStatus:       http.StatusOK,
+			ExpectedEncoding:     "gzip",
+			ExpectedETag:         etag(css, "gzip"),
+			ExpectedVary:         headkey.AcceptEncoding,
+			ExpectedCacheControl: static.CacheRevalidate.Build(),
+			ExpectedContentType:  "text/css; charset=utf-8",
+		},
+		{
+			Name:                 "identity",
+			Path:                 "/static/app.css",
+			ExpectedStatus:       http.StatusOK,
+			ExpectedETag:         etag(css, ""),
+			ExpectedVary:         headkey.AcceptEncoding,
+			ExpectedCacheControl: static.CacheRevalidate.Build(),
+			ExpectedContentType:  "text/css; charset=utf-8",
+		},
+		{
+			Name:                 "not modified",
+			Path:                 "/static/app.css",
+			AcceptEncoding:       "br",
+			IfNoneMatch:          etag(css, "br"),
+			ExpectedStatus:       http.StatusNotModified,
+			ExpectedETag:         etag(css, "br"),
+			ExpectedVary:         headkey.AcceptEncoding,
+			ExpectedCacheControl: static.CacheRevalidate.Build(),
+		},
+		{
+			Name:                 "other representation modified",
+			Path:                 "/static/app.css",
+			AcceptEncoding:       "br",
+			IfNoneMatch:          etag(css, "gzip"),
+			ExpectedStatus:       http.StatusOK,
+			ExpectedEncoding:     "br",
+			ExpectedETag:         etag(css, "br"),
+			ExpectedVary:         headkey.AcceptEncoding,
+			ExpectedCacheControl: static.CacheRevalidate.Build(),
+			ExpectedContentType:  "text/css; charset=utf-8",
+		},
+		{
+			Name:                 "range served from identity",
+			Path:                 "/static/app.css",
+			AcceptEncoding:       "zstd",
+			Range:                "bytes=0-3",
+			ExpectedStatus:       http.StatusPartialContent,
+			ExpectedETag:         etag(css, ""),
+			ExpectedVary:         headkey.AcceptEncoding,
+			ExpectedCacheControl: static.CacheRevalidate.Build(),
+			ExpectedContentType:  "text/css; charset=utf-8",
+		},
+		{
+			Name:                 "already compressed type",
+			Path:                 "/static/logo.png",
+			AcceptEncoding:       "zstd, br, gzip",
+			ExpectedStatus:       http.StatusOK,
+			ExpectedETag:         etag(png, ""),
+			ExpectedCacheControl: static.CacheRevalidate.Build(),
+			ExpectedContentType:  "image/png",
+		},
+		{
+			Name:                 "index and cache policy",
+			Path:                 "/static/docs/",
+			AcceptEncoding:       "gzip",
+			ExpectedStatus:       http.StatusOK,
+			ExpectedETag:         etag(html, ""),
+			ExpectedCacheControl: noStore.Build(),
+			ExpectedContentType:  "text/html; charset=utf-8",
+		},
+		{
+			Name:           "outside prefix",
+			Path:           "/app.css",
+			ExpectedStatus: http.StatusNotFound,
+		},
+		{
+			Name:           "traversal",
+			Path:           "/static/../static/../app.css",
+			ExpectedStatus: http.StatusNotFound,
+		},
+		{
+			Name:           "unknown asset",
+			Path:           "/static/app.js",
+			ExpectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			t.Parallel()
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.URL.Path = tt.Path
+
+			if tt.AcceptEncoding != "" {
+				r.Header.Set(headkey.AcceptEncoding, tt.AcceptEncoding)
+			}
+
+			if tt.IfNoneMatch != "" {
+				r.Header.Set(headkey.IfNoneMatch, tt.IfNoneMatch)
+			}
+
+			if tt.Range != "" {
+				r.Header.Set(headkey.Range, tt.Range)
+			}
+
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, r)
+
+			if rr.Code != tt.ExpectedStatus {
+				t.Fatalf("expected status %d but was %d", tt.ExpectedStatus, rr.Code)
+			}
+
+			if tt.ExpectedStatus == http.StatusNotFound {
+				return
+			}
+
+			head := rr.Header()
+
+			expected := map[string]string{
+				headkey.ContentEncoding: tt.ExpectedEncoding,
+				headkey.ETag:            tt.ExpectedETag,
+				headkey.Vary:            tt.ExpectedVary,
+				headkey.CacheControl:    tt.ExpectedCacheControl,
+				headkey.ContentType:     tt.ExpectedContentType,
+			}
+
+			for key, value := range expected {
+				if head.Get(key) != value {
+					t.Errorf("expected %s %q but was %q", key, value, head.Get(key))
+				}
+			}
+		})
+	}
+}
+
+func TestNewWithConfig(t *testing.T) {
+	t.Parallel()
+
+	_, err := static.NewWithConfig(assets, static.Config{
+		CachePolicies: []static.CachePolicy{
+			{Pattern: "[", Header: noStore},
+		},
+	})
+	if err == nil {
+		t.Errorf("expected invalid pattern error")
+	}
+}
+
+// fingerprinted returns fingerprinted URL path of asset [name] holding [data], served at [prefix].
+func fingerprinted(prefix string, name string, data []byte) string {
+	sum := sha256.Sum256(data)
+	base, ext, _ := strings.Cut(name, ".")
+
+	return prefix + base + "." + hex.EncodeToString(sum[:4]) + "." + ext
+}
+
+func TestFingerprint(t *testing.T) {
+	t.Parallel()
+
+	h, err := static.NewWithConfig(assets, static.Config{Prefix: "/static"})
+	if err != nil {
+		t.Fatalf("NewWithConfig: %s", err)
+	}
+
+	cssPath := fingerprinted("/static/", "app.css", css)
+
+	path, err := h.Asset("/app.css")
+	if err != nil || path != cssPath {
+		t.Errorf("expected asset path %q but was %q (error %v)", cssPath, path, err)
+	}
+
+	_, err = h.Asset("app.js")
+	if !errors.Is(err, static.ErrAssetNotFound) {
+		t.Errorf("expected error %v but was %v", static.ErrAssetNotFound, err)
+	}
+
+	expectedManifest := map[string]string{
+		"app.css":         cssPath,
+		"logo.png":        fingerprinted("/static/", "logo.png", png),
+		"docs/index.html": fingerprinted("/static/docs/", "index.html", html),
+	}
+	if !reflect.DeepEqual(h.Manifest(), expectedManifest) {
+		t.Errorf("expected manifest %v but was %v", expectedManifest, h.Manifest())
+	}
+
+	// Fingerprinted assets are cached forever, regardless of cache policies
+	r := httptest.NewRequest(http.MethodGet, cssPath, nil)
+	r.Header.Set(headkey.AcceptEncoding, "gzip")
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, r)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d but was %d", http.StatusOK, rr.Code)
+	}
+
+	if rr.Header().Get(headkey.CacheControl) != static.CacheImmutable.Build() {
+		t.Errorf(
+			"expected Cache-Control %q but was %q",
+			static.CacheImmutable.Build(),
+			rr.Header().Get(headkey.CacheControl),
+		)
+	}
+
+	if rr.Header().Get(headkey.ETag) != etag(css, "gzip") {
+		t.Errorf("expected ETag %q but was %q", etag(css, "gzip"), rr.Header().Get(headkey.ETag))
+	}
+}
+
+func TestIntegrity(t *testing.T) {
+	t.Parallel()
+
+	h, err := static.New(assets)
+	if err != nil {
+		t.Fatalf("New: %s", err)
+	}
+
+	sum := sha512.Sum384(css)
+	expected := "sha384-" + base64.StdEncoding.EncodeToString(sum[:])
+
+	integrity, err := h.Integrity("app.css")
+	if err != nil || integrity != expected {
+		t.Errorf("expected integrity %q but was %q (error %v)", expected, integrity, err)
+	}
+
+	_, err = h.Integrity("app.js")
+	if !errors.Is(err, static.ErrAssetNotFound) {
+		t.Errorf("expected error %v but was %v", static.ErrAssetNotFound, err)
+	}
+
+	tmpl := template.Must(template.New("page").Funcs(h.FuncMap()).Parse(
+		`<link rel="stylesheet" href="{{ asset "app.css" }}" integrity="{{ integrity "app.css" }}">`,
+	))
+
+	var b strings.Builder
+
+	err = tmpl.Execute(&b, nil)
+	if err != nil {
+		t.Fatalf("Execute: %s", err)
+	}
+
+	expectedHTML := `<link rel="stylesheet" href="` + fingerprinted("/", "app.css", css) +
+		`" integrity="` + expected + `">`
+	if b.String() != expectedHTML {
+		t.Errorf("expected %q but was %q", expectedHTML, b.String())
+	}
+
+	// Unknown assets fail rendering rather than producing broken references
+	err = template.Must(template.New("page").Funcs(h.FuncMap()).Parse(`{{ asset "app.js" }}`)).
+		Execute(&b, nil)
+	if !errors.Is(err, static.ErrAssetNotFound) {
+		t.Errorf("expected error %v but was %v", static.ErrAssetNotFound, err)
+	}
+}
@@ -4,18 +4,177 @@
 package timeout
 
 import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
 	"net/http"
 	"time"
+
+	"github.com/kemadev/go-framework/pkg/convenience/headutil"
+	"github.com/kemadev/go-framework/pkg/convenience/log"
+	"github.com/kemadev/go-framework/pkg/convenience/resp"
+	"github.com/kemadev/go-framework/pkg/router"
 )
 
+const packageName = "github.com/kemadev/go-framework/pkg/timeout"
+
+// Config defines the configuration for timeout middleware.
+type Config struct {
+	// Timeout is the maximum duration for handlers to complete, requests having no timeout if zero
+	Timeout time.Duration
+	// Router used to find [Override] annotations of requests routes, see [For] and [Disabled]
+	Router *router.Router
+	// Header carrying remaining time of callers deadline, formatted as [headutil.ParseGRPCTimeout], e.g.
+	// [github.com/kemadev/go-framework/pkg/convenience/headkey.GRPCTimeout]. As clients set it, it can only
+	// shorten timeout. Deadlines are not propagated if empty
+	Header string
+}
+
+// Override is a route timeout, overriding configured one, to be attached to routes and groups using
+// [router.Router.Annotate] or route annotations, see [router.Router.Handle]. When several overrides are
+// attached to a route, the last one applies.
+type Override struct {
+	timeout  time.Duration
+	disabled bool
+}
+
+// For returns an override setting route timeout to [t].
+func For(t time.Duration) Override {
+	return Override{
+		timeout: t,
+	}
+}
+
+// Disabled returns an override removing route timeout, e.g. for event streams and websockets.
+func Disabled() Override {
+	return Override{
+		disabled: true,
+	}
+}
+
 // WrapHandler returns an handler wrapping [handler] with a timeout set to [timeout].
 func WrapHandler(h http.Handler, t time.Duration) http.Handler {
-	return http.TimeoutHandler(h, t, http.StatusText(http.StatusServiceUnavailable))
+	return NewMiddlewareWithConfig(Config{Timeout: t})(h)
 }
 
 // NewMiddleware returns n middleware with a timeout set to [timeout].
 func NewMiddleware(t time.Duration) func(http.Handler) http.Handler {
+	return NewMiddlewareWithConfig(Config{Timeout: t})
+}
+
+// NewMiddlewareWithConfig returns a middleware with custom timeout configuration. It sets a deadline on request
+// context, which handlers must honor, as they are not interrupted. Responses are not buffered, so that handlers
+// can stream them. Handlers returning once deadline is exceeded without writing a response are answered with 504
+// status, using problem details, see [resp.ProblemJSON].
+func NewMiddlewareWithConfig(conf Config) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
-		return WrapHandler(next, t)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			t, ok := requestTimeout(conf, r)
+			if !ok {
+				next.ServeHTTP(w, r)
+
+				return
+			}
+
+			ctx, cancel := context.WithTimeout(r.Context(), t)
+			defer cancel()
+
+			tw := &timeoutResponseWriter{ResponseWriter: w}
+			next.ServeHTTP(tw, r.WithContext(ctx))
+
+			if tw.written || !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return
+			}
+
+			err := resp.ProblemJSON(w, resp.NewProblem(http.StatusGatewayTimeout, ""))
+			if err != nil {
+				log.ErrLog(packageName, "error sending problem", err)
+			}
+		})
+	}
+}
+
+// requestTimeout returns timeout of [r], if any.
+func requestTimeout(conf Config, r *http.Request) (time.Duration, bool) {
+	t := conf.Timeout
+
+	if conf.Router != nil {
+		route, found := conf.Router.Lookup(r)
+		if found {
+			for _, annotation := range route.Annotations {
+				override, ok := annotation.(Override)
+				if ok {
+					if override.disabled {
+						t = 0
+					} else {
+						t = override.timeout
+					}
+				}
+			}
+		}
 	}
+
+	if t <= 0 {
+		return 0, false
+	}
+
+	if conf.Header != "" && r.Header.Get(conf.Header) != "" {
+		// Invalid and non-positive values are ignored, falling back to configured timeout
+		propagated, err := headutil.ParseGRPCTimeout(r.Header.Get(conf.Header))
+		if err == nil && propagated > 0 {
+			t = min(t, propagated)
+		}
+	}
+
+	return t, true
+}
+
+// timeoutResponseWriter records whether a response was written.
+type timeoutResponseWriter struct {
+	http.ResponseWriter
+	written bool
+}
+
+// WriteHeader implements [net/http.ResponseWriter].
+func (w *timeoutResponseWriter) WriteHeader(code int) {
+	// Informational responses are followed by final one
+	if code >= http.StatusOK {
+		w.written = true
+	}
+
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Write implements [net/http.ResponseWriter].
+func (w *timeoutResponseWriter) Write(b []byte) (int, error) {
+	w.written = true
+
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush implements [net/http.Flusher].
+func (w *timeoutResponseWriter) Flush() {
+	w.written = true
+
+	err := http.NewResponseController(w.ResponseWriter).Flush()
+	if err != nil {
+		log.ErrLog(packageName, "error flushing response", err)
+	}
+}
+
+// Hijack implements [net/http.Hijacker] if the underlying ResponseWriter supports it.
+func (w *timeoutResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, brw, err := http.NewResponseController(w.ResponseWriter).Hijack()
+	if err == nil {
+		// Connection is no longer managed by server
+		w.written = true
+	}
+
+	return conn, brw, err
+}
+
+// Unwrap returns the underlying [net/http.ResponseWriter].
+func (w *timeoutResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
 }
@@ -0,0 +1,137 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package timeout_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/kemadev/go-framework/pkg/convenience/headkey"
+	"github.com/kemadev/go-framework/pkg/convenience/headval"
+	"github.com/kemadev/go-framework/pkg/router"
+	"github.com/kemadev/go-framework/pkg/timeout"
+)
+
+func TestMiddleware(t *testing.T) {
+	t.Parallel()
+
+	// Reports remaining time of request deadline, if any
+	deadline := func(w http.ResponseWriter, r *http.Request) {
+		d, ok := r.Context().Deadline()
+		if ok {
+			w.Header().Set("X-Remaining", time.Until(d).String())
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}
+
+	app := router.New()
+	app.Use(timeout.NewMiddlewareWithConfig(timeout.Config{
+		Timeout: time.Minute,
+		Router:  app,
+		Header:  headkey.GRPCTimeout,
+	}))
+
+	app.HandleFunc("GET /default", deadline)
+	app.HandleFunc("GET /long", deadline, timeout.For(time.Hour))
+	app.HandleFunc("GET /events", deadline, timeout.Disabled())
+	app.HandleFunc("GET /slow", func(_ http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}, timeout.For(10*time.Millisecond))
+	app.HandleFunc("GET /stream", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		<-r.Context().Done()
+	}, timeout.For(10*time.Millisecond))
+
+	tests := []struct {
+		RequestPath       string
+		TimeoutHeader     string
+		ExpectedStatus    int
+		ExpectedRemaining time.Duration
+	}{
+		{RequestPath: "/default", ExpectedStatus: http.StatusOK, ExpectedRemaining: time.Minute},
+		{RequestPath: "/long", ExpectedStatus: http.StatusOK, ExpectedRemaining: time.Hour},
+		{RequestPath: "/events", ExpectedStatus: http.StatusOK},
+		{RequestPath: "/events", TimeoutHeader: "1S", ExpectedStatus: http.StatusOK},
+		{
+			RequestPath:       "/default",
+			TimeoutHeader:     "30S",
+			ExpectedStatus:    http.StatusOK,
+			ExpectedRemaining: 30 * time.Second,
+		},
+		{
+			RequestPath:       "/default",
+			TimeoutHeader:     "2H",
+			ExpectedStatus:    http.StatusOK,
+			ExpectedRemaining: time.Minute,
+		},
+		{
+			RequestPath:       "/default",
+			TimeoutHeader:     "invalid",
+			ExpectedStatus:    http.StatusOK,
+			ExpectedRemaining: time.Minute,
+		},
+		{
+			RequestPath:       "/default",
+			TimeoutHeader:     "99999999H",
+			ExpectedStatus:    http.StatusOK,
+			ExpectedRemaining: time.Minute,
+		},
+		{
+			RequestPath:       "/default",
+			TimeoutHeader:     "0n",
+			ExpectedStatus:    http.StatusOK,
+			ExpectedRemaining: time.Minute,
+		},
+		{RequestPath: "/slow", ExpectedStatus: http.StatusGatewayTimeout},
+		{RequestPath: "/stream", ExpectedStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.RequestPath+" "+tt.TimeoutHeader, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(http.MethodGet, tt.RequestPath, nil)
+			if tt.TimeoutHeader != "" {
+				req.Header.Set(headkey.GRPCTimeout, tt.TimeoutHeader)
+			}
+
+			rr := httptest.NewRecorder()
+			app.ServeHTTP(rr, req)
+
+			if rr.Code != tt.ExpectedStatus {
+				t.Errorf("expected status %d but was %d", tt.ExpectedStatus, rr.Code)
+			}
+
+			if tt.ExpectedStatus == http.StatusGatewayTimeout &&
+				rr.Header().Get(headkey.ContentType) != headval.MIMEApplicationProblemJSON {
+				t.Errorf(
+					"expected content type %q but was %q",
+					headval.MIMEApplicationProblemJSON,
+					rr.Header().Get(headkey.ContentType),
+				)
+			}
+
+			remaining := rr.Header().Get("X-Remaining")
+			if tt.ExpectedRemaining == 0 {
+				if remaining != "" {
+					t.Errorf("expected no deadline but %s remained", remaining)
+				}
+
+				return
+			}
+
+			d, err := time.ParseDuration(remaining)
+			if err != nil {
+				t.Fatalf("expected a deadline but remaining time was %q", remaining)
+			}
+
+			if d > tt.ExpectedRemaining || d < tt.ExpectedRemaining-time.Second {
+				t.Errorf("expected %s remaining but was %s", tt.ExpectedRemaining, d)
+			}
+		})
+	}
+}
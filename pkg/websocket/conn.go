@@ -0,0 +1,522 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package websocket
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// MessageType is the type of a data message.
+type MessageType int
+
+const (
+	// MessageText denotes UTF-8 encoded text messages.
+	MessageText MessageType = iota + 1
+	// MessageBinary denotes binary messages.
+	MessageBinary
+)
+
+// String returns the string representation of the message type.
+func (t MessageType) String() string {
+	switch t {
+	case MessageText:
+		return "text"
+	case MessageBinary:
+		return "binary"
+	default:
+		return "unknown"
+	}
+}
+
+// Messages smaller than this are not worth compressing.
+const compressionMinThreshold = 128
+
+// Write timeout for a single frame.
+const frameWriteTimeout = 10 * time.Second
+
+type outgoing struct {
+	opcode  byte
+	payload []byte
+	written chan struct{}
+}
+
+// Conn is a server-side websocket connection. Reads and writes can be performed concurrently, that is,
+// a single goroutine can read while others write.
+type Conn struct {
+	c           context.Context
+	conn        net.Conn
+	br          *bufio.Reader
+	bw          *bufio.Writer
+	conf        Config
+	subprotocol string
+	compressed  bool
+	span        trace.Span
+
+	queue   chan outgoing
+	control chan outgoing
+	done    chan struct{}
+
+	readMu       sync.Mutex
+	closeOnce    sync.Once
+	teardownOnce sync.Once
+	closeSent    atomic.Bool
+	peerClosed   chan struct{}
+	peerOnce     sync.Once
+	lastPong     atomic.Int64
+}
+
+func newConn(
+	c context.Context,
+	netConn net.Conn,
+	br *bufio.Reader,
+	conf Config,
+	subprotocol string,
+	compressed bool,
+	span trace.Span,
+) *Conn {
+	conn := &Conn{
+		c:           c,
+		conn:        netConn,
+		br:          br,
+		bw:          bufio.NewWriter(netConn),
+		conf:        conf,
+		subprotocol: subprotocol,
+		compressed:  compressed,
+		span:        span,
+		queue:       make(chan outgoing, conf.SendQueueSize),
+		control:     make(chan outgoing, 4),
+		done:        make(chan struct{}),
+		peerClosed:  make(chan struct{}),
+	}
+
+	conn.lastPong.Store(time.Now().UnixNano())
+	getMetrics().connections.Add(c, 1)
+
+	go conn.writeLoop()
+
+	return conn
+}
+
+// Subprotocol returns the negotiated subprotocol, if any.
+func (c *Conn) Subprotocol() string {
+	return c.subprotocol
+}
+
+// Done returns a channel that is closed when the connection is closed.
+func (c *Conn) Done() <-chan struct{} {
+	return c.done
+}
+
+// Read reads the next data message, handling control frames in the meantime. It returns a [*CloseError]
+// when the peer closes the connection. Cancelling ctx while reading closes the connection, as a partially
+// read frame can't be recovered from.
+func (c *Conn) Read(ctx context.Context) (MessageType, []byte, error) {
+	c.readMu.Lock()
+	defer c.readMu.Unlock()
+
+	stop := context.AfterFunc(ctx, func() {
+		_ = c.conn.SetReadDeadline(time.Unix(1, 0))
+	})
+	defer stop()
+
+	typ, data, err := c.readMessage()
+	if err != nil && ctx.Err() != nil {
+		c.teardown(StatusGoingAway)
+
+		return 0, nil, ctx.Err()
+	}
+
+	return typ, data, err
+}
+
+// Write queues a data message for sending. It blocks when the send queue is full, until space is available,
+// ctx is done or the connection is closed, so that slow clients apply backpressure to producers. Data is
+// copied and can be reused once Write returns.
+func (c *Conn) Write(ctx context.Context, typ MessageType, data []byte) error {
+	opcode := opBinary
+	if typ == MessageText {
+		opcode = opText
+	}
+
+	msg := outgoing{
+		opcode:  opcode,
+		payload: append([]byte(nil), data...),
+	}
+
+	select {
+	case <-c.done:
+		return ErrClosed
+	default:
+	}
+
+	select {
+	case c.queue <- msg:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-c.done:
+		return ErrClosed
+	}
+}
+
+// Close performs the closing handshake with given status code and reason, then closes the underlying
+// connection. It is safe to call it multiple times and concurrently with other methods. Codes that must not be
+// sent, such as [StatusNoStatusReceived] and [StatusAbnormalClosure], are replaced with [StatusNormalClosure],
+// and reason is truncated to fit in a control frame.
+func (c *Conn) Close(code StatusCode, reason string) error {
+	// See https://www.rfc-editor.org/rfc/rfc6455#section-7.4.1
+	if !code.valid() {
+		code = StatusNormalClosure
+	}
+
+	c.closeOnce.Do(func() {
+		c.sendClose(code, reason)
+
+		// Wait for peer's close frame, reading it ourselves if nobody is reading
+		if c.readMu.TryLock() {
+			_ = c.conn.SetReadDeadline(time.Now().Add(c.conf.CloseTimeout))
+
+			for {
+				_, _, err := c.readMessage()
+				if err != nil {
+					break
+				}
+			}
+
+			c.readMu.Unlock()
+		} else {
+			select {
+			case <-c.peerClosed:
+			case <-c.done:
+			case <-time.After(c.conf.CloseTimeout):
+			}
+		}
+
+		c.teardown(code)
+	})
+
+	return nil
+}
+
+func (c *Conn) sendClose(code StatusCode, reason string) {
+	if !c.closeSent.CompareAndSwap(false, true) {
+		return
+	}
+
+	// Reason is truncated on a rune boundary, as peers fail the connection upon invalid UTF-8
+	reason = strings.ToValidUTF8(reason, "")
+	if len(reason) > maxControlPayload-2 {
+		n := maxControlPayload - 2
+		for !utf8.RuneStart(reason[n]) {
+			n--
+		}
+
+		reason = reason[:n]
+	}
+
+	payload := make([]byte, 2, 2+len(reason))
+	binary.BigEndian.PutUint16(payload, uint16(code))
+	payload = append(payload, reason...)
+
+	msg := outgoing{
+		opcode:  opClose,
+		payload: payload,
+		written: make(chan struct{}),
+	}
+
+	select {
+	case c.control <- msg:
+	case <-c.done:
+		return
+	}
+
+	select {
+	case <-msg.written:
+	case <-c.done:
+	case <-time.After(c.conf.CloseTimeout):
+	}
+}
+
+func (c *Conn) readMessage() (MessageType, []byte, error) {
+	var (
+		opcode     byte
+		compressed bool
+		inMessage  bool
+		buf        []byte
+	)
+
+	for {
+		h, err := readFrameHeader(c.br)
+		if err != nil {
+			return 0, nil, c.fail(err, StatusProtocolError)
+		}
+
+		if !h.masked {
+			return 0, nil, c.fail(fmt.Errorf("client frame not masked: %w", ErrProtocol), StatusProtocolError)
+		}
+
+		if h.rsv1 && (!c.compressed || isControl(h.opcode) || h.opcode == opContinuation) {
+			return 0, nil, c.fail(fmt.Errorf("unexpected compressed frame: %w", ErrProtocol), StatusProtocolError)
+		}
+
+		if isControl(h.opcode) {
+			payload, err := c.readPayload(h)
+			if err != nil {
+				return 0, nil, c.fail(err, StatusProtocolError)
+			}
+
+			err = c.handleControl(h.opcode, payload)
+			if err != nil {
+				return 0, nil, err
+			}
+
+			continue
+		}
+
+		if h.opcode == opContinuation {
+			if !inMessage {
+				return 0, nil, c.fail(fmt.Errorf("unexpected continuation frame: %w", ErrProtocol), StatusProtocolError)
+			}
+		} else {
+			if inMessage {
+				return 0, nil, c.fail(fmt.Errorf("expected continuation frame: %w", ErrProtocol), StatusProtocolError)
+			}
+
+			opcode, compressed, inMessage = h.opcode, h.rsv1, true
+		}
+
+		if int64(len(buf))+h.length > c.conf.ReadLimit {
+			return 0, nil, c.fail(ErrMessageTooBig, StatusMessageTooBig)
+		}
+
+		payload, err := c.readPayload(h)
+		if err != nil {
+			return 0, nil, c.fail(err, StatusProtocolError)
+		}
+
+		buf = append(buf, payload...)
+
+		if h.fin {
+			break
+		}
+	}
+
+	if compressed {
+		var err error
+
+		buf, err = decompress(buf, c.conf.ReadLimit)
+		if err != nil {
+			if errors.Is(err, ErrMessageTooBig) {
+				return 0, nil, c.fail(err, StatusMessageTooBig)
+			}
+
+			return 0, nil, c.fail(err, StatusInvalidPayloadData)
+		}
+	}
+
+	typ := MessageBinary
+	if opcode == opText {
+		typ = MessageText
+
+		if !utf8.Valid(buf) {
+			return 0, nil, c.fail(ErrInvalidUTF8, StatusInvalidPayloadData)
+		}
+	}
+
+	c.record("received", typ, len(buf))
+
+	return typ, buf, nil
+}
+
+func (c *Conn) readPayload(h frameHeader) ([]byte, error) {
+	payload := make([]byte, h.length)
+
+	_, err := io.ReadFull(c.br, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	maskBytes(h.mask, 0, payload)
+
+	return payload, nil
+}
+
+func (c *Conn) handleControl(opcode byte, payload []byte) error {
+	switch opcode {
+	case opPing:
+		select {
+		case c.control <- outgoing{opcode: opPong, payload: payload}:
+		default:
+			// Only the most recent ping requires a pong, drop it under pressure
+		}
+	case opPong:
+		c.lastPong.Store(time.Now().UnixNano())
+	case opClose:
+		code, reason := StatusNoStatusReceived, ""
+
+		switch {
+		case len(payload) == 1:
+			return c.fail(fmt.Errorf("invalid close payload: %w", ErrProtocol), StatusProtocolError)
+		case len(payload) >= 2:
+			code = StatusCode(binary.BigEndian.Uint16(payload))
+			reason = string(payload[2:])
+
+			if !code.valid() {
+				return c.fail(fmt.Errorf("invalid close code %d: %w", code, ErrProtocol), StatusProtocolError)
+			}
+
+			if !utf8.ValidString(reason) {
+				return c.fail(ErrInvalidUTF8, StatusInvalidPayloadData)
+			}
+		}
+
+		c.peerOnce.Do(func() { close(c.peerClosed) })
+
+		// Echo close frame, see https://www.rfc-editor.org/rfc/rfc6455#section-5.5.1
+		echo := code
+		if echo == StatusNoStatusReceived {
+			echo = StatusNormalClosure
+		}
+
+		c.sendClose(echo, "")
+		c.teardown(code)
+
+		return &CloseError{Code: code, Reason: reason}
+	}
+
+	return nil
+}
+
+// fail closes the connection with given status, returning err.
+func (c *Conn) fail(err error, code StatusCode) error {
+	select {
+	case <-c.done:
+		return ErrClosed
+	default:
+	}
+
+	if errors.Is(err, io.EOF) || errors.Is(err, net.ErrClosed) {
+		code = StatusAbnormalClosure
+	} else {
+		c.sendClose(code, "")
+	}
+
+	c.teardown(code)
+
+	return err
+}
+
+func (c *Conn) writeLoop() {
+	var ping <-chan time.Time
+
+	if c.conf.PingInterval > 0 {
+		ticker := time.NewTicker(c.conf.PingInterval)
+		defer ticker.Stop()
+
+		ping = ticker.C
+	}
+
+	for {
+		// Control frames take precedence over data frames
+		select {
+		case msg := <-c.control:
+			c.send(msg)
+
+			continue
+		default:
+		}
+
+		select {
+		case <-c.done:
+			return
+		case msg := <-c.control:
+			c.send(msg)
+		case msg := <-c.queue:
+			c.send(msg)
+		case <-ping:
+			lastPong := time.Unix(0, c.lastPong.Load())
+			if time.Since(lastPong) > c.conf.PingInterval+c.conf.PongTimeout {
+				c.teardown(StatusAbnormalClosure)
+
+				return
+			}
+
+			c.send(outgoing{opcode: opPing})
+		}
+	}
+}
+
+func (c *Conn) send(msg outgoing) {
+	if msg.written != nil {
+		defer close(msg.written)
+	}
+
+	payload, rsv1 := msg.payload, false
+
+	if !isControl(msg.opcode) && c.compressed && len(payload) >= compressionMinThreshold {
+		compressed, err := compress(payload)
+		if err == nil {
+			payload, rsv1 = compressed, true
+		}
+	}
+
+	_ = c.conn.SetWriteDeadline(time.Now().Add(frameWriteTimeout))
+
+	err := writeFrame(c.bw, true, rsv1, msg.opcode, payload)
+	if err != nil {
+		c.teardown(StatusAbnormalClosure)
+
+		return
+	}
+
+	switch msg.opcode {
+	case opText:
+		c.record("sent", MessageText, len(msg.payload))
+	case opBinary:
+		c.record("sent", MessageBinary, len(msg.payload))
+	}
+}
+
+func (c *Conn) record(direction string, typ MessageType, size int) {
+	attrs := metric.WithAttributes(
+		attribute.String("websocket.direction", direction),
+		attribute.String("websocket.message.type", typ.String()),
+	)
+
+	m := getMetrics()
+	m.messages.Add(c.c, 1, attrs)
+	m.messageSize.Record(c.c, int64(size), attrs)
+}
+
+// teardown closes the underlying connection and releases resources.
+func (c *Conn) teardown(code StatusCode) {
+	c.teardownOnce.Do(func() {
+		close(c.done)
+		_ = c.conn.Close()
+
+		connsMu.Lock()
+		delete(conns, c)
+		connsMu.Unlock()
+
+		getMetrics().connections.Add(c.c, -1)
+
+		c.span.SetAttributes(attribute.Int("websocket.close.code", int(code)))
+		c.span.End()
+	})
+}
@@ -0,0 +1,123 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package websocket
+
+import (
+	"bytes"
+	"compress/flate"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+const extensionDeflate = "permessage-deflate"
+
+// Trailer removed from compressed messages, see https://www.rfc-editor.org/rfc/rfc7692#section-7.2.1
+var deflateTail = []byte{0x00, 0x00, 0xff, 0xff}
+
+// Final empty stored block, making decompressor reach end of stream
+var deflateFinal = []byte{0x01, 0x00, 0x00, 0xff, 0xff}
+
+var flateWriterPool = sync.Pool{
+	New: func() any {
+		w, _ := flate.NewWriter(io.Discard, flate.BestSpeed)
+
+		return w
+	},
+}
+
+var flateReaderPool = sync.Pool{
+	New: func() any {
+		return flate.NewReader(bytes.NewReader(nil))
+	},
+}
+
+// negotiateDeflate returns the response extension header value if client offered a per-message deflate
+// configuration that is supported. No context takeover is used in both directions, so that each message
+// is compressed independently, which keeps per-connection memory low.
+func negotiateDeflate(offers []string) (string, bool) {
+	for _, header := range offers {
+		for offer := range strings.SplitSeq(header, ",") {
+			params := strings.Split(offer, ";")
+			if strings.TrimSpace(params[0]) != extensionDeflate {
+				continue
+			}
+
+			supported := true
+
+			for _, param := range params[1:] {
+				name, _, _ := strings.Cut(strings.TrimSpace(param), "=")
+
+				switch strings.TrimSpace(name) {
+				case "server_no_context_takeover", "client_no_context_takeover", "client_max_window_bits":
+				default:
+					// Custom server window size is not supported by compress/flate
+					supported = false
+				}
+			}
+
+			if supported {
+				return extensionDeflate + "; server_no_context_takeover; client_no_context_takeover", true
+			}
+		}
+	}
+
+	return "", false
+}
+
+func compress(payload []byte) ([]byte, error) {
+	var buf bytes.Buffer
+
+	fw, ok := flateWriterPool.Get().(*flate.Writer)
+	if !ok || fw == nil {
+		return nil, ErrFailureGetFromPool
+	}
+	defer flateWriterPool.Put(fw)
+
+	fw.Reset(&buf)
+
+	_, err := fw.Write(payload)
+	if err != nil {
+		return nil, fmt.Errorf("error compressing message: %w", err)
+	}
+
+	err = fw.Flush()
+	if err != nil {
+		return nil, fmt.Errorf("error compressing message: %w", err)
+	}
+
+	return bytes.TrimSuffix(buf.Bytes(), deflateTail), nil
+}
+
+func decompress(payload []byte, limit int64) ([]byte, error) {
+	fr, ok := flateReaderPool.Get().(io.ReadCloser)
+	if !ok || fr == nil {
+		return nil, ErrFailureGetFromPool
+	}
+	defer flateReaderPool.Put(fr)
+
+	err := fr.(flate.Resetter).Reset(
+		io.MultiReader(
+			bytes.NewReader(payload),
+			bytes.NewReader(deflateTail),
+			bytes.NewReader(deflateFinal),
+		),
+		nil,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error resetting decompressor: %w", err)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(fr, limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("error decompressing message: %w", err)
+	}
+
+	if int64(len(data)) > limit {
+		return nil, ErrMessageTooBig
+	}
+
+	return data, nil
+}
@@ -0,0 +1,10 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+// Package websocket implements the server side of the [WebSocket protocol], including [per-message deflate]
+// compression, keep-alive pings and bounded send queues providing backpressure. Connections are instrumented
+// using OpenTelemetry and are closed gracefully upon server shutdown.
+//
+// [WebSocket protocol]: https://www.rfc-editor.org/rfc/rfc6455
+// [per-message deflate]: https://www.rfc-editor.org/rfc/rfc7692
+package websocket
@@ -0,0 +1,155 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package websocket
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+const (
+	opContinuation byte = 0x0
+	opText         byte = 0x1
+	opBinary       byte = 0x2
+	opClose        byte = 0x8
+	opPing         byte = 0x9
+	opPong         byte = 0xa
+)
+
+const (
+	finBit  byte = 0x80
+	rsv1Bit byte = 0x40
+	rsv2Bit byte = 0x20
+	rsv3Bit byte = 0x10
+	maskBit byte = 0x80
+)
+
+// Control frames payload can't exceed 125 bytes, see https://www.rfc-editor.org/rfc/rfc6455#section-5.5
+const maxControlPayload = 125
+
+type frameHeader struct {
+	fin    bool
+	rsv1   bool
+	opcode byte
+	masked bool
+	mask   [4]byte
+	length int64
+}
+
+func isControl(opcode byte) bool {
+	return opcode&0x8 != 0
+}
+
+func readFrameHeader(br *bufio.Reader) (frameHeader, error) {
+	var h frameHeader
+
+	var b [8]byte
+
+	_, err := io.ReadFull(br, b[:2])
+	if err != nil {
+		return h, err
+	}
+
+	if b[0]&(rsv2Bit|rsv3Bit) != 0 {
+		return h, fmt.Errorf("reserved bits set: %w", ErrProtocol)
+	}
+
+	h.fin = b[0]&finBit != 0
+	h.rsv1 = b[0]&rsv1Bit != 0
+	h.opcode = b[0] & 0x0f
+	h.masked = b[1]&maskBit != 0
+
+	switch h.opcode {
+	case opContinuation, opText, opBinary, opClose, opPing, opPong:
+	default:
+		return h, fmt.Errorf("unknown opcode %#x: %w", h.opcode, ErrProtocol)
+	}
+
+	length := int64(b[1] &^ maskBit)
+
+	switch length {
+	case 126:
+		_, err = io.ReadFull(br, b[:2])
+		if err != nil {
+			return h, err
+		}
+
+		length = int64(binary.BigEndian.Uint16(b[:2]))
+	case 127:
+		_, err = io.ReadFull(br, b[:8])
+		if err != nil {
+			return h, err
+		}
+
+		u := binary.BigEndian.Uint64(b[:8])
+		if u>>63 != 0 {
+			return h, fmt.Errorf("invalid payload length: %w", ErrProtocol)
+		}
+
+		length = int64(u)
+	}
+
+	h.length = length
+
+	if isControl(h.opcode) && (!h.fin || h.length > maxControlPayload) {
+		return h, fmt.Errorf("invalid control frame: %w", ErrProtocol)
+	}
+
+	if h.masked {
+		_, err = io.ReadFull(br, h.mask[:])
+		if err != nil {
+			return h, err
+		}
+	}
+
+	return h, nil
+}
+
+func writeFrame(bw *bufio.Writer, fin, rsv1 bool, opcode byte, payload []byte) error {
+	b0 := opcode
+	if fin {
+		b0 |= finBit
+	}
+
+	if rsv1 {
+		b0 |= rsv1Bit
+	}
+
+	// Server frames are never masked
+	header := []byte{b0, 0}
+
+	switch n := len(payload); {
+	case n <= 125:
+		header[1] = byte(n)
+	case n <= 0xffff:
+		header[1] = 126
+		header = binary.BigEndian.AppendUint16(header, uint16(n))
+	default:
+		header[1] = 127
+		header = binary.BigEndian.AppendUint64(header, uint64(n))
+	}
+
+	_, err := bw.Write(header)
+	if err != nil {
+		return err
+	}
+
+	_, err = bw.Write(payload)
+	if err != nil {
+		return err
+	}
+
+	return bw.Flush()
+}
+
+func maskBytes(mask [4]byte, pos int, b []byte) int {
+	for i := range b {
+		b[i] ^= mask[pos&3]
+		pos++
+	}
+
+	return pos & 3
+}
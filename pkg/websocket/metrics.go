@@ -0,0 +1,67 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package websocket
+
+import (
+	"sync"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/noop"
+)
+
+type websocketMetrics struct {
+	messages    metric.Int64Counter
+	messageSize metric.Int64Histogram
+	connections metric.Int64UpDownCounter
+}
+
+var (
+	metrics     websocketMetrics
+	metricsOnce sync.Once
+)
+
+// getMetrics returns package metrics, creating them on first use so that they are created after the
+// meter provider has been set up.
+func getMetrics() websocketMetrics {
+	metricsOnce.Do(func() {
+		meter := otel.GetMeterProvider().Meter(packageName)
+		fallback := noop.NewMeterProvider().Meter(packageName)
+
+		messages, err := meter.Int64Counter(
+			"websocket.messages",
+			metric.WithDescription("Number of websocket messages"),
+			metric.WithUnit("{message}"),
+		)
+		if err != nil {
+			messages, _ = fallback.Int64Counter("websocket.messages")
+		}
+
+		messageSize, err := meter.Int64Histogram(
+			"websocket.message.size",
+			metric.WithDescription("Size of websocket messages payload"),
+			metric.WithUnit("By"),
+		)
+		if err != nil {
+			messageSize, _ = fallback.Int64Histogram("websocket.message.size")
+		}
+
+		connections, err := meter.Int64UpDownCounter(
+			"websocket.connections.active",
+			metric.WithDescription("Number of open websocket connections"),
+			metric.WithUnit("{connection}"),
+		)
+		if err != nil {
+			connections, _ = fallback.Int64UpDownCounter("websocket.connections.active")
+		}
+
+		metrics = websocketMetrics{
+			messages:    messages,
+			messageSize: messageSize,
+			connections: connections,
+		}
+	})
+
+	return metrics
+}
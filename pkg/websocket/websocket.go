@@ -0,0 +1,353 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package websocket
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kemadev/go-framework/pkg/convenience/headkey"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const packageName = "github.com/kemadev/go-framework/pkg/websocket"
+
+var (
+	ErrNotWebSocket       = errors.New("request is not a websocket upgrade")
+	ErrVersionUnsupported = errors.New("websocket version is not supported")
+	ErrOriginNotAllowed   = errors.New("cross-origin websocket request is not allowed")
+	ErrHijackUnsupported  = errors.New("response writer does not support hijacking")
+	ErrProtocol           = errors.New("websocket protocol error")
+	ErrMessageTooBig      = errors.New("websocket message too big")
+	ErrInvalidUTF8        = errors.New("websocket text message is not valid UTF-8")
+	ErrClosed             = errors.New("websocket connection is closed")
+	ErrFailureGetFromPool = errors.New("can't get a component from pool")
+)
+
+// StatusCode is a close status code, see https://www.rfc-editor.org/rfc/rfc6455#section-7.4.
+type StatusCode uint16
+
+const (
+	StatusNormalClosure      StatusCode = 1000
+	StatusGoingAway          StatusCode = 1001
+	StatusProtocolError      StatusCode = 1002
+	StatusUnsupportedData    StatusCode = 1003
+	StatusNoStatusReceived   StatusCode = 1005
+	StatusAbnormalClosure    StatusCode = 1006
+	StatusInvalidPayloadData StatusCode = 1007
+	StatusPolicyViolation    StatusCode = 1008
+	StatusMessageTooBig      StatusCode = 1009
+	StatusInternalError      StatusCode = 1011
+)
+
+// valid returns whether code can be sent in a close frame, that is, it is a registered code not reserved for
+// local use (1005, 1006 and 1015), or a code reserved for libraries, frameworks and applications, see
+// https://www.rfc-editor.org/rfc/rfc6455#section-7.4.2 and
+// https://www.iana.org/assignments/websocket/websocket.xhtml#close-code-number.
+func (code StatusCode) valid() bool {
+	switch {
+	case code >= 1000 && code <= 1003, code >= 1007 && code <= 1014:
+		return true
+	default:
+		return code >= 3000 && code <= 4999
+	}
+}
+
+// CloseError is returned when the connection has been closed by the peer.
+type CloseError struct {
+	Code   StatusCode
+	Reason string
+}
+
+// Error implements [error].
+func (e *CloseError) Error() string {
+	return fmt.Sprintf("websocket closed with status %d: %s", e.Code, e.Reason)
+}
+
+// Default configuration values.
+const (
+	DefaultReadLimit     = 1 << 20
+	DefaultSendQueueSize = 16
+	DefaultPingInterval  = 30 * time.Second
+	DefaultPongTimeout   = 10 * time.Second
+	DefaultCloseTimeout  = 5 * time.Second
+)
+
+// https://www.rfc-editor.org/rfc/rfc6455#section-1.3
+const acceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// Config defines the configuration for websocket upgrades and connections.
+type Config struct {
+	// TrustedOrigins are origins (e.g. https://example.com), besides request's own host, allowed to open connections
+	TrustedOrigins []string
+	// Subprotocols are supported subprotocols, by order of preference
+	Subprotocols []string
+	// EnableCompression enables per-message deflate when client supports it
+	EnableCompression bool
+	// ReadLimit is the maximum size of incoming messages, after decompression
+	ReadLimit int64
+	// SendQueueSize is the number of outgoing messages that can be queued before writes block
+	SendQueueSize int
+	// PingInterval is the interval between pings sent to the client, pings are disabled when negative
+	PingInterval time.Duration
+	// PongTimeout is the maximum duration to wait for a pong after a ping before closing the connection
+	PongTimeout time.Duration
+	// CloseTimeout is the maximum duration to wait for the closing handshake to complete
+	CloseTimeout time.Duration
+}
+
+var (
+	conns   = make(map[*Conn]struct{})
+	connsMu sync.Mutex
+)
+
+// Upgrade upgrades the HTTP connection to the WebSocket protocol, with default configuration.
+func Upgrade(w http.ResponseWriter, r *http.Request) (*Conn, error) {
+	return UpgradeWithConfig(w, r, Config{})
+}
+
+// UpgradeWithConfig upgrades the HTTP connection to the WebSocket protocol, with custom configuration.
+// Cross-origin requests are rejected unless their origin is trusted, as browsers do not apply same-origin
+// policy to websockets, and [net/http.CrossOriginProtection] does not check GET requests. Upon failure, an
+// appropriate HTTP error response is sent, or the connection is closed once hijacked, and an error is returned.
+// Headers already set on w (e.g. by [github.com/kemadev/go-framework/pkg/convenience/sechead] middleware) are
+// sent along with the handshake response.
+func UpgradeWithConfig(w http.ResponseWriter, r *http.Request, conf Config) (*Conn, error) {
+	conf = withDefaults(conf)
+
+	if r.Method != http.MethodGet || !IsUpgrade(r) {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+
+		return nil, ErrNotWebSocket
+	}
+
+	if r.Header.Get(headkey.SecWebSocketVersion) != "13" {
+		w.Header().Set(headkey.SecWebSocketVersion, "13")
+		http.Error(w, http.StatusText(http.StatusUpgradeRequired), http.StatusUpgradeRequired)
+
+		return nil, ErrVersionUnsupported
+	}
+
+	key := r.Header.Get(headkey.SecWebSocketKey)
+
+	decodedKey, err := base64.StdEncoding.DecodeString(key)
+	if err != nil || len(decodedKey) != 16 {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+
+		return nil, fmt.Errorf("invalid websocket key: %w", ErrNotWebSocket)
+	}
+
+	if !originAllowed(r, conf.TrustedOrigins) {
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+
+		return nil, ErrOriginNotAllowed
+	}
+
+	subprotocol := selectSubprotocol(r, conf.Subprotocols)
+
+	extension, compressed := "", false
+	if conf.EnableCompression {
+		extension, compressed = negotiateDeflate(r.Header.Values(headkey.SecWebSocketExtensions))
+	}
+
+	netConn, brw, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+
+		if errors.Is(err, http.ErrNotSupported) {
+			return nil, ErrHijackUnsupported
+		}
+
+		return nil, fmt.Errorf("error hijacking connection: %w", err)
+	}
+
+	// Server read / write timeouts do not apply to long-lived connections
+	err = netConn.SetDeadline(time.Time{})
+	if err != nil {
+		netConn.Close()
+
+		return nil, fmt.Errorf("error clearing connection deadline: %w", err)
+	}
+
+	head := w.Header().Clone()
+	head.Del(headkey.ContentType)
+	head.Del(headkey.ContentLength)
+	head.Del(headkey.ContentEncoding)
+	head.Del(headkey.Vary)
+	head.Set(headkey.Upgrade, "websocket")
+	head.Set("Connection", "Upgrade")
+	head.Set(headkey.SecWebSocketAccept, acceptKey(key))
+
+	if subprotocol != "" {
+		head.Set(headkey.SecWebSocketProtocol, subprotocol)
+	}
+
+	if compressed {
+		head.Set(headkey.SecWebSocketExtensions, extension)
+	}
+
+	_, err = brw.WriteString("HTTP/1.1 101 Switching Protocols\r\n")
+	if err == nil {
+		err = head.Write(brw)
+	}
+
+	if err == nil {
+		_, err = brw.WriteString("\r\n")
+	}
+
+	if err == nil {
+		err = brw.Flush()
+	}
+
+	if err != nil {
+		netConn.Close()
+
+		return nil, fmt.Errorf("error writing handshake response: %w", err)
+	}
+
+	c, span := otel.Tracer(packageName).Start(
+		context.WithoutCancel(r.Context()),
+		"websocket.connection",
+		trace.WithNewRoot(),
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithLinks(trace.LinkFromContext(r.Context())),
+		trace.WithAttributes(
+			attribute.String("network.protocol.name", "websocket"),
+			attribute.String("websocket.subprotocol", subprotocol),
+			attribute.Bool("websocket.compression", compressed),
+		),
+	)
+
+	conn := newConn(c, netConn, brw.Reader, conf, subprotocol, compressed, span)
+
+	connsMu.Lock()
+	conns[conn] = struct{}{}
+	connsMu.Unlock()
+
+	return conn, nil
+}
+
+// IsUpgrade returns whether r asks for a websocket upgrade.
+func IsUpgrade(r *http.Request) bool {
+	return headerContainsToken(r.Header, "Connection", "upgrade") &&
+		headerContainsToken(r.Header, headkey.Upgrade, "websocket")
+}
+
+// Drain closes all open connections with [StatusGoingAway]. It is meant to be registered using
+// [net/http.Server.RegisterOnShutdown], as hijacked connections are not tracked by the server.
+func Drain() {
+	connsMu.Lock()
+	open := make([]*Conn, 0, len(conns))
+
+	for c := range conns {
+		open = append(open, c)
+	}
+	connsMu.Unlock()
+
+	var wg sync.WaitGroup
+
+	for _, c := range open {
+		wg.Go(func() {
+			_ = c.Close(StatusGoingAway, "server shutting down")
+		})
+	}
+
+	wg.Wait()
+}
+
+func withDefaults(conf Config) Config {
+	if conf.ReadLimit <= 0 {
+		conf.ReadLimit = DefaultReadLimit
+	}
+
+	if conf.SendQueueSize <= 0 {
+		conf.SendQueueSize = DefaultSendQueueSize
+	}
+
+	if conf.PingInterval == 0 {
+		conf.PingInterval = DefaultPingInterval
+	}
+
+	if conf.PongTimeout <= 0 {
+		conf.PongTimeout = DefaultPongTimeout
+	}
+
+	if conf.CloseTimeout <= 0 {
+		conf.CloseTimeout = DefaultCloseTimeout
+	}
+
+	return conf
+}
+
+func acceptKey(key string) string {
+	h := sha1.New()
+	h.Write([]byte(key + acceptGUID))
+
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+func originAllowed(r *http.Request, trusted []string) bool {
+	switch r.Header.Get(headkey.SecFetchSite) {
+	case "same-origin", "none":
+		return true
+	}
+
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		// Non-browser clients
+		return true
+	}
+
+	if slices.Contains(trusted, origin) {
+		return true
+	}
+
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+
+	return strings.EqualFold(u.Host, r.Host)
+}
+
+func selectSubprotocol(r *http.Request, supported []string) string {
+	var offered []string
+
+	for _, header := range r.Header.Values(headkey.SecWebSocketProtocol) {
+		for proto := range strings.SplitSeq(header, ",") {
+			offered = append(offered, strings.TrimSpace(proto))
+		}
+	}
+
+	for _, proto := range supported {
+		if slices.Contains(offered, proto) {
+			return proto
+		}
+	}
+
+	return ""
+}
+
+func headerContainsToken(h http.Header, key, token string) bool {
+	for _, value := range h.Values(key) {
+		for v := range strings.SplitSeq(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(v), token) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
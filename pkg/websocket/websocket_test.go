@@ -0,0 +1,729 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package websocket_test
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kemadev/go-framework/pkg/convenience/headkey"
+	"github.com/kemadev/go-framework/pkg/websocket"
+)
+
+const (
+	opText  byte = 0x1
+	opClose byte = 0x8
+	opPing  byte = 0x9
+	opPong  byte = 0xa
+)
+
+// sampleKey is the handshake key of https://www.rfc-editor.org/rfc/rfc6455#section-1.3.
+const sampleKey = "dGhlIHNhbXBsZSBub25jZQ=="
+
+func TestUpgradeHandshake(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Name           string
+		Method         string
+		Header         map[string]string
+		ExpectedStatus int
+		ExpectedErr    error
+	}{
+		{
+			Name:           "not an upgrade",
+			Method:         http.MethodGet,
+			Header:         map[string]string{headkey.SecWebSocketVersion: "13", headkey.SecWebSocketKey: sampleKey},
+			ExpectedStatus: http.StatusBadRequest,
+			ExpectedErr:    websocket.ErrNotWebSocket,
+		},
+		{
+			Name:   "not a GET",
+			Method: http.MethodPost,
+			Header: map[string]string{
+				"Connection":                "Upgrade",
+				headkey.Upgrade:             "websocket",
+				headkey.SecWebSocketVersion: "13",
+				headkey.SecWebSocketKey:     sampleKey,
+			},
+			ExpectedStatus: http.StatusBadRequest,
+			ExpectedErr:    websocket.ErrNotWebSocket,
+		},
+		{
+			Name:   "unsupported version",
+			Method: http.MethodGet,
+			Header: map[string]string{
+				"Connection":                "keep-alive, Upgrade",
+				headkey.Upgrade:             "websocket",
+				headkey.SecWebSocketVersion: "8",
+				headkey.SecWebSocketKey:     sampleKey,
+			},
+			ExpectedStatus: http.StatusUpgradeRequired,
+			ExpectedErr:    websocket.ErrVersionUnsupported,
+		},
+		{
+			Name:   "invalid key",
+			Method: http.MethodGet,
+			Header: map[string]string{
+				"Connection":                "Upgrade",
+				headkey.Upgrade:             "websocket",
+				headkey.SecWebSocketVersion: "13",
+				headkey.SecWebSocketKey:     "c2hvcnQ=",
+			},
+			ExpectedStatus: http.StatusBadRequest,
+			ExpectedErr:    websocket.ErrNotWebSocket,
+		},
+		{
+			Name:   "cross-origin",
+			Method: http.MethodGet,
+			Header: map[string]string{
+				"Connection":                "Upgrade",
+				headkey.Upgrade:             "websocket",
+				headkey.SecWebSocketVersion: "13",
+				headkey.SecWebSocketKey:     sampleKey,
+				"Origin":                    "https://evil.example.org",
+			},
+			ExpectedStatus: http.StatusForbidden,
+			ExpectedErr:    websocket.ErrOriginNotAllowed,
+		},
+		{
+			Name:   "hijacking unsupported",
+			Method: http.MethodGet,
+			Header: map[string]string{
+				"Connection":                "Upgrade",
+				headkey.Upgrade:             "websocket",
+				headkey.SecWebSocketVersion: "13",
+				headkey.SecWebSocketKey:     sampleKey,
+				"Origin":                    "https://trusted.example.org",
+			},
+			ExpectedStatus: http.StatusInternalServerError,
+			ExpectedErr:    websocket.ErrHijackUnsupported,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(tt.Method, "http://example.com/ws", nil)
+			for k, v := range tt.Header {
+				req.Header.Set(k, v)
+			}
+
+			rr := httptest.NewRecorder()
+
+			_, err := websocket.UpgradeWithConfig(rr, req, websocket.Config{
+				TrustedOrigins: []string{"https://trusted.example.org"},
+			})
+			if !errors.Is(err, tt.ExpectedErr) {
+				t.Errorf("expected error %v but was %v", tt.ExpectedErr, err)
+			}
+
+			if rr.Code != tt.ExpectedStatus {
+				t.Errorf("expected status %d but was %d", tt.ExpectedStatus, rr.Code)
+			}
+		})
+	}
+}
+
+// echoServer returns a server echoing messages, sending read errors to returned channel.
+func echoServer(t *testing.T, conf websocket.Config) (*httptest.Server, <-chan error) {
+	t.Helper()
+
+	errs := make(chan error, 1)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := websocket.UpgradeWithConfig(w, r, conf)
+		if err != nil {
+			errs <- err
+
+			return
+		}
+		defer conn.Close(websocket.StatusNormalClosure, "")
+
+		for {
+			typ, msg, err := conn.Read(r.Context())
+			if err != nil {
+				errs <- err
+
+				return
+			}
+
+			err = conn.Write(r.Context(), typ, msg)
+			if err != nil {
+				errs <- err
+
+				return
+			}
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, errs
+}
+
+type client struct {
+	t    *testing.T
+	conn net.Conn
+	br   *bufio.Reader
+	resp *http.Response
+}
+
+// dial opens a connection to [srv], sending additional [header] in handshake request.
+func dial(t *testing.T, srv *httptest.Server, header map[string]string) *client {
+	t.Helper()
+
+	conn, err := net.Dial("tcp", srv.Listener.Addr().String())
+	if err != nil {
+		t.Fatalf("Dial: %s", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set(headkey.Upgrade, "websocket")
+	req.Header.Set(headkey.SecWebSocketVersion, "13")
+	req.Header.Set(headkey.SecWebSocketKey, sampleKey)
+
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+
+	err = req.Write(conn)
+	if err != nil {
+		t.Fatalf("error writing handshake: %s", err)
+	}
+
+	br := bufio.NewReader(conn)
+
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		t.Fatalf("error reading handshake: %s", err)
+	}
+
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("expected status %d but was %d", http.StatusSwitchingProtocols, resp.StatusCode)
+	}
+
+	return &client{t: t, conn: conn, br: br, resp: resp}
+}
+
+// write sends a masked frame, unless [unmasked] is set.
+func (c *client) write(fin bool, rsv1 bool, opcode byte, payload []byte, unmasked bool) {
+	c.t.Helper()
+
+	b0 := opcode
+	if fin {
+		b0 |= 0x80
+	}
+
+	if rsv1 {
+		b0 |= 0x40
+	}
+
+	frame := []byte{b0}
+
+	switch n := len(payload); {
+	case n <= 125:
+		frame = append(frame, byte(n))
+	case n <= 0xffff:
+		frame = binary.BigEndian.AppendUint16(append(frame, 126), uint16(n))
+	default:
+		frame = binary.BigEndian.AppendUint64(append(frame, 127), uint64(n))
+	}
+
+	masked := append([]byte(nil), payload...)
+
+	if !unmasked {
+		frame[1] |= 0x80
+		mask := [4]byte{0x12, 0x34, 0x56, 0x78}
+		frame = append(frame, mask[:]...)
+
+		for i := range masked {
+			masked[i] ^= mask[i%4]
+		}
+	}
+
+	_, err := c.conn.Write(append(frame, masked...))
+	if err != nil {
+		c.t.Fatalf("error writing frame: %s", err)
+	}
+}
+
+// read returns opcode and payload of next frame sent by server.
+func (c *client) read() (byte, []byte) {
+	c.t.Helper()
+
+	var b [8]byte
+
+	_, err := io.ReadFull(c.br, b[:2])
+	if err != nil {
+		c.t.Fatalf("error reading frame: %s", err)
+	}
+
+	if b[1]&0x80 != 0 {
+		c.t.Fatal("server frame is masked")
+	}
+
+	length := int(b[1] & 0x7f)
+
+	switch length {
+	case 126:
+		_, _ = io.ReadFull(c.br, b[:2])
+		length = int(binary.BigEndian.Uint16(b[:2]))
+	case 127:
+		_, _ = io.ReadFull(c.br, b[:8])
+		length = int(binary.BigEndian.Uint64(b[:8]))
+	}
+
+	payload := make([]byte, length)
+
+	_, err = io.ReadFull(c.br, payload)
+	if err != nil {
+		c.t.Fatalf("error reading frame payload: %s", err)
+	}
+
+	return b[0] & 0x0f, payload
+}
+
+// expectClose reads frames until a close frame, returning its status code.
+func (c *client) expectClose() websocket.StatusCode {
+	c.t.Helper()
+
+	for {
+		opcode, payload := c.read()
+		if opcode != opClose {
+			continue
+		}
+
+		if len(payload) < 2 {
+			c.t.Fatalf("close frame payload too short: %v", payload)
+		}
+
+		return websocket.StatusCode(binary.BigEndian.Uint16(payload))
+	}
+}
+
+func closePayload(code uint16, reason string) []byte {
+	return append(binary.BigEndian.AppendUint16(nil, code), reason...)
+}
+
+func TestHandshake(t *testing.T) {
+	t.Parallel()
+
+	srv, _ := echoServer(t, websocket.Config{
+		Subprotocols: []string{"v2.example", "v1.example"},
+		PingInterval: -1,
+	})
+
+	c := dial(t, srv, map[string]string{headkey.SecWebSocketProtocol: "v1.example, v2.example"})
+
+	// https://www.rfc-editor.org/rfc/rfc6455#section-1.3
+	if c.resp.Header.Get(headkey.SecWebSocketAccept) != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Errorf("unexpected accept key %q", c.resp.Header.Get(headkey.SecWebSocketAccept))
+	}
+
+	if c.resp.Header.Get(headkey.SecWebSocketProtocol) != "v2.example" {
+		t.Errorf("expected subprotocol %q but was %q", "v2.example", c.resp.Header.Get(headkey.SecWebSocketProtocol))
+	}
+
+	if c.resp.Header.Get(headkey.SecWebSocketExtensions) != "" {
+		t.Errorf("expected no extension but was %q", c.resp.Header.Get(headkey.SecWebSocketExtensions))
+	}
+}
+
+func TestFrames(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Name          string
+		Send          func(c *client)
+		ExpectedCode  websocket.StatusCode
+		ExpectedEcho  string
+		ExpectedError error
+	}{
+		{
+			Name: "fragmented message with interleaved ping",
+			Send: func(c *client) {
+				c.write(false, false, opText, []byte("hello "), false)
+				c.write(true, false, opPing, []byte("ping"), false)
+				c.write(true, false, 0x0, []byte("world"), false)
+			},
+			ExpectedEcho: "hello world",
+		},
+		{
+			Name: "unmasked frame",
+			Send: func(c *client) {
+				c.write(true, false, opText, []byte("hello"), true)
+			},
+			ExpectedCode:  websocket.StatusProtocolError,
+			ExpectedError: websocket.ErrProtocol,
+		},
+		{
+			Name: "unexpected continuation",
+			Send: func(c *client) {
+				c.write(true, false, 0x0, []byte("hello"), false)
+			},
+			ExpectedCode:  websocket.StatusProtocolError,
+			ExpectedError: websocket.ErrProtocol,
+		},
+		{
+			Name: "interleaved data message",
+			Send: func(c *client) {
+				c.write(false, false, opText, []byte("hello"), false)
+				c.write(true, false, opText, []byte("world"), false)
+			},
+			ExpectedCode:  websocket.StatusProtocolError,
+			ExpectedError: websocket.ErrProtocol,
+		},
+		{
+			Name: "oversized control frame",
+			Send: func(c *client) {
+				c.write(true, false, opPing, bytes.Repeat([]byte("a"), 126), false)
+			},
+			ExpectedCode:  websocket.StatusProtocolError,
+			ExpectedError: websocket.ErrProtocol,
+		},
+		{
+			Name: "fragmented control frame",
+			Send: func(c *client) {
+				c.write(false, false, opPing, []byte("ping"), false)
+			},
+			ExpectedCode:  websocket.StatusProtocolError,
+			ExpectedError: websocket.ErrProtocol,
+		},
+		{
+			Name: "compressed frame without extension",
+			Send: func(c *client) {
+				c.write(true, true, opText, []byte("hello"), false)
+			},
+			ExpectedCode:  websocket.StatusProtocolError,
+			ExpectedError: websocket.ErrProtocol,
+		},
+		{
+			Name: "invalid UTF-8",
+			Send: func(c *client) {
+				c.write(true, false, opText, []byte{0xff, 0xfe}, false)
+			},
+			ExpectedCode:  websocket.StatusInvalidPayloadData,
+			ExpectedError: websocket.ErrInvalidUTF8,
+		},
+		{
+			Name: "message too big",
+			Send: func(c *client) {
+				c.write(true, false, opText, bytes.Repeat([]byte("a"), 65), false)
+			},
+			ExpectedCode:  websocket.StatusMessageTooBig,
+			ExpectedError: websocket.ErrMessageTooBig,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			t.Parallel()
+
+			srv, errs := echoServer(t, websocket.Config{ReadLimit: 64, PingInterval: -1})
+			c := dial(t, srv, nil)
+
+			tt.Send(c)
+
+			if tt.ExpectedEcho != "" {
+				opcode, payload := c.read()
+				if opcode == opPong {
+					if string(payload) != "ping" {
+						t.Errorf("expected pong payload %q but was %q", "ping", payload)
+					}
+
+					opcode, payload = c.read()
+				}
+
+				if opcode != opText || string(payload) != tt.ExpectedEcho {
+					t.Errorf("expected echo %q but was %q (opcode %#x)", tt.ExpectedEcho, payload, opcode)
+				}
+
+				return
+			}
+
+			code := c.expectClose()
+			if code != tt.ExpectedCode {
+				t.Errorf("expected close code %d but was %d", tt.ExpectedCode, code)
+			}
+
+			err := <-errs
+			if !errors.Is(err, tt.ExpectedError) {
+				t.Errorf("expected error %v but was %v", tt.ExpectedError, err)
+			}
+		})
+	}
+}
+
+func TestCloseHandshake(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Name         string
+		Payload      []byte
+		ExpectedCode websocket.StatusCode
+		ExpectedErr  *websocket.CloseError
+	}{
+		{
+			Name:         "normal closure",
+			Payload:      closePayload(1000, "bye"),
+			ExpectedCode: websocket.StatusNormalClosure,
+			ExpectedErr:  &websocket.CloseError{Code: websocket.StatusNormalClosure, Reason: "bye"},
+		},
+		{
+			Name:         "no status",
+			Payload:      nil,
+			ExpectedCode: websocket.StatusNormalClosure,
+			ExpectedErr:  &websocket.CloseError{Code: websocket.StatusNoStatusReceived},
+		},
+		{
+			Name:         "registered code",
+			Payload:      closePayload(1012, ""),
+			ExpectedCode: 1012,
+			ExpectedErr:  &websocket.CloseError{Code: 1012},
+		},
+		{
+			Name:         "application code",
+			Payload:      closePayload(4999, ""),
+			ExpectedCode: 4999,
+			ExpectedErr:  &websocket.CloseError{Code: 4999},
+		},
+		{Name: "one byte payload", Payload: []byte{0x03}, ExpectedCode: websocket.StatusProtocolError},
+		{Name: "no status code sent", Payload: closePayload(1005, ""), ExpectedCode: websocket.StatusProtocolError},
+		{Name: "abnormal closure sent", Payload: closePayload(1006, ""), ExpectedCode: websocket.StatusProtocolError},
+		{Name: "TLS handshake code sent", Payload: closePayload(1015, ""), ExpectedCode: websocket.StatusProtocolError},
+		{Name: "unassigned code", Payload: closePayload(2000, ""), ExpectedCode: websocket.StatusProtocolError},
+		{Name: "code below range", Payload: closePayload(999, ""), ExpectedCode: websocket.StatusProtocolError},
+		{Name: "code above range", Payload: closePayload(5000, ""), ExpectedCode: websocket.StatusProtocolError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			t.Parallel()
+
+			srv, errs := echoServer(t, websocket.Config{PingInterval: -1})
+			c := dial(t, srv, nil)
+
+			c.write(true, false, opClose, tt.Payload, false)
+
+			code := c.expectClose()
+			if code != tt.ExpectedCode {
+				t.Errorf("expected close code %d but was %d", tt.ExpectedCode, code)
+			}
+
+			err := <-errs
+			if tt.ExpectedErr == nil {
+				return
+			}
+
+			var closeErr *websocket.CloseError
+			if !errors.As(err, &closeErr) || *closeErr != *tt.ExpectedErr {
+				t.Errorf("expected error %v but was %v", tt.ExpectedErr, err)
+			}
+		})
+	}
+}
+
+func TestServerClose(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Name           string
+		Code           websocket.StatusCode
+		Reason         string
+		ExpectedCode   websocket.StatusCode
+		ExpectedReason string
+	}{
+		{
+			Name:           "going away",
+			Code:           websocket.StatusGoingAway,
+			Reason:         "bye",
+			ExpectedCode:   websocket.StatusGoingAway,
+			ExpectedReason: "bye",
+		},
+		{
+			Name:           "no status code",
+			Code:           websocket.StatusNoStatusReceived,
+			Reason:         "bye",
+			ExpectedCode:   websocket.StatusNormalClosure,
+			ExpectedReason: "bye",
+		},
+		{
+			Name:         "abnormal closure",
+			Code:         websocket.StatusAbnormalClosure,
+			ExpectedCode: websocket.StatusNormalClosure,
+		},
+		{
+			Name:         "code out of range",
+			Code:         5000,
+			ExpectedCode: websocket.StatusNormalClosure,
+		},
+		{
+			Name:         "long reason",
+			Code:         websocket.StatusGoingAway,
+			Reason:       strings.Repeat("é", 100),
+			ExpectedCode: websocket.StatusGoingAway,
+			// 123 bytes are available, truncated on a rune boundary
+			ExpectedReason: strings.Repeat("é", 61),
+		},
+		{
+			Name:           "invalid UTF-8 reason",
+			Code:           websocket.StatusGoingAway,
+			Reason:         "b\xffye",
+			ExpectedCode:   websocket.StatusGoingAway,
+			ExpectedReason: "bye",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			t.Parallel()
+
+			closed := make(chan error, 1)
+
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				conn, err := websocket.UpgradeWithConfig(
+					w,
+					r,
+					websocket.Config{PingInterval: -1, CloseTimeout: time.Second},
+				)
+				if err != nil {
+					closed <- err
+
+					return
+				}
+
+				closed <- conn.Close(tt.Code, tt.Reason)
+			}))
+			t.Cleanup(srv.Close)
+
+			c := dial(t, srv, nil)
+
+			opcode, payload := c.read()
+			if opcode != opClose || !bytes.Equal(payload, closePayload(uint16(tt.ExpectedCode), tt.ExpectedReason)) {
+				t.Fatalf("expected close frame but got opcode %#x with payload %q", opcode, payload)
+			}
+
+			// Complete closing handshake
+			c.write(true, false, opClose, payload[:2], false)
+
+			select {
+			case err := <-closed:
+				if err != nil {
+					t.Errorf("Close: %s", err)
+				}
+			case <-time.After(time.Second / 2):
+				t.Error("expected close to complete upon peer close frame, before close timeout")
+			}
+		})
+	}
+}
+
+// deflate compresses [data] as a permessage-deflate message, see https://www.rfc-editor.org/rfc/rfc7692.
+func deflate(t *testing.T, data []byte) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+
+	fw, _ := flate.NewWriter(&buf, flate.BestCompression)
+
+	_, err := fw.Write(data)
+	if err == nil {
+		err = fw.Flush()
+	}
+
+	if err != nil {
+		t.Fatalf("error compressing message: %s", err)
+	}
+
+	return bytes.TrimSuffix(buf.Bytes(), []byte{0x00, 0x00, 0xff, 0xff})
+}
+
+func TestDeflate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Name          string
+		Message       string
+		ExpectedCode  websocket.StatusCode
+		ExpectedError error
+	}{
+		{Name: "within limit", Message: strings.Repeat("hello ", 100)},
+		{
+			Name:          "exceeding limit once decompressed",
+			Message:       strings.Repeat("a", 1<<16),
+			ExpectedCode:  websocket.StatusMessageTooBig,
+			ExpectedError: websocket.ErrMessageTooBig,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			t.Parallel()
+
+			srv, errs := echoServer(t, websocket.Config{
+				EnableCompression: true,
+				ReadLimit:         1024,
+				PingInterval:      -1,
+			})
+			c := dial(t, srv, map[string]string{
+				headkey.SecWebSocketExtensions: "permessage-deflate; client_max_window_bits",
+			})
+
+			extension := c.resp.Header.Get(headkey.SecWebSocketExtensions)
+			if !strings.HasPrefix(extension, "permessage-deflate") {
+				t.Fatalf("expected permessage-deflate extension but was %q", extension)
+			}
+
+			compressed := deflate(t, []byte(tt.Message))
+			if len(compressed) > 1024 {
+				t.Fatalf("compressed message must fit read limit, was %d bytes", len(compressed))
+			}
+
+			c.write(true, true, opText, compressed, false)
+
+			if tt.ExpectedCode == 0 {
+				opcode, payload := c.read()
+				if opcode != opText {
+					t.Fatalf("expected text frame but was opcode %#x", opcode)
+				}
+
+				// Echo is compressed as well, as it exceeds compression threshold
+				data, err := io.ReadAll(flate.NewReader(io.MultiReader(
+					bytes.NewReader(payload),
+					bytes.NewReader([]byte{0x00, 0x00, 0xff, 0xff, 0x01, 0x00, 0x00, 0xff, 0xff}),
+				)))
+				if err != nil || string(data) != tt.Message {
+					t.Errorf("expected echo of message but got %d bytes (error %v)", len(data), err)
+				}
+
+				return
+			}
+
+			code := c.expectClose()
+			if code != tt.ExpectedCode {
+				t.Errorf("expected close code %d but was %d", tt.ExpectedCode, code)
+			}
+
+			err := <-errs
+			if !errors.Is(err, tt.ExpectedError) {
+				t.Errorf("expected error %v but was %v", tt.ExpectedError, err)
+			}
+		})
+	}
+}
@@ -11,7 +11,7 @@ var static embed.FS
 
 const TemplateBaseDirName = "tmpl"
 
-//go:embed tmpl/*.html
+//go:embed tmpl
 var tmpl embed.FS
 
 // GetStaticFS returns static assets as an [embed.FS]